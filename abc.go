@@ -0,0 +1,248 @@
+package guitar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	// abcUnitDivisions is the length of the unit note, an eighth, in
+	// score divisions.
+	abcUnitDivisions = musicXMLDivisions / 2
+
+	abcMeasuresPerLine = 4
+)
+
+var (
+	abcSharpNames = []string{"C", "^C", "D", "^D", "E", "F", "^F", "G", "^G", "A", "^A", "B"}
+	abcFlatNames  = []string{"C", "_D", "D", "_E", "E", "F", "_G", "G", "_A", "A", "_B", "B"}
+)
+
+// abcWriter spells the notes of a tune in its key, remembering the
+// accidentals written in the current bar.
+type abcWriter struct {
+	flats       bool
+	accidentals map[byte]int
+	barNotes    map[string]int
+}
+
+// WriteABC writes a track of the song as an ABC tune at sounding pitch,
+// with its tempo, meters and key. Notes played together are written as
+// chords and dead notes as rests. Rhythms are written on the grid of 64th
+// notes of the score; tuplets are an error.
+func (s *Song) WriteABC(w io.Writer, track int) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	if track < 0 || track >= len(s.Tracks) {
+		return fmt.Errorf("invalid track index %d, song has %d tracks", track, len(s.Tracks))
+	}
+	t := s.Tracks[track]
+
+	timing := s.Timing
+	timing.Sort()
+
+	key, fifths := "C", 0
+	if s.Key != "" {
+		f, minor, err := s.KeySignature()
+		if err != nil {
+			return err
+		}
+		key, fifths = keyName(f, minor), f
+	}
+
+	fb, err := t.FingerBoard()
+	if err != nil {
+		return err
+	}
+	mw := musicXMLWriter{timing: timing, fb: fb}
+	notes, err := mw.scoreNotes(t.Events)
+	if err != nil {
+		return fmt.Errorf("track %q: %w", t.Name, err)
+	}
+	end := 0
+	for _, n := range notes {
+		end = max(end, n.start+n.length)
+	}
+	measures := scoreMeasures(timing, end)
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "X:1\nT:%s\n", s.Title)
+	if s.Artist != "" {
+		fmt.Fprintf(bw, "C:%s\n", s.Artist)
+	}
+	fmt.Fprintf(bw, "M:%s\nL:1/8\nQ:%s\nK:%s\n", measures[0].signature, abcTempo(s.Tempo(0)), key)
+
+	aw := abcWriter{flats: fifths < 0, accidentals: map[byte]int{}}
+	for i := 0; i < fifths; i++ {
+		aw.accidentals["FCGDAEB"[i]] = 1
+	}
+	for i := 0; i < -fifths; i++ {
+		aw.accidentals["BEADGCF"[i]] = -1
+	}
+
+	for i, m := range measures {
+		aw.barNotes = map[string]int{}
+		if i > 0 && m.changed {
+			fmt.Fprintf(bw, "[M:%s] ", m.signature)
+		}
+
+		tempos := []TempoChange{}
+		for _, c := range timing.Tempos {
+			if pos := divisionsAt(timing, c.Time); pos > 0 && pos >= m.start && pos < m.end {
+				tempos = append(tempos, c)
+			}
+		}
+
+		beats := layoutVoice(notes, m)
+		if len(beats) == 0 {
+			beats = restBeats(m.end - m.start)
+		}
+		pos := m.start
+		symbols := []string{}
+		for _, b := range beats {
+			for len(tempos) > 0 && divisionsAt(timing, tempos[0].Time) <= pos {
+				symbols = append(symbols, "[Q:"+abcTempo(tempos[0].BPM)+"]")
+				tempos = tempos[1:]
+			}
+			beat, err := aw.beat(b)
+			if err != nil {
+				return fmt.Errorf("track %q: %w", t.Name, err)
+			}
+			symbols = append(symbols, beat)
+			pos += b.value.length
+		}
+		bw.WriteString(strings.Join(symbols, " "))
+
+		switch {
+		case i == len(measures)-1:
+			bw.WriteString(" |]\n")
+		case (i+1)%abcMeasuresPerLine == 0:
+			bw.WriteString(" |\n")
+		default:
+			bw.WriteString(" | ")
+		}
+	}
+	return bw.Flush()
+}
+
+// abcTempo writes a tempo in quarter notes, rounded to whole beats per
+// minute as most ABC tools read it.
+func abcTempo(bpm float32) string {
+	return fmt.Sprintf("1/4=%d", int(math.Round(float64(bpm))))
+}
+
+// beat writes a note, a chord or a rest, tying the notes held over.
+func (aw *abcWriter) beat(b scoreBeat) (string, error) {
+	length := abcLengthText(b.value.length)
+
+	pieces := []scorePiece{}
+	for _, p := range b.pieces {
+		if !p.note.dead {
+			pieces = append(pieces, p)
+		}
+	}
+	// chords are written from the lowest note
+	sort.SliceStable(pieces, func(i, j int) bool {
+		pi, _ := midiPitch(pieces[i].note.pitch)
+		pj, _ := midiPitch(pieces[j].note.pitch)
+		return pi < pj
+	})
+
+	notes := []string{}
+	for _, p := range pieces {
+		note, err := aw.pitch(p.note.pitch)
+		if err != nil {
+			return "", err
+		}
+		if !b.lastValue || p.tieStart {
+			note += "-"
+		}
+		notes = append(notes, note)
+	}
+
+	switch len(notes) {
+	case 0:
+		return "z" + length, nil
+	case 1:
+		// the tie follows the length of a single note
+		note, tied := strings.CutSuffix(notes[0], "-")
+		if tied {
+			return note + length + "-", nil
+		}
+		return note + length, nil
+	}
+	return "[" + strings.Join(notes, "") + "]" + length, nil
+}
+
+// pitch spells a note with the accidental it needs in the bar, and its
+// octave: C4 is "C", C5 "c", C6 "c'" and C3 "C,".
+func (aw *abcWriter) pitch(n Note) (string, error) {
+	key, err := midiPitch(n)
+	if err != nil {
+		return "", err
+	}
+
+	name := abcSharpNames[key%12]
+	if aw.flats {
+		name = abcFlatNames[key%12]
+	}
+	alter, rest := abcAccidental(name)
+	if alter == math.MaxInt {
+		alter = 0
+	}
+	letter := rest[0]
+	octave := key/12 - 1
+
+	barKey := fmt.Sprintf("%c%d", letter, octave)
+	current, written := aw.barNotes[barKey]
+	if !written {
+		current = aw.accidentals[letter]
+	}
+
+	text := ""
+	if alter != current {
+		text = map[int]string{-1: "_", 0: "=", 1: "^"}[alter]
+		aw.barNotes[barKey] = alter
+	}
+
+	switch {
+	case octave >= 5:
+		text += strings.ToLower(string(letter)) + strings.Repeat("'", octave-5)
+	default:
+		text += string(letter) + strings.Repeat(",", 4-octave)
+	}
+	return text, nil
+}
+
+// abcLengthText writes a length in divisions as a multiple of the unit
+// note: "" for one, "2", "3/2" or "/" for a half.
+func abcLengthText(length int) string {
+	num, den := length, abcUnitDivisions
+	for d := gcd(num, den); d > 1; d = gcd(num, den) {
+		num, den = num/d, den/d
+	}
+
+	switch {
+	case den == 1 && num == 1:
+		return ""
+	case den == 1:
+		return fmt.Sprint(num)
+	case num == 1 && den == 2:
+		return "/"
+	case num == 1:
+		return fmt.Sprintf("/%d", den)
+	}
+	return fmt.Sprintf("%d/%d", num, den)
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
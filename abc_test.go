@@ -0,0 +1,90 @@
+package guitar
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteABC(t *testing.T) {
+	s := testSong(t)
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, s.WriteABC(&buf, 0))
+	// the capo raises the notes to A4, F#4 and E4, a note without duration
+	// rings until the next one
+	assert.Equal(t, "X:1\nT:Etude\nC:Anon\nM:2/4\nL:1/8\nQ:1/4=120\nK:Am\n"+
+		"A4- | A ^F2 z | [Q:1/4=60] z E2 z |]\n", buf.String())
+
+	assert.Error(t, s.WriteABC(&buf, 1))
+}
+
+func TestWriteABCTempoAndRange(t *testing.T) {
+	tun, _ := ParseTuning("G9")
+	s := NewSong("Slow", "")
+	s.SetTempo(0, 92.5)
+	tr, _ := NewTrack("", tun, 24)
+	tr.Add(Note{Fret: 0, String: 0, Time: 0})
+	s.AddTrack(tr)
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, s.WriteABC(&buf, 0))
+	assert.Contains(t, buf.String(), "\nQ:1/4=93\n")
+
+	// a note above the MIDI range can not be spelled
+	tr.Events = Playables{Note{Fret: 1, String: 0, Time: 0}}
+	assert.ErrorContains(t, s.WriteABC(&bytes.Buffer{}, 0), "out of the MIDI range")
+}
+
+func TestWriteABCAccidentals(t *testing.T) {
+	tun, _ := ParseTuning(StandardTuning)
+	s := NewSong("Chromatic", "")
+	s.Key = "F"
+	s.SetTimeSignature(0, TimeSignature{4, 4})
+	s.SetTempo(0, 120)
+
+	tr, _ := NewTrack("", tun, 24)
+	tr.Add(
+		Note{Fret: 1, String: 0, Time: 0, Duration: 0.25},
+		Note{Fret: 2, String: 0, Time: 0.25, Duration: 0.25},
+		Note{Fret: 1, String: 0, Time: 0.5, Duration: 0.25},
+		Note{Fret: 7, String: 0, Time: 0.75, Duration: 0.25},
+		Note{Fret: 6, String: 0, Time: 1, Duration: 0.25},
+		NewChord("C", []int{0, 1, 0, 2, 3, ChordMuted}, 1.25),
+		DeadNote{String: 0, Time: 1.75, Duration: 0.25},
+	)
+	s.AddTrack(tr)
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, s.WriteABC(&buf, 0))
+	// a flat key spells with flats, a natural lasts until the bar line
+	assert.Equal(t, "X:1\nT:Chromatic\nM:4/4\nL:1/8\nQ:1/4=120\nK:F\n"+
+		"F _G F =B _B [C,E,G,CE]2 z |]\n", buf.String())
+}
+
+func TestWriteABCRoundTrip(t *testing.T) {
+	tune := `X:1
+T:Reel
+M:4/4
+L:1/8
+Q:1/4=90
+K:D
+|: dAFA dAFA | B2 [EB]2 e>d cB :|
+`
+	tun, _ := ParseTuning(StandardTuning)
+	fb, _ := NewFingerBoard(tun, 24)
+	song, err := ReadABC(strings.NewReader(tune), fb)
+	assert.NoError(t, err)
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, song.WriteABC(&buf, 0))
+	assert.Equal(t, "X:1\nT:Reel\nM:4/4\nL:1/8\nQ:1/4=90\nK:D\n"+
+		"d A F A d A F A | B2 [EB]2 e3/2 d/ c B | d A F A d A F A | B2 [EB]2 e3/2 d/ c B |]\n", buf.String())
+
+	again, err := ReadABC(&buf, fb)
+	assert.NoError(t, err)
+	assert.Equal(t, song.Timing, again.Timing)
+	assert.Equal(t, song.Tracks[0].Events, again.Tracks[0].Events)
+}
@@ -0,0 +1,833 @@
+package guitar
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type ABCReadOption func(*abcReader)
+
+// WithABCTune reads the tune with the reference number X. By default the
+// first tune of the file is read.
+func WithABCTune(number int) ABCReadOption {
+	return func(r *abcReader) {
+		r.tune = number
+	}
+}
+
+// abcNote is a note of a bar in quarter notes from the bar start.
+type abcNote struct {
+	start, length float64
+	pitch         int
+	tie           bool
+}
+
+// abcBar is a bar of a tune with its repeat marks. A bar in an ending
+// is only played on that pass.
+type abcBar struct {
+	notes       []abcNote
+	length      float64
+	startRepeat bool
+	// endRepeat is how many times the repeated bars are played, 0 when
+	// the bar does not end a repeat.
+	endRepeat  int
+	ending     int
+	tempos     []quarterTempo
+	signatures []quarterSignature
+	// meter and bpm are in force when the bar starts, to restore them
+	// when it is played again after a repeat
+	meter TimeSignature
+	bpm   float64
+}
+
+type abcReader struct {
+	tune int
+
+	title, composer string
+	key             string
+	unit            float64
+	meter           TimeSignature
+	bpm             float64
+	hasUnit         bool
+
+	// accidentals maps a letter to its alteration in the key, barNotes a
+	// letter and octave to one written earlier in the bar.
+	accidentals map[byte]int
+	barNotes    map[string]int
+
+	bars   []abcBar
+	bar    abcBar
+	ending int
+
+	firstVoice string
+	otherVoice bool
+
+	// tuplet notes left, and their length factor
+	tupletNotes  int
+	tupletFactor float64
+	// lastEvent are the notes of the last note, chord or rest and
+	// lastLength its length, for broken rhythms
+	lastEvent  []int
+	lastLength float64
+	broken     float64
+}
+
+// ReadABC reads a tune in ABC notation into a Song with its title,
+// composer, key, meter and tempo. Repeats and endings are played out and
+// the notes are fingered on fb, each one close to the previous.
+func ReadABC(r io.Reader, fb *FingerBoard, opts ...ABCReadOption) (*Song, error) {
+	if fb == nil || len(fb.tuning) == 0 {
+		return nil, errors.New("empty tuning")
+	}
+
+	ar := &abcReader{tune: -1, accidentals: map[byte]int{}, barNotes: map[string]int{}, broken: 1}
+	for _, opt := range opts {
+		opt(ar)
+	}
+
+	found, inHeader := false, false
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '%'); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+
+		if number, ok := strings.CutPrefix(line, "X:"); ok {
+			if found {
+				break
+			}
+			x, err := strconv.Atoi(strings.TrimSpace(number))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid reference number %q", lineNumber, number)
+			}
+			found, inHeader = ar.tune < 0 || ar.tune == x, true
+			continue
+		}
+		if !found {
+			continue
+		}
+		if line == "" {
+			if inHeader {
+				continue
+			}
+			// a blank line ends the tune
+			break
+		}
+
+		var err error
+		if isABCField(line) {
+			err = ar.field(line[0], strings.TrimSpace(line[2:]))
+			if line[0] == 'K' && inHeader {
+				// the unit note length follows the meter of the header
+				ar.unit, ar.hasUnit = ar.unitLength(), true
+				inHeader = false
+			}
+		} else if !inHeader {
+			err = ar.body(line)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !found {
+		if ar.tune >= 0 {
+			return nil, fmt.Errorf("no tune X:%d", ar.tune)
+		}
+		return nil, errors.New("no tune in ABC file")
+	}
+	ar.closeBar()
+
+	qt, notes := ar.play()
+	song := &Song{Title: ar.title, Artist: ar.composer, Key: ar.key, Timing: qt.timing()}
+
+	keys := make([]fingerKey, len(notes))
+	for i, n := range notes {
+		start := qt.seconds(n.start)
+		keys[i] = fingerKey{start: start, length: qt.seconds(n.start+n.length) - start, pitch: n.pitch}
+	}
+	fingered, err := fb.finger(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	track, err := NewTrack("", fb.tuning, fb.frets)
+	if err != nil {
+		return nil, err
+	}
+	track.Add(fingered...)
+	song.AddTrack(track)
+	return song, nil
+}
+
+func isABCField(line string) bool {
+	return len(line) >= 2 && line[1] == ':' &&
+		(line[0] >= 'A' && line[0] <= 'Z' || line[0] >= 'a' && line[0] <= 'z')
+}
+
+// field reads a header field, or one given in the body on its own line
+// or inline in brackets.
+func (ar *abcReader) field(name byte, value string) error {
+	switch name {
+	case 'T':
+		if ar.title == "" {
+			ar.title = value
+		}
+	case 'C':
+		if ar.composer == "" {
+			ar.composer = value
+		}
+	case 'M':
+		meter, err := parseABCMeter(value)
+		if err != nil {
+			return err
+		}
+		if meter != ar.meter {
+			ar.bar.signatures = append(ar.bar.signatures, quarterSignature{quarter: ar.bar.length, signature: meter})
+		}
+		ar.meter = meter
+	case 'L':
+		unit, err := parseABCFraction(value)
+		if err != nil {
+			return fmt.Errorf("invalid unit note length %q", value)
+		}
+		ar.unit, ar.hasUnit = unit*4, true
+	case 'Q':
+		bpm, err := parseABCTempo(value, ar.unitLength())
+		if err != nil {
+			return err
+		}
+		ar.bar.tempos = append(ar.bar.tempos, quarterTempo{quarter: ar.bar.length, bpm: bpm})
+		ar.bpm = bpm
+	case 'K':
+		return ar.keyField(value)
+	case 'V':
+		voice, _, _ := strings.Cut(value, " ")
+		if ar.firstVoice == "" {
+			ar.firstVoice = voice
+		}
+		ar.otherVoice = voice != ar.firstVoice
+	}
+	return nil
+}
+
+// unitLength is the length of a note without a length in quarter notes:
+// an eighth, or a sixteenth in meters shorter than 3/4.
+func (ar *abcReader) unitLength() float64 {
+	if ar.hasUnit {
+		return ar.unit
+	}
+	if ar.meter.BeatUnit > 0 && float64(ar.meter.Beats)/float64(ar.meter.BeatUnit) < 0.75 {
+		return 0.25
+	}
+	return 0.5
+}
+
+// abcModes shift the fifths of a key from its major mode.
+var abcModes = map[string]int{
+	"": 0, "maj": 0, "ion": 0, "m": -3, "min": -3, "aeo": -3,
+	"mix": -1, "dor": -2, "phr": -4, "lyd": 1, "loc": -5,
+}
+
+func (ar *abcReader) keyField(value string) error {
+	fields := strings.Fields(value)
+	if len(fields) == 0 || strings.Contains(fields[0], "=") {
+		// only the clef or other properties change
+		return nil
+	}
+	ar.accidentals = map[byte]int{}
+	if fields[0] == "none" {
+		return nil
+	}
+
+	tonic := fields[0]
+	mode := ""
+	if len(tonic) > 1 && (tonic[1] == '#' || tonic[1] == 'b') {
+		tonic, mode = tonic[:2], tonic[2:]
+	} else {
+		tonic, mode = tonic[:1], tonic[1:]
+	}
+	if mode == "" && len(fields) > 1 && !strings.ContainsAny(fields[1][:1], "^_=") && !strings.Contains(fields[1], "=") {
+		mode = fields[1]
+	}
+	mode = strings.ToLower(mode)
+	if len(mode) > 3 {
+		mode = mode[:3]
+	}
+
+	fifths, ok := keyFifths[strings.ToUpper(tonic[:1])+tonic[1:]]
+	shift, known := abcModes[mode]
+	if !ok || !known {
+		return fmt.Errorf("invalid key %q", value)
+	}
+	fifths += shift
+	if fifths < -7 || fifths > 7 {
+		return fmt.Errorf("invalid key %q", value)
+	}
+	if ar.key == "" {
+		ar.key = keyName(fifths, shift == -3 && mode != "")
+	}
+
+	for i := 0; i < fifths; i++ {
+		ar.accidentals["FCGDAEB"[i]] = 1
+	}
+	for i := 0; i < -fifths; i++ {
+		ar.accidentals["BEADGCF"[i]] = -1
+	}
+	// explicit accidentals such as "^f _b" follow the key
+	for _, f := range fields[1:] {
+		alter, rest := abcAccidental(f)
+		if rest != "" && rest != f {
+			ar.accidentals[strings.ToUpper(rest)[0]] = alter
+		}
+	}
+	return nil
+}
+
+func parseABCMeter(value string) (TimeSignature, error) {
+	switch value {
+	case "C":
+		return TimeSignature{4, 4}, nil
+	case "C|":
+		return TimeSignature{2, 2}, nil
+	case "none", "":
+		return TimeSignature{}, nil
+	}
+
+	beats, unit, ok := strings.Cut(value, "/")
+	if !ok {
+		return TimeSignature{}, fmt.Errorf("invalid meter %q", value)
+	}
+	ts := TimeSignature{}
+	// additive meters such as (2+3)/8
+	for _, b := range strings.Split(strings.Trim(beats, "()"), "+") {
+		n, err := strconv.Atoi(strings.TrimSpace(b))
+		if err != nil {
+			return TimeSignature{}, fmt.Errorf("invalid meter %q", value)
+		}
+		ts.Beats += n
+	}
+	var err error
+	if ts.BeatUnit, err = strconv.Atoi(strings.TrimSpace(unit)); err != nil {
+		return TimeSignature{}, fmt.Errorf("invalid meter %q", value)
+	}
+	if err := ts.Validate(); err != nil {
+		return TimeSignature{}, err
+	}
+	return ts, nil
+}
+
+func parseABCFraction(value string) (float64, error) {
+	num, den, ok := strings.Cut(strings.TrimSpace(value), "/")
+	n, err := strconv.Atoi(num)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid fraction %q", value)
+	}
+	d := 1
+	if ok {
+		if d, err = strconv.Atoi(den); err != nil || d <= 0 {
+			return 0, fmt.Errorf("invalid fraction %q", value)
+		}
+	}
+	return float64(n) / float64(d), nil
+}
+
+// parseABCTempo reads a tempo such as "1/4=120", "3/8=60" or "Allegro"
+// 1/4=120 into quarter notes per minute. A bare number counts unit
+// notes.
+func parseABCTempo(value string, unit float64) (float64, error) {
+	// text in quotes is only shown
+	for strings.Count(value, `"`) >= 2 {
+		open := strings.IndexByte(value, '"')
+		end := strings.IndexByte(value[open+1:], '"')
+		value = value[:open] + value[open+end+2:]
+	}
+	value = strings.TrimSpace(value)
+
+	beat, bpm, ok := strings.Cut(value, "=")
+	if !ok {
+		beat, bpm = "", value
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(bpm), 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid tempo %q", value)
+	}
+
+	quarters := unit
+	if beat = strings.TrimSpace(beat); beat != "" {
+		quarters = 0
+		// a beat may add up several lengths, e.g. 1/4 1/8
+		for _, f := range strings.Fields(beat) {
+			length, err := parseABCFraction(f)
+			if err != nil {
+				return 0, fmt.Errorf("invalid tempo %q", value)
+			}
+			quarters += length * 4
+		}
+	}
+	return n * quarters, nil
+}
+
+// abcAccidental reads the accidental before a note letter: 1 for ^, -1
+// for _, 0 for =. Without one the alteration is math.MaxInt.
+func abcAccidental(s string) (int, string) {
+	switch {
+	case strings.HasPrefix(s, "^^"):
+		return 2, s[2:]
+	case strings.HasPrefix(s, "__"):
+		return -2, s[2:]
+	case strings.HasPrefix(s, "^"):
+		return 1, s[1:]
+	case strings.HasPrefix(s, "_"):
+		return -1, s[1:]
+	case strings.HasPrefix(s, "="):
+		return 0, s[1:]
+	}
+	return math.MaxInt, s
+}
+
+// abcSemitones are the pitch classes of the note letters.
+var abcSemitones = map[byte]int{'C': 0, 'D': 2, 'E': 4, 'F': 5, 'G': 7, 'A': 9, 'B': 11}
+
+// body reads a line of notes.
+func (ar *abcReader) body(line string) error {
+	s := strings.TrimSuffix(line, "\\")
+	for i := 0; i < len(s); {
+		if ar.otherVoice {
+			// only the first voice is read
+			return nil
+		}
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '`' || c == 'y' || c == ')':
+			i++
+		case c == '"':
+			// chord symbols and annotations
+			end := strings.IndexByte(s[i+1:], '"')
+			if end < 0 {
+				return errors.New("unclosed quote")
+			}
+			i += end + 2
+		case c == '!' || c == '+':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return fmt.Errorf("unclosed decoration %q", s[i:])
+			}
+			i += end + 2
+		case c == '{':
+			// grace notes are not played
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return errors.New("unclosed grace notes")
+			}
+			i += end + 1
+		case c == '.' || c == '~' || c == 'H' || c == 'L' || c == 'M' || c == 'O' ||
+			c == 'P' || c == 'S' || c == 'T' || c == 'u' || c == 'v':
+			// decorations
+			i++
+		case c == '(':
+			n, err := ar.tuplet(s[i+1:])
+			if err != nil {
+				return err
+			}
+			i += n + 1
+		case c == '>' || c == '<':
+			n := 1
+			for i+n < len(s) && s[i+n] == c {
+				n++
+			}
+			ar.brokenRhythm(c == '>', n)
+			i += n
+		case c == '[' && isABCField(s[i+1:]):
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return fmt.Errorf("unclosed field %q", s[i:])
+			}
+			field := s[i+1 : i+end]
+			if err := ar.field(field[0], strings.TrimSpace(field[2:])); err != nil {
+				return err
+			}
+			i += end + 1
+		case c == '|' || c == ':' || c == '[' && i+1 < len(s) && (s[i+1] == '|' || isDigit(rune(s[i+1]))):
+			i += ar.barLine(s[i:])
+		case c == '[':
+			n, err := ar.chord(s[i:])
+			if err != nil {
+				return err
+			}
+			i += n
+		case c == 'z' || c == 'x':
+			num, den, n := abcLength(s[i+1:])
+			ar.rest(ar.length(num, den))
+			i += n + 1
+		case c == 'Z' || c == 'X':
+			num, _, n := abcLength(s[i+1:])
+			bar := 4.0
+			if ar.meter.BeatUnit > 0 {
+				bar = float64(ar.meter.Beats) * 4 / float64(ar.meter.BeatUnit)
+			}
+			ar.rest(bar * float64(num))
+			i += n + 1
+		case c == '-':
+			for _, n := range ar.lastEvent {
+				ar.bar.notes[n].tie = true
+			}
+			i++
+		default:
+			pitch, n, ok := ar.pitch(s[i:])
+			if !ok {
+				return fmt.Errorf("unknown symbol %q", s[i:])
+			}
+			num, den, ln := abcLength(s[i+n:])
+			length := ar.length(num, den)
+			ar.addEvent([]abcNote{{pitch: pitch}}, length)
+			i += n + ln
+		}
+	}
+	return nil
+}
+
+// pitch reads an accidental, a letter and octave marks into a MIDI key,
+// C being middle C and c an octave above.
+func (ar *abcReader) pitch(s string) (int, int, bool) {
+	alter, rest := abcAccidental(s)
+	n := len(s) - len(rest)
+	if rest == "" {
+		return 0, 0, false
+	}
+
+	letter := rest[0]
+	octave := 4
+	if letter >= 'a' && letter <= 'g' {
+		letter, octave = letter-'a'+'A', 5
+	}
+	semitones, ok := abcSemitones[letter]
+	if !ok {
+		return 0, 0, false
+	}
+	n++
+	for ; n < len(s) && (s[n] == '\'' || s[n] == ','); n++ {
+		if s[n] == '\'' {
+			octave++
+		} else {
+			octave--
+		}
+	}
+
+	barKey := fmt.Sprintf("%c%d", letter, octave)
+	if alter == math.MaxInt {
+		var written bool
+		if alter, written = ar.barNotes[barKey]; !written {
+			alter = ar.accidentals[letter]
+		}
+	} else {
+		ar.barNotes[barKey] = alter
+	}
+	return (octave+1)*12 + semitones + alter, n, true
+}
+
+// abcLength reads a length such as "2", "/", "3/2" or "//" as a
+// fraction of the unit note, and how many bytes it took.
+func abcLength(s string) (int, int, int) {
+	n := 0
+	for n < len(s) && isDigit(rune(s[n])) {
+		n++
+	}
+	num := 1
+	if n > 0 {
+		num, _ = strconv.Atoi(s[:n])
+	}
+
+	den := 1
+	slashes := 0
+	for n < len(s) && s[n] == '/' {
+		slashes++
+		n++
+	}
+	if slashes > 0 {
+		start := n
+		for n < len(s) && isDigit(rune(s[n])) {
+			n++
+		}
+		if n > start {
+			den, _ = strconv.Atoi(s[start:n])
+		} else {
+			den = 1 << slashes
+		}
+	}
+	return num, max(den, 1), n
+}
+
+func (ar *abcReader) length(num, den int) float64 {
+	length := ar.unitLength() * float64(num) / float64(den)
+	if ar.tupletNotes > 0 {
+		length *= ar.tupletFactor
+		ar.tupletNotes--
+	}
+	return length
+}
+
+// tuplet reads "(3" or "(p:q:r" and how many bytes it took. A "(" alone
+// starts a slur, which is skipped.
+func (ar *abcReader) tuplet(s string) (int, error) {
+	n := 0
+	for n < len(s) && (isDigit(rune(s[n])) || s[n] == ':') {
+		n++
+	}
+	if n == 0 {
+		return 0, nil
+	}
+
+	parts := strings.Split(s[:n], ":")
+	p, err := strconv.Atoi(parts[0])
+	if err != nil || p < 2 {
+		return 0, fmt.Errorf("invalid tuplet (%s", s[:n])
+	}
+	// p notes in the time of q, r notes being affected
+	q := map[int]int{2: 3, 3: 2, 4: 3, 6: 2, 8: 3}[p]
+	if q == 0 {
+		q = 2
+	}
+	r := p
+	if len(parts) > 1 && parts[1] != "" {
+		if q, err = strconv.Atoi(parts[1]); err != nil || q <= 0 {
+			return 0, fmt.Errorf("invalid tuplet (%s", s[:n])
+		}
+	}
+	if len(parts) > 2 && parts[2] != "" {
+		if r, err = strconv.Atoi(parts[2]); err != nil || r <= 0 {
+			return 0, fmt.Errorf("invalid tuplet (%s", s[:n])
+		}
+	}
+	ar.tupletNotes, ar.tupletFactor = r, float64(q)/float64(p)
+	return n, nil
+}
+
+// brokenRhythm dots the last note and shortens the next one for ">",
+// the other way round for "<".
+func (ar *abcReader) brokenRhythm(longFirst bool, count int) {
+	short := math.Pow(0.5, float64(count))
+	first, next := 2-short, short
+	if !longFirst {
+		first, next = short, 2-short
+	}
+
+	delta := ar.lastLength*first - ar.lastLength
+	for _, n := range ar.lastEvent {
+		ar.bar.notes[n].length *= first
+	}
+	ar.bar.length += delta
+	ar.broken = next
+}
+
+func (ar *abcReader) addEvent(notes []abcNote, length float64) {
+	length *= ar.broken
+	ar.broken = 1
+
+	ar.lastEvent = ar.lastEvent[:0]
+	for _, n := range notes {
+		n.start, n.length = ar.bar.length, length
+		ar.lastEvent = append(ar.lastEvent, len(ar.bar.notes))
+		ar.bar.notes = append(ar.bar.notes, n)
+	}
+	ar.lastLength = length
+	ar.bar.length += length
+}
+
+func (ar *abcReader) rest(length float64) {
+	ar.addEvent(nil, length)
+}
+
+// chord reads "[CEG]2" and how many bytes it took. The chord lasts as
+// long as its first note.
+func (ar *abcReader) chord(s string) (int, error) {
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return 0, fmt.Errorf("unclosed chord %q", s)
+	}
+
+	notes := []abcNote{}
+	length := -1.0
+	body := s[1:end]
+	for i := 0; i < len(body); {
+		if body[i] == '-' {
+			if len(notes) > 0 {
+				notes[len(notes)-1].tie = true
+			}
+			i++
+			continue
+		}
+		pitch, n, ok := ar.pitch(body[i:])
+		if !ok {
+			return 0, fmt.Errorf("unknown symbol %q in chord", body[i:])
+		}
+		num, den, ln := abcLength(body[i+n:])
+		if length < 0 {
+			length = float64(num) / float64(den)
+		}
+		notes = append(notes, abcNote{pitch: pitch})
+		i += n + ln
+	}
+	if len(notes) == 0 {
+		return 0, errors.New("empty chord")
+	}
+
+	num, den, n := abcLength(s[end+1:])
+	ar.addEvent(notes, ar.length(num, den)*length)
+	return end + 1 + n, nil
+}
+
+// barLine reads a bar line with its repeat marks and ending numbers, and
+// how many bytes it took.
+func (ar *abcReader) barLine(s string) int {
+	n := 0
+	if s[0] == '[' && len(s) > 1 && s[1] == '|' {
+		n = 2
+	}
+	for n < len(s) && (s[n] == '|' || s[n] == ':' || (s[n] == ']' && n > 0 && s[n-1] == '|')) {
+		n++
+	}
+	if s[0] == '[' && n == 0 {
+		// "[2" starts an ending after a bar line
+		n = 1
+	}
+	token := s[:n]
+
+	ending := 0
+	digits := n
+	for digits < len(s) && isDigit(rune(s[digits])) {
+		digits++
+	}
+	if digits > n {
+		ending, _ = strconv.Atoi(s[n:digits])
+	}
+
+	pipes := strings.Count(token, "|")
+	first, last := strings.Index(token, "|"), strings.LastIndex(token, "|")
+	endRepeat, startRepeat := 0, false
+	switch {
+	case pipes == 0:
+		// "::" ends a repeat and starts another
+		if colons := strings.Count(token, ":"); colons > 0 {
+			endRepeat, startRepeat = 2, true
+		}
+	default:
+		if colons := strings.Count(token[:first], ":"); colons > 0 {
+			endRepeat = colons + 1
+		}
+		startRepeat = strings.Contains(token[last:], ":")
+	}
+
+	if token != "[" {
+		if endRepeat > 0 && len(ar.bar.notes) == 0 && ar.bar.length == 0 && len(ar.bars) > 0 {
+			ar.bars[len(ar.bars)-1].endRepeat = endRepeat
+		} else {
+			ar.bar.endRepeat = endRepeat
+		}
+		ar.closeBar()
+		if endRepeat > 0 || pipes > 1 || strings.ContainsAny(token, "[]") || startRepeat {
+			ar.ending = 0
+		}
+	}
+	if ending > 0 {
+		ar.ending = ending
+	}
+	ar.bar.startRepeat = ar.bar.startRepeat || startRepeat
+	ar.bar.ending = ar.ending
+	return digits
+}
+
+// closeBar ends the bar being read, forgetting its accidentals.
+func (ar *abcReader) closeBar() {
+	ar.barNotes = map[string]int{}
+	if len(ar.bar.notes) > 0 || ar.bar.length > 0 || ar.bar.endRepeat > 0 ||
+		len(ar.bar.tempos) > 0 || len(ar.bar.signatures) > 0 {
+		ar.bars = append(ar.bars, ar.bar)
+	}
+	ar.bar = abcBar{ending: ar.ending, meter: ar.meter, bpm: ar.bpm}
+	ar.lastEvent = nil
+}
+
+// play lays out the bars with their repeats played, tying notes to the
+// same pitch that follows them.
+func (ar *abcReader) play() (*quarterTiming, []abcNote) {
+	qt := &quarterTiming{}
+	notes := []abcNote{}
+	ties := map[int]int{}
+
+	pos := 0.0
+	meter, bpm := TimeSignature{}, 0.0
+	start, pass := 0, 1
+	inEnding := false
+	for i := 0; i < len(ar.bars); i++ {
+		b := ar.bars[i]
+		if b.startRepeat && i != start {
+			start, pass = i, 1
+		}
+		if b.ending != 0 {
+			inEnding = true
+			if b.ending != pass {
+				continue
+			}
+		} else if inEnding {
+			inEnding = false
+			start, pass = i, 1
+		}
+
+		if b.bpm != bpm && (len(b.tempos) == 0 || b.tempos[0].quarter > 0) {
+			qt.addTempo(pos, b.bpm)
+			bpm = b.bpm
+		}
+		for _, t := range b.tempos {
+			qt.addTempo(pos+t.quarter, t.bpm)
+			bpm = t.bpm
+		}
+		if b.meter != meter && (len(b.signatures) == 0 || b.signatures[0].quarter > 0) {
+			qt.addSignature(pos, b.meter)
+			meter = b.meter
+		}
+		for _, s := range b.signatures {
+			if s.signature != meter {
+				qt.addSignature(pos+s.quarter, s.signature)
+			}
+			meter = s.signature
+		}
+		for _, n := range b.notes {
+			n.start += pos
+			if j, ok := ties[n.pitch]; ok && math.Abs(notes[j].start+notes[j].length-n.start) < timeEpsilon {
+				notes[j].length += n.length
+				notes[j].tie = n.tie
+				if !n.tie {
+					delete(ties, n.pitch)
+				}
+				continue
+			}
+			if n.tie {
+				ties[n.pitch] = len(notes)
+			}
+			notes = append(notes, n)
+		}
+		pos += b.length
+
+		if b.endRepeat > 0 && pass < b.endRepeat {
+			pass++
+			i, inEnding = start-1, false
+			continue
+		}
+		if b.endRepeat > 0 && !inEnding {
+			start, pass = i+1, 1
+		}
+	}
+	return qt, notes
+}
@@ -0,0 +1,163 @@
+package guitar
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readTestABC(t *testing.T, tune string, opts ...ABCReadOption) *Song {
+	t.Helper()
+
+	tun, _ := ParseTuning(StandardTuning)
+	fb, _ := NewFingerBoard(tun, 24)
+	song, err := ReadABC(strings.NewReader(tune), fb, opts...)
+	assert.NoError(t, err)
+	return song
+}
+
+func TestReadABC(t *testing.T) {
+	song := readTestABC(t, `% a tune
+X:1
+T:Exercise
+T:Second title
+C:Trad.
+M:3/4
+L:1/4
+Q:1/4=120
+K:F
+|: F ^F/ =F/ B | [CEG]2 z |1 A2- A/ A/ :|2 c3 |]
+`)
+
+	assert.Equal(t, "Exercise", song.Title)
+	assert.Equal(t, "Trad.", song.Artist)
+	assert.Equal(t, "F", song.Key)
+	assert.Equal(t, Timing{
+		Tempos:         []TempoChange{{Time: 0, BPM: 120}},
+		TimeSignatures: []TimeSignatureChange{{Time: 0, Signature: TimeSignature{Beats: 3, BeatUnit: 4}}},
+	}, song.Timing)
+
+	assert.Len(t, song.Tracks, 1)
+	// the key flattens B, an accidental lasts until the bar line and the
+	// first ending is only played before the repeat
+	assert.Equal(t, Playables{
+		Note{Name: "F", Octave: 4, Fret: 1, String: 0, Time: 0, Duration: 0.5},
+		Note{Name: "F#", Octave: 4, Fret: 2, String: 0, Time: 0.5, Duration: 0.25},
+		Note{Name: "F", Octave: 4, Fret: 1, String: 0, Time: 0.75, Duration: 0.25},
+		Note{Name: "A#", Octave: 4, Fret: 6, String: 0, Time: 1, Duration: 0.5},
+		Note{Name: "G", Octave: 4, Fret: 3, String: 0, Time: 1.5, Duration: 1},
+		Note{Name: "E", Octave: 4, Fret: 5, String: 1, Time: 1.5, Duration: 1},
+		Note{Name: "C", Octave: 4, Fret: 5, String: 2, Time: 1.5, Duration: 1},
+		Note{Name: "A", Octave: 4, Fret: 5, String: 0, Time: 3, Duration: 1.25},
+		Note{Name: "A", Octave: 4, Fret: 5, String: 0, Time: 4.25, Duration: 0.25},
+		Note{Name: "F", Octave: 4, Fret: 6, String: 1, Time: 4.5, Duration: 0.5},
+		Note{Name: "F#", Octave: 4, Fret: 7, String: 1, Time: 5, Duration: 0.25},
+		Note{Name: "F", Octave: 4, Fret: 6, String: 1, Time: 5.25, Duration: 0.25},
+		Note{Name: "A#", Octave: 4, Fret: 6, String: 0, Time: 5.5, Duration: 0.5},
+		Note{Name: "G", Octave: 4, Fret: 3, String: 0, Time: 6, Duration: 1},
+		Note{Name: "E", Octave: 4, Fret: 5, String: 1, Time: 6, Duration: 1},
+		Note{Name: "C", Octave: 4, Fret: 5, String: 2, Time: 6, Duration: 1},
+		Note{Name: "C", Octave: 5, Fret: 8, String: 0, Time: 7.5, Duration: 1.5},
+	}, song.Tracks[0].Events)
+}
+
+func TestReadABCRhythms(t *testing.T) {
+	song := readTestABC(t, `X:1
+M:4/4
+K:C
+"Am" A>B c<d z2 (3efg | !trill!a4 {g}f2 e,2 | Z |
+[M:2/4][Q:1/4=60] C'2 c'2 |]
+`)
+
+	assert.Equal(t, Timing{
+		Tempos: []TempoChange{{Time: 6, BPM: 60}},
+		TimeSignatures: []TimeSignatureChange{
+			{Time: 0, Signature: TimeSignature{Beats: 4, BeatUnit: 4}},
+			{Time: 6, Signature: TimeSignature{Beats: 2, BeatUnit: 4}},
+		},
+	}, song.Timing)
+
+	type timed struct {
+		name           string
+		time, duration float32
+	}
+	expected := []timed{
+		{"A4", 0, 0.375}, {"B4", 0.375, 0.125},
+		{"C5", 0.5, 0.125}, {"D5", 0.625, 0.375},
+		{"E5", 1.5, 1.0 / 6}, {"F5", 1.5 + 1.0/6, 1.0 / 6}, {"G5", 1.5 + 2.0/6, 1.0 / 6},
+		{"A5", 2, 1}, {"F5", 3, 0.5}, {"E4", 3.5, 0.5},
+		// the unit note stays an eighth in 2/4, at the slower tempo
+		{"C5", 6, 1}, {"C6", 7, 1},
+	}
+
+	events := song.Tracks[0].Events
+	assert.Len(t, events, len(expected))
+	for i, e := range expected {
+		n := events[i].(Note)
+		assert.Equal(t, e.name, n.Name+string(rune('0'+n.Octave)))
+		assert.InDelta(t, e.time, n.Time, 1e-4)
+		assert.InDelta(t, e.duration, n.Duration, 1e-4)
+	}
+}
+
+func TestReadABCTune(t *testing.T) {
+	tunes := `X:1
+T:First
+K:G
+GABc|
+
+X:2
+T:Second
+K:D
+V:1
+DEF2|
+V:2
+A,B,C2|
+`
+
+	song := readTestABC(t, tunes, WithABCTune(2))
+	assert.Equal(t, "Second", song.Title)
+	assert.Equal(t, "D", song.Key)
+	// only the first voice is read
+	assert.Len(t, song.Tracks[0].Events, 3)
+	assert.Equal(t, "F#", song.Tracks[0].Events[2].(Note).Name)
+
+	song = readTestABC(t, tunes)
+	assert.Equal(t, "First", song.Title)
+	assert.Len(t, song.Tracks[0].Events, 4)
+}
+
+func TestReadABCErrors(t *testing.T) {
+	tun, _ := ParseTuning(StandardTuning)
+	fb, _ := NewFingerBoard(tun, 24)
+
+	testCases := []struct {
+		name string
+		tune string
+		opts []ABCReadOption
+	}{
+		{name: "no tune", tune: "T:Title\nK:C\nCDE|"},
+		{name: "missing tune", tune: "X:1\nK:C\nCDE|", opts: []ABCReadOption{WithABCTune(2)}},
+		{name: "reference number", tune: "X:one\nK:C\nCDE|"},
+		{name: "meter", tune: "X:1\nM:three\nK:C\nCDE|"},
+		{name: "unit length", tune: "X:1\nL:1/0\nK:C\nCDE|"},
+		{name: "tempo", tune: "X:1\nQ:fast\nK:C\nCDE|"},
+		{name: "key", tune: "X:1\nK:H\nCDE|"},
+		{name: "unclosed chord", tune: "X:1\nK:C\n[CEG|"},
+		{name: "empty chord", tune: "X:1\nK:C\n[]|"},
+		{name: "unknown symbol", tune: "X:1\nK:C\nCD&E|"},
+		{name: "tuplet", tune: "X:1\nK:C\n(1CDE|"},
+		{name: "out of range", tune: "X:1\nK:C\nC,,,|"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ReadABC(strings.NewReader(tc.tune), fb, tc.opts...)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := ReadABC(strings.NewReader("X:1\nK:C\nC|"), &FingerBoard{})
+	assert.Error(t, err)
+}
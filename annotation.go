@@ -0,0 +1,217 @@
+package guitar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+type AnnotationKind int
+
+const (
+	TextAnnotation AnnotationKind = iota
+	ChordAnnotation
+	LyricAnnotation
+	SectionAnnotation
+	// RangeAnnotation spans from Time to End, e.g. "P.M.----|".
+	RangeAnnotation
+)
+
+type AnnotationPlacement int
+
+const (
+	// PlaceDefault puts lyrics below the staff and everything else above it.
+	PlaceDefault AnnotationPlacement = iota
+	PlaceAbove
+	PlaceBelow
+)
+
+// Annotation is a piece of text anchored to a time of the tab and
+// rendered on its own line above or below the strings.
+type Annotation struct {
+	Kind      AnnotationKind
+	Text      string
+	Time      float32
+	End       float32
+	Placement AnnotationPlacement
+}
+
+func ChordSymbol(name string, time float32) Annotation {
+	return Annotation{Kind: ChordAnnotation, Text: name, Time: time}
+}
+
+func Lyric(syllable string, time float32) Annotation {
+	return Annotation{Kind: LyricAnnotation, Text: syllable, Time: time}
+}
+
+func SectionMark(name string, time float32) Annotation {
+	return Annotation{Kind: SectionAnnotation, Text: name, Time: time}
+}
+
+func TextAt(text string, time float32) Annotation {
+	return Annotation{Kind: TextAnnotation, Text: text, Time: time}
+}
+
+func RangeMark(text string, start, end float32) Annotation {
+	return Annotation{Kind: RangeAnnotation, Text: text, Time: start, End: end}
+}
+
+func (a Annotation) Validate() error {
+	if a.Kind < TextAnnotation || a.Kind > RangeAnnotation {
+		return fmt.Errorf("invalid annotation kind: %d", a.Kind)
+	}
+	if a.Time < 0 {
+		return fmt.Errorf("invalid annotation time: %v", a.Time)
+	}
+	if a.Kind == RangeAnnotation && a.End < a.Time {
+		return fmt.Errorf("annotation range ends at %v before it starts at %v", a.End, a.Time)
+	}
+	return nil
+}
+
+func (a Annotation) below() bool {
+	if a.Placement == PlaceDefault {
+		return a.Kind == LyricAnnotation
+	}
+	return a.Placement == PlaceBelow
+}
+
+// Annotate adds annotations to the tab. They may be added before or after
+// the notes they refer to.
+func (tb *TabWriter) Annotate(annotations ...Annotation) error {
+	for _, a := range annotations {
+		if err := a.Validate(); err != nil {
+			return err
+		}
+	}
+
+	tb.annotations = append(tb.annotations, annotations...)
+	return nil
+}
+
+// aboveOrder and belowOrder list the kinds from the top line to the bottom one.
+var (
+	aboveOrder = []AnnotationKind{SectionAnnotation, TextAnnotation, ChordAnnotation, LyricAnnotation, RangeAnnotation}
+	belowOrder = []AnnotationKind{RangeAnnotation, ChordAnnotation, LyricAnnotation, TextAnnotation, SectionAnnotation}
+)
+
+// annotationItem is an annotation placed at a character offset of a line.
+type annotationItem struct {
+	pos  int
+	text string
+}
+
+// renderAnnotations returns the annotation lines of a system placed above
+// or below the strings.
+func (tb *TabWriter) renderAnnotations(system tabSystem, below bool) []string {
+	order := aboveOrder
+	if below {
+		order = belowOrder
+	}
+
+	lines := []string{}
+	for _, kind := range order {
+		items := []annotationItem{}
+		for _, a := range tb.annotations {
+			if a.Kind != kind || a.below() != below {
+				continue
+			}
+			if item, ok := tb.placeAnnotation(system, a); ok {
+				items = append(items, item)
+			}
+		}
+		lines = append(lines, packAnnotations(items)...)
+	}
+
+	return lines
+}
+
+// placeAnnotation finds where a is written in system. A range crossing
+// the system boundaries is continued with dashes and closed with "|"
+// only in the system it ends in.
+func (tb *TabWriter) placeAnnotation(system tabSystem, a Annotation) (annotationItem, bool) {
+	from := a.Time
+	if a.Kind == RangeAnnotation {
+		if a.End <= system.start+timeEpsilon {
+			return annotationItem{}, false
+		}
+		from = max(from, system.start)
+	}
+	if from < system.start-timeEpsilon || from >= system.end-timeEpsilon {
+		return annotationItem{}, false
+	}
+
+	offset := tb.lineStart(system.columns)
+	found := false
+	startPos, endPos := 0, 0
+	for _, col := range system.columns {
+		if !col.bar && !found && col.time >= from-timeEpsilon {
+			found, startPos = true, offset
+		}
+		if !col.bar && col.time < a.End-timeEpsilon {
+			endPos = offset + col.width()
+		}
+		offset += col.width()
+	}
+
+	if !found && system.last && a.Kind != RangeAnnotation {
+		// anchored after the last column, e.g. a repeat count
+		found, startPos = true, offset
+	}
+	if !found {
+		return annotationItem{}, false
+	}
+	if a.Kind != RangeAnnotation {
+		return annotationItem{pos: startPos, text: a.Text}, true
+	}
+
+	text := a.Text
+	if a.Time < system.start-timeEpsilon {
+		text = ""
+	}
+
+	closing := "|"
+	if a.End >= system.end-timeEpsilon {
+		closing, endPos = "", offset
+	}
+	if endPos < startPos {
+		endPos = startPos
+	}
+
+	dashes := max(0, endPos-startPos-len(text)-len(closing))
+	return annotationItem{pos: startPos, text: text + strings.Repeat("-", dashes) + closing}, true
+}
+
+// packAnnotations writes items left to right, moving an item that would
+// overlap its neighbour to an extra line.
+func packAnnotations(items []annotationItem) []string {
+	sort.SliceStable(items, func(i, j int) bool { return items[i].pos < items[j].pos })
+
+	// widths are counted in runes so that symbols such as "↓" line up
+	lines := []*strings.Builder{}
+	widths := []int{}
+	for _, item := range items {
+		line := -1
+		for i := range lines {
+			if widths[i] < item.pos {
+				line = i
+				break
+			}
+		}
+		if line == -1 {
+			line = len(lines)
+			lines = append(lines, &strings.Builder{})
+			widths = append(widths, 0)
+		}
+		lines[line].WriteString(strings.Repeat(" ", item.pos-widths[line]))
+		lines[line].WriteString(item.text)
+		widths[line] = item.pos + utf8.RuneCountInString(item.text)
+	}
+
+	result := make([]string, len(lines))
+	for i, l := range lines {
+		result[i] = l.String()
+	}
+	return result
+}
@@ -0,0 +1,87 @@
+package guitar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnotate(t *testing.T) {
+	annotations := []Annotation{
+		SectionMark("Verse", 0),
+		ChordSymbol("Am", 0),
+		ChordSymbol("C", 1),
+		RangeMark("P.M.", 0.4, 1.6),
+		Lyric("Hel", 0),
+		Lyric("lo", 0.4),
+		Lyric("world", 2.2),
+	}
+
+	testCases := []struct {
+		name        string
+		opts        []TabOption
+		expectedTab string
+	}{
+		{
+			name: "single system",
+			opts: []TabOption{WithTimeSignature(2, 4)},
+			expectedTab: "  Verse\n  Am     C\n    P.M.---|\n" +
+				"e|3-----|-----|-12-|\nB|--5---|-----|----|\nG|---7--|-----|----|\n" +
+				"  Hel           world\n    lo\n",
+		},
+		{
+			name: "wrapped",
+			opts: []TabOption{WithTimeSignature(2, 4), WithLineWidth(16)},
+			expectedTab: "  Verse\n  Am     C\n    P.M.---|\n" +
+				"e|3-----|-----|\nB|--5---|-----|\nG|---7--|-----|\n" +
+				"  Hel\n    lo\n\n" +
+				"e|-12-|\nB|----|\nG|----|\n   world\n",
+		},
+		{
+			name: "range across systems",
+			opts: []TabOption{WithTimeSignature(2, 4), WithLineWidth(9)},
+			expectedTab: "  Verse\n  Am\n    P.M.-\n" +
+				"e|3-----|\nB|--5---|\nG|---7--|\n  Hel\n    lo\n\n" +
+				"  C\n  --|\n" +
+				"e|-----|\nB|-----|\nG|-----|\n\n" +
+				"e|-12-|\nB|----|\nG|----|\n   world\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tb, _ := NewTabWriter([]string{"e", "B", "G"}, tc.opts...)
+
+			err := tb.Annotate(annotations...)
+			assert.NoError(t, err)
+
+			err = tb.WriteNotes(
+				Note{Fret: 3, String: 0, Time: 0},
+				Note{Fret: 5, String: 1, Time: 0.4},
+				Note{Fret: 7, String: 2, Time: 0.6},
+			)
+			assert.NoError(t, err)
+			err = tb.WriteNotes(Note{Fret: 12, String: 0, Time: 2.2})
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.expectedTab, tb.Tab())
+		})
+	}
+}
+
+func TestAnnotationAlignsWithWideColumns(t *testing.T) {
+	tb, _ := NewTabWriter([]string{"e", "B"})
+
+	_ = tb.Annotate(ChordSymbol("D", 0.2), TextAt("let ring", 0.2), Annotation{Kind: LyricAnnotation, Text: "la", Time: 0.2, Placement: PlaceAbove})
+	_ = tb.WriteNotes(Note{Fret: 12, String: 0, Time: 0}, Note{Fret: 10, String: 1, Time: 0.2})
+
+	assert.Equal(t, "    let ring\n    D\n    la\ne|12---\nB|--10-\n", tb.Tab())
+}
+
+func TestAnnotateInvalid(t *testing.T) {
+	tb, _ := NewTabWriter([]string{"e"})
+
+	assert.Error(t, tb.Annotate(RangeMark("P.M.", 2, 1)))
+	assert.Error(t, tb.Annotate(TextAt("x", -1)))
+	assert.Error(t, tb.Annotate(Annotation{Kind: AnnotationKind(42)}))
+}
@@ -0,0 +1,320 @@
+package guitar
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Section is a named part of a song defined once and played from an
+// Arrangement. Note and annotation times are relative to the section start.
+type Section struct {
+	Name        string
+	Length      float32
+	Notes       Playables
+	Annotations []Annotation
+}
+
+// Ending is played at the end of the listed passes of a repeated section,
+// e.g. Passes []int{1, 2} for a "1, 2." ending.
+type Ending struct {
+	Passes      []int
+	Length      float32
+	Notes       Playables
+	Annotations []Annotation
+}
+
+// Part plays a section Times times, finishing each pass with the ending
+// that lists it.
+type Part struct {
+	Section string
+	Times   int
+	Endings []Ending
+}
+
+type Arrangement struct {
+	sections map[string]Section
+	parts    []Part
+}
+
+func NewArrangement(sections ...Section) (*Arrangement, error) {
+	a := &Arrangement{sections: map[string]Section{}}
+
+	for _, s := range sections {
+		if err := a.Define(s); err != nil {
+			return nil, err
+		}
+	}
+
+	return a, nil
+}
+
+// Define adds a section or replaces the one with the same name.
+func (a *Arrangement) Define(s Section) error {
+	if s.Name == "" {
+		return errors.New("section name can not be empty")
+	}
+	if err := validateBlock(s.Length, s.Notes); err != nil {
+		return fmt.Errorf("section %q: %w", s.Name, err)
+	}
+
+	a.sections[s.Name] = s
+	return nil
+}
+
+func (a *Arrangement) Section(name string) (Section, bool) {
+	s, ok := a.sections[name]
+	return s, ok
+}
+
+// Play appends a part playing the section times times.
+func (a *Arrangement) Play(section string, times int, endings ...Ending) error {
+	part := Part{Section: section, Times: times, Endings: endings}
+	if err := a.validatePart(part); err != nil {
+		return err
+	}
+
+	a.parts = append(a.parts, part)
+	return nil
+}
+
+func (a *Arrangement) Parts() []Part {
+	return append([]Part(nil), a.parts...)
+}
+
+func (a *Arrangement) validatePart(p Part) error {
+	if _, ok := a.sections[p.Section]; !ok {
+		return fmt.Errorf("unknown section %q", p.Section)
+	}
+	if p.Times < 1 {
+		return fmt.Errorf("section %q: invalid repeat count %d", p.Section, p.Times)
+	}
+
+	seen := map[int]bool{}
+	for _, e := range p.Endings {
+		if len(e.Passes) == 0 {
+			return fmt.Errorf("section %q: ending without passes", p.Section)
+		}
+		for _, pass := range e.Passes {
+			if pass < 1 || pass > p.Times {
+				return fmt.Errorf("section %q: ending pass %d out of range 1-%d", p.Section, pass, p.Times)
+			}
+			if seen[pass] {
+				return fmt.Errorf("section %q: pass %d has more than one ending", p.Section, pass)
+			}
+			seen[pass] = true
+		}
+		if err := validateBlock(e.Length, e.Notes); err != nil {
+			return fmt.Errorf("section %q ending: %w", p.Section, err)
+		}
+	}
+
+	return nil
+}
+
+func validateBlock(length float32, notes []Playable) error {
+	if length <= 0 {
+		return fmt.Errorf("invalid length %v", length)
+	}
+	for _, n := range notes {
+		if _, ok := n.(Movable); !ok {
+			return fmt.Errorf("%T can not be moved in time", n)
+		}
+		if n.StartTime() < 0 || n.StartTime() >= length {
+			return fmt.Errorf("note time %v is out of 0-%v", n.StartTime(), length)
+		}
+	}
+	return nil
+}
+
+// Length returns the playing time of the arrangement with repeats expanded.
+func (a *Arrangement) Length() float32 {
+	var length float32
+	for _, p := range a.parts {
+		for pass := 1; pass <= p.Times; pass++ {
+			length += a.sections[p.Section].Length
+			if e, ok := p.ending(pass); ok {
+				length += e.Length
+			}
+		}
+	}
+	return length
+}
+
+// Timeline returns the notes of the arrangement in playing order with
+// every repeat unrolled and times shifted to the song time.
+func (a *Arrangement) Timeline() []Playable {
+	timeline := []Playable{}
+
+	var offset float32
+	for _, p := range a.parts {
+		s := a.sections[p.Section]
+		for pass := 1; pass <= p.Times; pass++ {
+			timeline = append(timeline, shiftNotes(s.Notes, offset)...)
+			offset += s.Length
+
+			if e, ok := p.ending(pass); ok {
+				timeline = append(timeline, shiftNotes(e.Notes, offset)...)
+				offset += e.Length
+			}
+		}
+	}
+
+	sort.SliceStable(timeline, func(i, j int) bool { return timeline[i].StartTime() < timeline[j].StartTime() })
+	return timeline
+}
+
+// WriteTab writes the arrangement to tb. With expand set every repeat is
+// written out, otherwise sections are written once between repeat signs
+// with "x3" counts and numbered endings.
+func (a *Arrangement) WriteTab(tb *TabWriter, expand bool) error {
+	var offset float32
+	for _, p := range a.parts {
+		var err error
+		if expand {
+			offset, err = a.writeExpanded(tb, p, offset)
+		} else {
+			offset, err = a.writeRepeated(tb, p, offset)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *Arrangement) writeExpanded(tb *TabWriter, p Part, offset float32) (float32, error) {
+	s := a.sections[p.Section]
+
+	if err := tb.Annotate(SectionMark(s.Name, offset)); err != nil {
+		return 0, err
+	}
+
+	for pass := 1; pass <= p.Times; pass++ {
+		if err := writeBlock(tb, s.Notes, s.Annotations, offset); err != nil {
+			return 0, err
+		}
+		offset += s.Length
+
+		if e, ok := p.ending(pass); ok {
+			if err := writeBlock(tb, e.Notes, e.Annotations, offset); err != nil {
+				return 0, err
+			}
+			offset += e.Length
+		}
+	}
+
+	return offset, nil
+}
+
+func (a *Arrangement) writeRepeated(tb *TabWriter, p Part, offset float32) (float32, error) {
+	s := a.sections[p.Section]
+	repeated := p.Times > 1
+
+	if err := tb.Annotate(SectionMark(s.Name, offset)); err != nil {
+		return 0, err
+	}
+	if repeated {
+		if err := tb.WriteBarLine(RepeatStart, offset); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := writeBlock(tb, s.Notes, s.Annotations, offset); err != nil {
+		return 0, err
+	}
+	offset += s.Length
+
+	if !repeated {
+		return offset, nil
+	}
+
+	if len(p.Endings) == 0 {
+		if err := tb.WriteBarLine(RepeatEnd, offset); err != nil {
+			return 0, err
+		}
+		if p.Times > 2 {
+			if err := tb.Annotate(TextAt("x"+strconv.Itoa(p.Times), offset)); err != nil {
+				return 0, err
+			}
+		}
+		return offset, nil
+	}
+
+	endings := append([]Ending(nil), p.Endings...)
+	sort.SliceStable(endings, func(i, j int) bool { return minPass(endings[i]) < minPass(endings[j]) })
+
+	for i, e := range endings {
+		if err := tb.Annotate(RangeMark(endingLabel(e), offset, offset+e.Length)); err != nil {
+			return 0, err
+		}
+		if err := writeBlock(tb, e.Notes, e.Annotations, offset); err != nil {
+			return 0, err
+		}
+		offset += e.Length
+
+		if i < len(endings)-1 {
+			if err := tb.WriteBarLine(RepeatEnd, offset); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return offset, nil
+}
+
+func writeBlock(tb *TabWriter, notes []Playable, annotations []Annotation, offset float32) error {
+	for _, an := range annotations {
+		an.Time += offset
+		an.End += offset
+		if err := tb.Annotate(an); err != nil {
+			return err
+		}
+	}
+
+	if len(notes) == 0 {
+		return nil
+	}
+	return tb.WriteNotes(shiftNotes(notes, offset)...)
+}
+
+func (p Part) ending(pass int) (Ending, bool) {
+	for _, e := range p.Endings {
+		for _, ep := range e.Passes {
+			if ep == pass {
+				return e, true
+			}
+		}
+	}
+	return Ending{}, false
+}
+
+func minPass(e Ending) int {
+	m := e.Passes[0]
+	for _, p := range e.Passes {
+		m = min(m, p)
+	}
+	return m
+}
+
+// endingLabel returns the volta label of e, e.g. "1." or "1, 2.".
+func endingLabel(e Ending) string {
+	passes := append([]int(nil), e.Passes...)
+	sort.Ints(passes)
+
+	labels := make([]string, len(passes))
+	for i, p := range passes {
+		labels[i] = strconv.Itoa(p)
+	}
+	return strings.Join(labels, ", ") + "."
+}
+
+func shiftNotes(notes []Playable, offset float32) []Playable {
+	shifted := make([]Playable, len(notes))
+	for i, n := range notes {
+		shifted[i] = n.(Movable).AtTime(n.StartTime() + offset)
+	}
+	return shifted
+}
@@ -0,0 +1,88 @@
+package guitar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testArrangement(t *testing.T) *Arrangement {
+	a, err := NewArrangement(
+		Section{Name: "Intro", Length: 1, Notes: []Playable{
+			Note{Fret: 0, String: 2},
+			Note{Fret: 2, String: 2, Time: 0.4},
+		}},
+		Section{Name: "Verse", Length: 1, Notes: []Playable{
+			Note{Fret: 3, String: 0},
+			Note{Fret: 5, String: 1, Time: 0.4},
+		}},
+	)
+	assert.NoError(t, err)
+
+	assert.NoError(t, a.Play("Intro", 4))
+	assert.NoError(t, a.Play("Verse", 2,
+		Ending{Passes: []int{1}, Length: 1, Notes: []Playable{Note{Fret: 7, String: 0}}},
+		Ending{Passes: []int{2}, Length: 1, Notes: []Playable{Note{Fret: 8, String: 0}}},
+	))
+
+	return a
+}
+
+func TestArrangementWriteTab(t *testing.T) {
+	testCases := []struct {
+		name        string
+		expand      bool
+		expectedTab string
+	}{
+		{
+			name:   "repeat signs",
+			expand: false,
+			expectedTab: "   Intro    Verse\n            x4\n                   1.---|  2.|\n" +
+				"e|:------:|:3-----|7-----:|8-|\nB|:------:|:--5---|------:|--|\nG|:0-2---:|:------|------:|--|\n",
+		},
+		{
+			name:   "expanded",
+			expand: true,
+			expectedTab: "  Intro                       Verse\n" +
+				"e|------|------|------|------|3-----|7-----|3-----|8-|\n" +
+				"B|------|------|------|------|--5---|------|--5---|--|\n" +
+				"G|0-2---|0-2---|0-2---|0-2---|------|------|------|--|\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := testArrangement(t)
+			tb, _ := NewTabWriter([]string{"e", "B", "G"}, WithTimeSignature(2, 4))
+
+			err := a.WriteTab(tb, tc.expand)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedTab, tb.Tab())
+		})
+	}
+}
+
+func TestArrangementTimeline(t *testing.T) {
+	a := testArrangement(t)
+
+	timeline := a.Timeline()
+
+	assert.InDelta(t, 8, a.Length(), 1e-6)
+	assert.Len(t, timeline, 14)
+	assert.Equal(t, Note{Fret: 2, String: 2, Time: 3.4}, timeline[7])
+	assert.Equal(t, Note{Fret: 7, String: 0, Time: 5}, timeline[10])
+	assert.Equal(t, Note{Fret: 8, String: 0, Time: 7}, timeline[13])
+}
+
+func TestArrangementErrors(t *testing.T) {
+	a := testArrangement(t)
+
+	assert.Error(t, a.Play("Chorus", 1))
+	assert.Error(t, a.Play("Verse", 0))
+	assert.Error(t, a.Play("Verse", 2, Ending{Passes: []int{3}, Length: 1}))
+	assert.Error(t, a.Play("Verse", 2, Ending{Passes: []int{1}, Length: 1}, Ending{Passes: []int{1}, Length: 1}))
+	assert.Error(t, a.Define(Section{Name: "Bridge", Length: 1, Notes: []Playable{Note{Time: 1.5}}}))
+	assert.Error(t, a.Define(Section{Name: "Bridge"}))
+	assert.Error(t, a.Define(Section{Length: 1}))
+}
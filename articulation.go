@@ -0,0 +1,310 @@
+package guitar
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Articulation is a set of ways a note is played, combined with "|".
+type Articulation uint16
+
+const (
+	Vibrato Articulation = 1 << iota
+	WideVibrato
+	PalmMute
+	// Muted is a fretting hand mute, written "x" in place of the fret.
+	Muted
+	// Ghost is a note played softly, written "(5)".
+	Ghost
+	Accent
+	Staccato
+	LetRing
+)
+
+var articulationNames = []struct {
+	a    Articulation
+	name string
+}{
+	{Vibrato, "vibrato"},
+	{WideVibrato, "wide_vibrato"},
+	{PalmMute, "palm_mute"},
+	{Muted, "muted"},
+	{Ghost, "ghost"},
+	{Accent, "accent"},
+	{Staccato, "staccato"},
+	{LetRing, "let_ring"},
+}
+
+// Annotation texts of the articulations not written in the staff.
+const (
+	palmMuteText = "P.M."
+	letRingText  = "let ring"
+	accentText   = ">"
+	staccatoText = "."
+)
+
+func (a Articulation) Has(other Articulation) bool {
+	return a&other == other
+}
+
+// Names returns the names of the articulations in a, e.g. "palm_mute".
+func (a Articulation) Names() []string {
+	names := []string{}
+	for _, n := range articulationNames {
+		if a.Has(n.a) {
+			names = append(names, n.name)
+		}
+	}
+	return names
+}
+
+func (a Articulation) String() string {
+	return strings.Join(a.Names(), "|")
+}
+
+func ParseArticulation(name string) (Articulation, error) {
+	for _, n := range articulationNames {
+		if n.name == name {
+			return n.a, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown articulation %q", name)
+}
+
+func articulationFromNames(names []string) (Articulation, error) {
+	var a Articulation
+	for _, name := range names {
+		n, err := ParseArticulation(name)
+		if err != nil {
+			return 0, err
+		}
+		a |= n
+	}
+	return a, nil
+}
+
+func (a Articulation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.Names())
+}
+
+func (a *Articulation) UnmarshalJSON(data []byte) error {
+	names := []string{}
+	if err := json.Unmarshal(data, &names); err != nil {
+		return err
+	}
+	articulation, err := articulationFromNames(names)
+	if err != nil {
+		return err
+	}
+	*a = articulation
+	return nil
+}
+
+func (a Articulation) MarshalYAML() (any, error) {
+	return a.Names(), nil
+}
+
+func (a *Articulation) UnmarshalYAML(value *yaml.Node) error {
+	names := []string{}
+	if err := value.Decode(&names); err != nil {
+		return err
+	}
+	articulation, err := articulationFromNames(names)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", value.Line, err)
+	}
+	*a = articulation
+	return nil
+}
+
+// Articulated is a Playable with articulations. It plays and is moved
+// like the Playable it wraps.
+type Articulated struct {
+	Playable
+	Articulations Articulation
+}
+
+// Articulate adds articulations to p, merging them with the ones p
+// already has.
+func Articulate(p Playable, articulations ...Articulation) Articulated {
+	result := Articulated{Playable: p}
+	if a, ok := p.(Articulated); ok {
+		result = a
+	}
+	for _, a := range articulations {
+		result.Articulations |= a
+	}
+	return result
+}
+
+// TabSymbol writes the inline articulations: "x" for a muted note,
+// "(5)" for a ghost note and "5~" or "5~~" for vibrato.
+func (a Articulated) TabSymbol() string {
+	if a.Articulations.Has(Muted) {
+		return deadNoteTechnique.Format()
+	}
+
+	symbol := a.Playable.TabSymbol()
+	if a.Articulations.Has(Ghost) {
+		symbol = "(" + symbol + ")"
+	}
+	switch {
+	case a.Articulations.Has(WideVibrato):
+		symbol += "~~"
+	case a.Articulations.Has(Vibrato):
+		symbol += "~"
+	}
+	return symbol
+}
+
+func (a Articulated) EndTime() float32 {
+	if s, ok := a.Playable.(Sustained); ok {
+		return s.EndTime()
+	}
+	return a.StartTime()
+}
+
+func (a Articulated) AtTime(t float32) Playable {
+	if m, ok := a.Playable.(Movable); ok {
+		a.Playable = m.AtTime(t)
+	}
+	return a
+}
+
+// Unwrap returns the Playable without its articulations.
+func Unwrap(p Playable) Playable {
+	for {
+		a, ok := p.(Articulated)
+		if !ok {
+			return p
+		}
+		p = a.Playable
+	}
+}
+
+// ArticulationsOf returns the articulations of p, none for a plain Playable.
+func ArticulationsOf(p Playable) Articulation {
+	var result Articulation
+	for {
+		a, ok := p.(Articulated)
+		if !ok {
+			return result
+		}
+		result |= a.Articulations
+		p = a.Playable
+	}
+}
+
+type articulatedJSON struct {
+	Articulations Articulation    `json:"articulations"`
+	Playable      json.RawMessage `json:"playable"`
+}
+
+type articulatedYAML struct {
+	Articulations Articulation `yaml:"articulations"`
+	Playable      yaml.Node    `yaml:"playable"`
+}
+
+func (a Articulated) MarshalJSON() ([]byte, error) {
+	data, err := MarshalPlayable(a.Playable)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(articulatedJSON{Articulations: a.Articulations, Playable: data})
+}
+
+func (a *Articulated) UnmarshalJSON(data []byte) error {
+	schema := articulatedJSON{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return err
+	}
+
+	p, err := UnmarshalPlayable(schema.Playable)
+	if err != nil {
+		return err
+	}
+	*a = Articulated{Playable: p, Articulations: schema.Articulations}
+	return nil
+}
+
+func (a Articulated) MarshalYAML() (any, error) {
+	node, err := playableYAMLNode(a.Playable)
+	if err != nil {
+		return nil, err
+	}
+	return articulatedYAML{Articulations: a.Articulations, Playable: *node}, nil
+}
+
+func (a *Articulated) UnmarshalYAML(value *yaml.Node) error {
+	schema := articulatedYAML{}
+	if err := value.Decode(&schema); err != nil {
+		return err
+	}
+	if schema.Playable.Kind == 0 {
+		return fmt.Errorf("line %d: articulated without playable", value.Line)
+	}
+
+	p, err := playableFromYAML(&schema.Playable)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", schema.Playable.Line, err)
+	}
+	*a = Articulated{Playable: p, Articulations: schema.Articulations}
+	return nil
+}
+
+// annotateArticulations writes the articulations not shown in the staff
+// on the annotation lines: ranges for palm mute and let ring, marks for
+// accent and staccato.
+func (tb *TabWriter) annotateArticulations(notes []Playable) {
+	for _, n := range notes {
+		a := ArticulationsOf(n)
+		if a == 0 {
+			continue
+		}
+
+		end := n.StartTime() + tb.timeStep
+		if s, ok := n.(Sustained); ok && s.EndTime() > end {
+			end = s.EndTime()
+		}
+
+		if a.Has(PalmMute) {
+			tb.markRange(palmMuteText, n.StartTime(), end)
+		}
+		if a.Has(LetRing) {
+			tb.markRange(letRingText, n.StartTime(), end)
+		}
+		if a.Has(Accent) {
+			tb.markPoint(accentText, n.StartTime())
+		}
+		if a.Has(Staccato) {
+			tb.markPoint(staccatoText, n.StartTime())
+		}
+	}
+}
+
+// markRange adds a range annotation, extending one with the same text
+// that ends where this one starts.
+func (tb *TabWriter) markRange(text string, start, end float32) {
+	for i := len(tb.annotations) - 1; i >= 0; i-- {
+		a := &tb.annotations[i]
+		if a.Kind == RangeAnnotation && a.Text == text &&
+			a.Time <= start+timeEpsilon && a.End >= start-timeEpsilon {
+			a.End = max(a.End, end)
+			return
+		}
+	}
+	tb.annotations = append(tb.annotations, RangeMark(text, start, end))
+}
+
+// markPoint adds a text annotation once per time.
+func (tb *TabWriter) markPoint(text string, time float32) {
+	for _, a := range tb.annotations {
+		if a.Kind == TextAnnotation && a.Text == text && abs32(a.Time-time) < timeEpsilon {
+			return
+		}
+	}
+	tb.annotations = append(tb.annotations, TextAt(text, time))
+}
@@ -0,0 +1,111 @@
+package guitar
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArticulatedTabSymbol(t *testing.T) {
+	note := Note{Fret: 5, String: 0}
+
+	testCases := []struct {
+		name     string
+		p        Playable
+		expected string
+	}{
+		{name: "vibrato", p: Articulate(note, Vibrato), expected: "5~"},
+		{name: "wide vibrato", p: Articulate(note, Vibrato, WideVibrato), expected: "5~~"},
+		{name: "muted", p: Articulate(note, Muted), expected: "x"},
+		{name: "ghost", p: Articulate(note, Ghost), expected: "(5)"},
+		{name: "ghost vibrato", p: Articulate(Articulate(note, Ghost), Vibrato), expected: "(5)~"},
+		{name: "palm mute", p: Articulate(note, PalmMute), expected: "5"},
+		{name: "bend vibrato", p: Articulate(Bend{Fret: 7, Cents: FullStep}, Vibrato), expected: "7b9~"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.p.TabSymbol())
+		})
+	}
+}
+
+func TestArticulationsOf(t *testing.T) {
+	p := Articulated{Playable: Articulate(Note{Fret: 5, Time: 1, Duration: 0.5}, Accent), Articulations: PalmMute}
+
+	assert.Equal(t, Accent|PalmMute, ArticulationsOf(p))
+	assert.Equal(t, Articulation(0), ArticulationsOf(Note{}))
+	assert.Equal(t, Note{Fret: 5, Time: 1, Duration: 0.5}, Unwrap(p))
+	assert.InDelta(t, 1.5, p.EndTime(), 1e-4)
+	assert.InDelta(t, 3, Unwrap(p.AtTime(3)).StartTime(), 1e-4)
+	assert.Equal(t, "palm_mute|accent", (Accent | PalmMute).String())
+
+	a, err := ParseArticulation("let_ring")
+	assert.NoError(t, err)
+	assert.Equal(t, LetRing, a)
+	_, err = ParseArticulation("wobble")
+	assert.Error(t, err)
+}
+
+func TestWriteNotesArticulations(t *testing.T) {
+	tb, _ := NewTabWriter([]string{"e", "B"})
+	_ = tb.WriteNotes(
+		Articulate(Note{Fret: 5, String: 0, Time: 0}, PalmMute, Accent),
+		Articulate(Note{Fret: 5, String: 1, Time: 0}, PalmMute, Accent),
+		Articulate(Note{Fret: 7, String: 0, Time: 0.2}, PalmMute),
+		Articulate(Note{Fret: 7, String: 0, Time: 0.4}, PalmMute, Staccato),
+		Articulate(Note{Fret: 9, String: 1, Time: 0.8, Duration: 0.4}, LetRing, Vibrato),
+	)
+
+	expected := "" +
+		"  > .\n" +
+		"  P.M.|\n" +
+		"      let ring|\n" +
+		"e|577----\n" +
+		"B|5---9~-\n"
+	assert.Equal(t, expected, tb.Tab())
+}
+
+func TestArticulatedSplitAtBar(t *testing.T) {
+	head, tail, ok := splitPlayable(Articulate(Note{Fret: 5, String: 0, Duration: 0.4}, Vibrato), 0.2)
+
+	assert.True(t, ok)
+	assert.Equal(t, Articulate(Note{Fret: 5, String: 0, Duration: 0.2}, Vibrato), head)
+	assert.Equal(t, "5", tail.TabSymbol())
+	assert.InDelta(t, 0.2, tail.StartTime(), 1e-4)
+}
+
+func TestParseTabVibrato(t *testing.T) {
+	tun, _ := ParseTuning("E4 B3")
+
+	ps, err := ParseTab(strings.NewReader("e|-5~--7~~-|\nB|---------|\n"), tun)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Playable{
+		Articulate(Note{Name: "A", Octave: 4, Fret: 5, String: 0, Time: 0.2}, Vibrato),
+		Articulate(Note{Name: "B", Octave: 4, Fret: 7, String: 0, Time: 1}, WideVibrato),
+	}, ps)
+}
+
+func TestArticulatedEncoding(t *testing.T) {
+	ps := []Playable{Articulate(Note{Name: "A", Octave: 4, Fret: 5, Time: 1}, PalmMute, Vibrato)}
+
+	data, err := MarshalPlayables(ps)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"type":"articulated","articulations":["vibrato","palm_mute"],`+
+		`"playable":{"type":"note","name":"A","octave":4,"fret":5,"string":0,"time":1}}]`, string(data))
+
+	decoded, err := UnmarshalPlayables(data)
+	assert.NoError(t, err)
+	assert.Equal(t, ps, decoded)
+
+	data, err = MarshalPlayablesYAML(ps)
+	assert.NoError(t, err)
+	decoded, err = UnmarshalPlayablesYAML(data)
+	assert.NoError(t, err)
+	assert.Equal(t, ps, decoded)
+
+	_, err = UnmarshalPlayables([]byte(`[{"type":"articulated","articulations":["wobble"],"playable":{"type":"note"}}]`))
+	assert.Error(t, err)
+}
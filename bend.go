@@ -0,0 +1,404 @@
+package guitar
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Bend amounts in cents.
+const (
+	QuarterStep = 50
+	HalfStep    = 100
+	FullStep    = 200
+	StepAndHalf = 300
+)
+
+// BendPoint is a point of a bend curve: at Position, from 0 at the start
+// of the bend to 1 at its end, the string sounds Cents above the fret.
+type BendPoint struct {
+	Position float32 `json:"position" yaml:"position"`
+	Cents    int     `json:"cents" yaml:"cents"`
+}
+
+// BendCurve is the pitch of a bent string over the length of the bend,
+// with points in position order.
+type BendCurve []BendPoint
+
+func (c BendCurve) Validate() error {
+	if len(c) == 0 {
+		return errors.New("empty bend curve")
+	}
+	for i, p := range c {
+		if p.Position < 0 || p.Position > 1 {
+			return fmt.Errorf("bend point %d: invalid position %v", i, p.Position)
+		}
+		if p.Cents < 0 {
+			return fmt.Errorf("bend point %d: invalid cents %d", i, p.Cents)
+		}
+		if i > 0 && p.Position < c[i-1].Position {
+			return fmt.Errorf("bend point %d: points are not in position order", i)
+		}
+	}
+	return nil
+}
+
+// At returns the cents at position, interpolated between the points.
+func (c BendCurve) At(position float32) float32 {
+	if len(c) == 0 {
+		return 0
+	}
+	if position <= c[0].Position {
+		return float32(c[0].Cents)
+	}
+	for i := 1; i < len(c); i++ {
+		if position > c[i].Position {
+			continue
+		}
+		prev, next := c[i-1], c[i]
+		if next.Position == prev.Position {
+			return float32(next.Cents)
+		}
+		k := (position - prev.Position) / (next.Position - prev.Position)
+		return float32(prev.Cents) + k*float32(next.Cents-prev.Cents)
+	}
+	return float32(c[len(c)-1].Cents)
+}
+
+// Peak returns the highest cents of the curve.
+func (c BendCurve) Peak() int {
+	peak := 0
+	for _, p := range c {
+		peak = max(peak, p.Cents)
+	}
+	return peak
+}
+
+// Samples returns the curve at n evenly spaced positions, for exporters
+// that write pitch bend events.
+func (c BendCurve) Samples(n int) []BendPoint {
+	if n < 2 {
+		n = 2
+	}
+	samples := make([]BendPoint, n)
+	for i := range samples {
+		position := float32(i) / float32(n-1)
+		samples[i] = BendPoint{Position: position, Cents: int(math.Round(float64(c.At(position))))}
+	}
+	return samples
+}
+
+// Bender is a Playable whose pitch follows a bend curve over its duration.
+type Bender interface {
+	Playable
+	BendFret() int
+	BendCurve() BendCurve
+}
+
+// Bend raises a fretted string by Cents, e.g. "7b9".
+type Bend struct {
+	Fret  int `json:"fret" yaml:"fret"`
+	Cents int `json:"cents" yaml:"cents"`
+
+	String int `json:"string" yaml:"string"`
+
+	Time     float32 `json:"time,omitempty" yaml:"time,omitempty"`
+	Duration float32 `json:"duration,omitempty" yaml:"duration,omitempty"`
+}
+
+func (b Bend) TabSymbol() string {
+	if b.Cents == QuarterStep {
+		return quarterBendTechnique.Format(b.Fret)
+	}
+	return bendTechnique.Format(b.Fret, bentFret(b.Fret, b.Cents))
+}
+
+func (b Bend) StringNumber() int {
+	return b.String
+}
+
+func (b Bend) StartTime() float32 {
+	return b.Time
+}
+
+func (b Bend) EndTime() float32 {
+	return b.Time + b.Duration
+}
+
+func (b Bend) AtTime(t float32) Playable {
+	b.Time = t
+	return b
+}
+
+func (b Bend) BendFret() int {
+	return b.Fret
+}
+
+// BendCurve reaches the bend halfway and holds it.
+func (b Bend) BendCurve() BendCurve {
+	return BendCurve{{0, 0}, {0.5, b.Cents}, {1, b.Cents}}
+}
+
+func (b Bend) Validate() error {
+	return validateBend(b.Fret, b.Cents)
+}
+
+// PreBend is a string bent by Cents before it is picked, e.g. "(7)b9".
+type PreBend struct {
+	Fret  int `json:"fret" yaml:"fret"`
+	Cents int `json:"cents" yaml:"cents"`
+
+	String int `json:"string" yaml:"string"`
+
+	Time     float32 `json:"time,omitempty" yaml:"time,omitempty"`
+	Duration float32 `json:"duration,omitempty" yaml:"duration,omitempty"`
+}
+
+func (b PreBend) TabSymbol() string {
+	return preBendTechnique.Format(b.Fret, bentFret(b.Fret, b.Cents))
+}
+
+func (b PreBend) StringNumber() int {
+	return b.String
+}
+
+func (b PreBend) StartTime() float32 {
+	return b.Time
+}
+
+func (b PreBend) EndTime() float32 {
+	return b.Time + b.Duration
+}
+
+func (b PreBend) AtTime(t float32) Playable {
+	b.Time = t
+	return b
+}
+
+func (b PreBend) BendFret() int {
+	return b.Fret
+}
+
+func (b PreBend) BendCurve() BendCurve {
+	return BendCurve{{0, b.Cents}, {1, b.Cents}}
+}
+
+func (b PreBend) Validate() error {
+	return validateBend(b.Fret, b.Cents)
+}
+
+// BendRelease bends a string by Cents and lets it back down to Release
+// cents, usually 0, e.g. "7b9r7".
+type BendRelease struct {
+	Fret    int `json:"fret" yaml:"fret"`
+	Cents   int `json:"cents" yaml:"cents"`
+	Release int `json:"release,omitempty" yaml:"release,omitempty"`
+
+	String int `json:"string" yaml:"string"`
+
+	Time     float32 `json:"time,omitempty" yaml:"time,omitempty"`
+	Duration float32 `json:"duration,omitempty" yaml:"duration,omitempty"`
+}
+
+func (b BendRelease) TabSymbol() string {
+	return bendReleaseTechnique.Format(b.Fret, bentFret(b.Fret, b.Cents), bentFret(b.Fret, b.Release))
+}
+
+func (b BendRelease) StringNumber() int {
+	return b.String
+}
+
+func (b BendRelease) StartTime() float32 {
+	return b.Time
+}
+
+func (b BendRelease) EndTime() float32 {
+	return b.Time + b.Duration
+}
+
+func (b BendRelease) AtTime(t float32) Playable {
+	b.Time = t
+	return b
+}
+
+func (b BendRelease) BendFret() int {
+	return b.Fret
+}
+
+func (b BendRelease) BendCurve() BendCurve {
+	return BendCurve{{0, 0}, {1.0 / 3, b.Cents}, {2.0 / 3, b.Cents}, {1, b.Release}}
+}
+
+func (b BendRelease) Validate() error {
+	if err := validateBend(b.Fret, b.Cents); err != nil {
+		return err
+	}
+	if b.Release < 0 || b.Release > b.Cents {
+		return fmt.Errorf("release %d is out of the bend of %d cents", b.Release, b.Cents)
+	}
+	return nil
+}
+
+func validateBend(fret, cents int) error {
+	if fret < 0 {
+		return fmt.Errorf("invalid fret %d", fret)
+	}
+	if cents <= 0 {
+		return fmt.Errorf("invalid bend of %d cents", cents)
+	}
+	return nil
+}
+
+// CompoundBend follows an arbitrary curve, e.g. a bend, release and
+// bend again written "7b9r7b9".
+type CompoundBend struct {
+	Fret  int       `json:"fret" yaml:"fret"`
+	Curve BendCurve `json:"curve" yaml:"curve"`
+
+	String int `json:"string" yaml:"string"`
+
+	Time     float32 `json:"time,omitempty" yaml:"time,omitempty"`
+	Duration float32 `json:"duration,omitempty" yaml:"duration,omitempty"`
+}
+
+// TabSymbol writes the turning points of the curve rounded to frets,
+// "b" going up and "r" coming down.
+func (b CompoundBend) TabSymbol() string {
+	levels := []int{}
+	for _, p := range b.Curve {
+		level := bentFret(b.Fret, p.Cents)
+		if len(levels) > 0 && levels[len(levels)-1] == level {
+			continue
+		}
+		// keep only the extreme of a run going the same way
+		if n := len(levels); n > 1 && (levels[n-1] > levels[n-2]) == (level > levels[n-1]) {
+			levels[n-1] = level
+			continue
+		}
+		levels = append(levels, level)
+	}
+	if len(levels) == 0 {
+		return noteTechnique.Format(b.Fret)
+	}
+
+	sb := strings.Builder{}
+	if levels[0] != b.Fret {
+		sb.WriteString(preBendTechnique.Format(b.Fret, levels[0]))
+	} else {
+		sb.WriteString(noteTechnique.Format(b.Fret))
+	}
+	for i := 1; i < len(levels); i++ {
+		if levels[i] > levels[i-1] {
+			sb.WriteString("b")
+		} else {
+			sb.WriteString("r")
+		}
+		sb.WriteString(noteTechnique.Format(levels[i]))
+	}
+	return sb.String()
+}
+
+func (b CompoundBend) StringNumber() int {
+	return b.String
+}
+
+func (b CompoundBend) StartTime() float32 {
+	return b.Time
+}
+
+func (b CompoundBend) EndTime() float32 {
+	return b.Time + b.Duration
+}
+
+func (b CompoundBend) AtTime(t float32) Playable {
+	b.Time = t
+	return b
+}
+
+func (b CompoundBend) BendFret() int {
+	return b.Fret
+}
+
+func (b CompoundBend) BendCurve() BendCurve {
+	return b.Curve
+}
+
+func (b CompoundBend) Validate() error {
+	if b.Fret < 0 {
+		return fmt.Errorf("invalid fret %d", b.Fret)
+	}
+	return b.Curve.Validate()
+}
+
+// validateBender checks p when it is a bend, also inside articulations.
+func validateBender(p Playable) error {
+	b, ok := Unwrap(p).(Bender)
+	if !ok {
+		return nil
+	}
+	if v, ok := b.(interface{ Validate() error }); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// bendFromCurve returns the simplest bend following curve: a PreBend, a
+// Bend, a BendRelease or else a CompoundBend. A flat curve at 0 is a
+// plain Note.
+func bendFromCurve(curve BendCurve, fret, stringNumber int, time, duration float32) Playable {
+	// the levels the string turns at
+	levels := []int{}
+	for _, p := range curve {
+		n := len(levels)
+		if n > 0 && levels[n-1] == p.Cents {
+			continue
+		}
+		if n > 1 && (levels[n-1] > levels[n-2]) == (p.Cents > levels[n-1]) {
+			levels[n-1] = p.Cents
+			continue
+		}
+		levels = append(levels, p.Cents)
+	}
+
+	switch {
+	case len(levels) == 0 || len(levels) == 1 && levels[0] == 0:
+		return Note{Fret: fret, String: stringNumber, Time: time, Duration: duration}
+	case len(levels) == 1:
+		return PreBend{Fret: fret, Cents: levels[0], String: stringNumber, Time: time, Duration: duration}
+	case len(levels) == 2 && levels[0] == 0:
+		return Bend{Fret: fret, Cents: levels[1], String: stringNumber, Time: time, Duration: duration}
+	case len(levels) == 3 && levels[0] == 0 && levels[2] < levels[1]:
+		return BendRelease{Fret: fret, Cents: levels[1], Release: levels[2], String: stringNumber, Time: time, Duration: duration}
+	}
+	return CompoundBend{Fret: fret, Curve: curve, String: stringNumber, Time: time, Duration: duration}
+}
+
+// bentFret returns the fret sounding the pitch of fret bent by cents,
+// rounded to the nearest semitone.
+func bentFret(fret, cents int) int {
+	return fret + int(math.Round(float64(cents)/100))
+}
+
+// SoundingPitch returns the note a bend sounds at position, from 0 at
+// its start to 1 at its end, and the cents it is above that note.
+func (fb *FingerBoard) SoundingPitch(b Bender, position float32) (Note, int, error) {
+	if b.BendFret() > fb.frets {
+		return Note{}, 0, fmt.Errorf("fret %d is out of the %d frets", b.BendFret(), fb.frets)
+	}
+
+	cents := int(math.Round(float64(b.BendCurve().At(position))))
+	note, err := fb.tuning.NoteAt(b.StringNumber(), b.BendFret())
+	if err != nil {
+		return Note{}, 0, err
+	}
+	for range cents / 100 {
+		if err := note.AddFret(); err != nil {
+			return Note{}, 0, err
+		}
+	}
+
+	note.Fret = b.BendFret()
+	note.Time = b.StartTime()
+	return note, cents % 100, nil
+}
@@ -0,0 +1,192 @@
+package guitar
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBendTabSymbol(t *testing.T) {
+	testCases := []struct {
+		name     string
+		bend     Playable
+		expected string
+	}{
+		{name: "full", bend: Bend{Fret: 7, Cents: FullStep}, expected: "7b9"},
+		{name: "half", bend: Bend{Fret: 7, Cents: HalfStep}, expected: "7b8"},
+		{name: "step and half", bend: Bend{Fret: 12, Cents: StepAndHalf}, expected: "12b15"},
+		{name: "quarter", bend: Bend{Fret: 5, Cents: QuarterStep}, expected: "5b¼"},
+		{name: "cents", bend: Bend{Fret: 5, Cents: 180}, expected: "5b7"},
+		{name: "pre-bend", bend: PreBend{Fret: 7, Cents: FullStep}, expected: "(7)b9"},
+		{name: "release", bend: BendRelease{Fret: 7, Cents: FullStep}, expected: "7b9r7"},
+		{name: "partial release", bend: BendRelease{Fret: 7, Cents: FullStep, Release: HalfStep}, expected: "7b9r8"},
+		{
+			name: "compound",
+			bend: CompoundBend{Fret: 7, Curve: BendCurve{
+				{0, 0}, {0.2, 100}, {0.3, 200}, {0.5, 0}, {0.8, 200}, {1, 200},
+			}},
+			expected: "7b9r7b9",
+		},
+		{
+			name:     "compound pre-bent",
+			bend:     CompoundBend{Fret: 7, Curve: BendCurve{{0, 200}, {1, 0}}},
+			expected: "(7)b9r7",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.bend.TabSymbol())
+		})
+	}
+}
+
+func TestBendCurve(t *testing.T) {
+	c := BendRelease{Fret: 7, Cents: FullStep}.BendCurve()
+
+	assert.NoError(t, c.Validate())
+	assert.Equal(t, FullStep, c.Peak())
+	assert.InDelta(t, 0, c.At(0), 1e-4)
+	assert.InDelta(t, 100, c.At(1.0/6), 1e-3)
+	assert.InDelta(t, 200, c.At(0.5), 1e-4)
+	assert.InDelta(t, 0, c.At(1), 1e-4)
+
+	samples := Bend{Cents: HalfStep}.BendCurve().Samples(3)
+	assert.Equal(t, []BendPoint{{0, 0}, {0.5, 100}, {1, 100}}, samples)
+
+	assert.Error(t, BendCurve{}.Validate())
+	assert.Error(t, BendCurve{{0.5, 0}, {0.2, 100}}.Validate())
+	assert.Error(t, BendCurve{{0, -100}}.Validate())
+}
+
+func TestBendValidate(t *testing.T) {
+	testCases := []struct {
+		name  string
+		bend  interface{ Validate() error }
+		valid bool
+	}{
+		{name: "bend", bend: Bend{Fret: 7, Cents: FullStep}, valid: true},
+		{name: "bend without cents", bend: Bend{Fret: 7}},
+		{name: "bend down", bend: Bend{Fret: 7, Cents: -HalfStep}},
+		{name: "bend below the nut", bend: Bend{Fret: -1, Cents: HalfStep}},
+		{name: "pre-bend", bend: PreBend{Fret: 7, Cents: FullStep}, valid: true},
+		{name: "pre-bend without cents", bend: PreBend{Fret: 7}},
+		{name: "release", bend: BendRelease{Fret: 7, Cents: FullStep, Release: HalfStep}, valid: true},
+		{name: "release without cents", bend: BendRelease{Fret: 7}},
+		{name: "release above the bend", bend: BendRelease{Fret: 7, Cents: HalfStep, Release: FullStep}},
+		{name: "release below the fret", bend: BendRelease{Fret: 7, Cents: FullStep, Release: -HalfStep}},
+		{name: "compound", bend: CompoundBend{Fret: 7, Curve: BendCurve{{0, 0}, {0.5, 200}, {1, 100}}}, valid: true},
+		{name: "compound without curve", bend: CompoundBend{Fret: 7}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.valid {
+				assert.NoError(t, tc.bend.Validate())
+			} else {
+				assert.Error(t, tc.bend.Validate())
+			}
+		})
+	}
+}
+
+func TestSoundingPitch(t *testing.T) {
+	tun, _ := ParseTuning(StandardTuning)
+	fb, _ := NewFingerBoard(tun, 22)
+
+	note, cents, err := fb.SoundingPitch(Bend{Fret: 7, Cents: FullStep, String: 2}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "E", note.Name)
+	assert.Equal(t, 4, note.Octave)
+	assert.Equal(t, 7, note.Fret)
+	assert.Equal(t, 0, cents)
+
+	note, cents, err = fb.SoundingPitch(Bend{Fret: 7, Cents: QuarterStep, String: 2}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "D", note.Name)
+	assert.Equal(t, 50, cents)
+
+	note, _, err = fb.SoundingPitch(PreBend{Fret: 7, Cents: HalfStep, String: 2}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "D#", note.Name)
+
+	_, _, err = fb.SoundingPitch(Bend{Fret: 23, Cents: FullStep, String: 2}, 1)
+	assert.Error(t, err)
+}
+
+func TestParseTabBends(t *testing.T) {
+	tun, _ := ParseTuning("E4 B3")
+
+	ps, err := ParseTab(strings.NewReader("e|-7b9---(7)b9--|\nB|-7b9r7--5b¼---|\n"), tun)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Playable{
+		Bend{Fret: 7, Cents: FullStep, String: 0, Time: 0.2},
+		BendRelease{Fret: 7, Cents: FullStep, String: 1, Time: 0.2},
+		PreBend{Fret: 7, Cents: FullStep, String: 0, Time: 1.4},
+		Bend{Fret: 5, Cents: QuarterStep, String: 1, Time: 1.6},
+	}, ps)
+
+	// a bend down or a release above the bend is not read
+	for _, tab := range []string{"e|--9b7--|\nB|-------|\n", "e|--7b9r11--|\nB|----------|\n"} {
+		_, err = ParseTab(strings.NewReader(tab), tun)
+		var parseErr *TabParseError
+		assert.ErrorAs(t, err, &parseErr)
+		assert.Equal(t, 5, parseErr.Column)
+	}
+}
+
+func TestWriteInvalidBends(t *testing.T) {
+	tun, _ := ParseTuning(StandardTuning)
+	bend := Articulate(Bend{Fret: 9, Cents: -FullStep, String: 0}, Vibrato)
+
+	tb, _ := NewTabWriter(tun.NoteNames())
+	assert.Error(t, tb.WriteNotes(bend))
+	assert.Error(t, WriteMIDI(&bytes.Buffer{}, tun, []Playable{bend}))
+
+	s := NewSong("", "")
+	tr, _ := NewTrack("", tun, 24)
+	tr.Add(bend)
+	s.AddTrack(tr)
+	assert.Error(t, s.Validate())
+	assert.Error(t, s.WriteMusicXML(&bytes.Buffer{}))
+}
+
+func TestWriteNotesBends(t *testing.T) {
+	tb, _ := NewTabWriter([]string{"e", "B"})
+	_ = tb.WriteNotes(Bend{Fret: 5, Cents: QuarterStep, String: 0}, PreBend{Fret: 7, Cents: FullStep, String: 1})
+
+	lines := strings.Split(strings.TrimSpace(tb.Tab()), "\n")
+	assert.Equal(t, "e|5b¼---", lines[0])
+	assert.Equal(t, "B|(7)b9-", lines[1])
+}
+
+func TestBendFromCurve(t *testing.T) {
+	testCases := []struct {
+		name     string
+		curve    BendCurve
+		expected Playable
+	}{
+		{name: "flat", curve: BendCurve{{0, 0}, {1, 0}}, expected: Note{Fret: 7, String: 2}},
+		{name: "pre-bend", curve: BendCurve{{0, 200}, {1, 200}}, expected: PreBend{Fret: 7, Cents: 200, String: 2}},
+		{name: "bend", curve: BendCurve{{0, 0}, {0.3, 100}, {0.6, 200}, {1, 200}}, expected: Bend{Fret: 7, Cents: 200, String: 2}},
+		{
+			name:     "release",
+			curve:    BendCurve{{0, 0}, {0.3, 200}, {0.6, 200}, {1, 100}},
+			expected: BendRelease{Fret: 7, Cents: 200, Release: 100, String: 2},
+		},
+		{
+			name:     "compound",
+			curve:    BendCurve{{0, 200}, {0.5, 0}, {1, 200}},
+			expected: CompoundBend{Fret: 7, Curve: BendCurve{{0, 200}, {0.5, 0}, {1, 200}}, String: 2},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, bendFromCurve(tc.curve, 7, 2, 0, 0))
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package guitar
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Fret values of a chord voicing that are not a fretted note.
+const (
+	// ChordSkip leaves a string out of the chord.
+	ChordSkip = -1
+	// ChordMuted strikes a string muted, written "x".
+	ChordMuted = -2
+)
+
+type StrumDirection int
+
+const (
+	// NoStrum plays every string at once.
+	NoStrum StrumDirection = iota
+	// StrumDown goes from the lowest sounding string, the last one, to string 0.
+	StrumDown
+	// StrumUp goes from string 0 to the lowest sounding string.
+	StrumUp
+)
+
+var strumNames = []string{"none", "down", "up"}
+
+// strumArrowSymbols are written above the tab by WithStrumArrows.
+var strumArrowSymbols = []string{"", "↓", "↑"}
+
+func (d StrumDirection) String() string {
+	if d < 0 || int(d) >= len(strumNames) {
+		return fmt.Sprintf("StrumDirection(%d)", int(d))
+	}
+	return strumNames[d]
+}
+
+func (d StrumDirection) MarshalText() ([]byte, error) {
+	if d < 0 || int(d) >= len(strumNames) {
+		return nil, fmt.Errorf("invalid strum direction %d", int(d))
+	}
+	return []byte(d.String()), nil
+}
+
+func (d *StrumDirection) UnmarshalText(text []byte) error {
+	for i, name := range strumNames {
+		if name == string(text) {
+			*d = StrumDirection(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown strum direction %q", text)
+}
+
+// Chord is a voicing played as one event. Frets has one value per
+// string, string 0 first, with ChordSkip and ChordMuted for strings not
+// fretted. A strum plays the strings Spread seconds apart; all of them
+// stop together at the end of the chord.
+type Chord struct {
+	Name  string `json:"name,omitempty" yaml:"name,omitempty"`
+	Frets []int  `json:"frets" yaml:"frets"`
+
+	Strum  StrumDirection `json:"strum,omitempty" yaml:"strum,omitempty"`
+	Spread float32        `json:"spread,omitempty" yaml:"spread,omitempty"`
+
+	Time     float32 `json:"time,omitempty" yaml:"time,omitempty"`
+	Duration float32 `json:"duration,omitempty" yaml:"duration,omitempty"`
+}
+
+func NewChord(name string, frets []int, time float32) Chord {
+	return Chord{Name: name, Frets: append([]int(nil), frets...), Time: time}
+}
+
+// Strummed returns the chord strummed in direction with spread seconds
+// between strings. A spread longer than a fast strum makes a roll.
+func (c Chord) Strummed(direction StrumDirection, spread float32) Chord {
+	c.Strum, c.Spread = direction, spread
+	return c
+}
+
+func (c Chord) Validate() error {
+	played := false
+	for i, f := range c.Frets {
+		if f < ChordMuted {
+			return fmt.Errorf("string %d: invalid fret %d", i, f)
+		}
+		played = played || f != ChordSkip
+	}
+	if !played {
+		return fmt.Errorf("chord %q plays no string", c.Name)
+	}
+	if c.Strum < NoStrum || c.Strum > StrumUp {
+		return fmt.Errorf("invalid strum direction %d", c.Strum)
+	}
+	if c.Spread < 0 {
+		return fmt.Errorf("invalid strum spread %v", c.Spread)
+	}
+	return nil
+}
+
+// TabSymbol writes the voicing in the ParseChord format, string 0 first,
+// e.g. "0 1 0 2 3 x".
+func (c Chord) TabSymbol() string {
+	symbols := make([]string, len(c.Frets))
+	for i, f := range c.Frets {
+		switch f {
+		case ChordSkip:
+			symbols[i] = "-"
+		case ChordMuted:
+			symbols[i] = deadNoteTechnique.Format()
+		default:
+			symbols[i] = noteTechnique.Format(f)
+		}
+	}
+	return strings.Join(symbols, " ")
+}
+
+// StringNumber returns the lowest sounding string of the chord.
+func (c Chord) StringNumber() int {
+	for i := len(c.Frets) - 1; i >= 0; i-- {
+		if c.Frets[i] != ChordSkip {
+			return i
+		}
+	}
+	return 0
+}
+
+func (c Chord) StartTime() float32 {
+	return c.Time
+}
+
+func (c Chord) EndTime() float32 {
+	return c.Time + c.Duration
+}
+
+func (c Chord) AtTime(t float32) Playable {
+	c.Time = t
+	return c
+}
+
+// strings returns the played strings in the order the strum hits them.
+func (c Chord) strings() []int {
+	order := []int{}
+	for i, f := range c.Frets {
+		if f != ChordSkip {
+			order = append(order, i)
+		}
+	}
+	if c.Strum == StrumDown {
+		for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+			order[i], order[j] = order[j], order[i]
+		}
+	}
+	return order
+}
+
+// note returns the Playable of one string starting at time.
+func (c Chord) note(stringNumber int, time float32) Playable {
+	duration := max(0, c.EndTime()-time)
+	if c.Frets[stringNumber] == ChordMuted {
+		return DeadNote{String: stringNumber, Time: time, Duration: duration}
+	}
+	return Note{Fret: c.Frets[stringNumber], String: stringNumber, Time: time, Duration: duration}
+}
+
+// Notes returns a Playable per played string, offset in time by the
+// strum: Notes for fretted strings and DeadNotes for muted ones.
+func (c Chord) Notes() []Playable {
+	notes := []Playable{}
+	for i, s := range c.strings() {
+		time := c.Time
+		if c.Strum != NoStrum {
+			time += float32(i) * c.Spread
+		}
+		notes = append(notes, c.note(s, time))
+	}
+	return notes
+}
+
+// Arpeggiate plays the strings in order, step seconds apart, each one
+// ringing until the end of the chord.
+func (c Chord) Arpeggiate(order []int, step float32) ([]Playable, error) {
+	notes := []Playable{}
+	for i, s := range order {
+		if s < 0 || s >= len(c.Frets) || c.Frets[s] == ChordSkip {
+			return nil, fmt.Errorf("string %d is not played in chord %q", s, c.Name)
+		}
+		notes = append(notes, c.note(s, c.Time+float32(i)*step))
+	}
+	return notes, nil
+}
+
+// WithStrumArrows writes an arrow above strummed chords, "↓" for down
+// strums and "↑" for up strums.
+func WithStrumArrows() TabOption {
+	return func(tb *TabWriter) {
+		tb.strumArrows = true
+	}
+}
+
+// WithChordNames writes the name of every named Chord above the tab.
+func WithChordNames() TabOption {
+	return func(tb *TabWriter) {
+		tb.chordNames = true
+	}
+}
+
+// expandChords replaces every Chord by its strings, all written in the
+// column of the chord, and returns the chords found.
+func expandChords(notes []Playable) ([]Playable, []Chord, error) {
+	result := make([]Playable, 0, len(notes))
+	chords := []Chord{}
+	for _, n := range notes {
+		c, ok := n.(Chord)
+		if !ok {
+			result = append(result, n)
+			continue
+		}
+		if err := c.Validate(); err != nil {
+			return nil, nil, err
+		}
+
+		for _, s := range c.strings() {
+			result = append(result, c.note(s, c.Time))
+		}
+		chords = append(chords, c)
+	}
+	return result, chords, nil
+}
+
+// annotateChords writes the names and strum arrows of chords when the
+// tab asks for them.
+func (tb *TabWriter) annotateChords(chords []Chord) {
+	for _, c := range chords {
+		if tb.chordNames && c.Name != "" {
+			tb.annotations = append(tb.annotations, ChordSymbol(c.Name, c.Time))
+		}
+		if tb.strumArrows && c.Strum != NoStrum {
+			tb.markPoint(strumArrowSymbols[c.Strum], c.Time)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package guitar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testC = NewChord("C", []int{0, 1, 0, 2, 3, ChordMuted}, 1)
+
+func TestChord(t *testing.T) {
+	assert.NoError(t, testC.Validate())
+	assert.Equal(t, "0 1 0 2 3 x", testC.TabSymbol())
+	assert.Equal(t, 5, testC.StringNumber())
+	assert.Equal(t, 3, NewChord("E5", []int{ChordSkip, ChordSkip, ChordSkip, 2, ChordSkip, ChordSkip}, 0).StringNumber())
+
+	assert.Error(t, NewChord("", []int{ChordSkip, ChordSkip}, 0).Validate())
+	assert.Error(t, NewChord("", []int{-3}, 0).Validate())
+	assert.Error(t, testC.Strummed(StrumDown, -1).Validate())
+	assert.Error(t, testC.Strummed(StrumDirection(7), 0).Validate())
+}
+
+func TestChordNotes(t *testing.T) {
+	chord := NewChord("Em", []int{ChordSkip, 0, 0, 2, 2, 0}, 1)
+	chord.Duration = 1
+
+	assert.Equal(t, []Playable{
+		Note{Fret: 0, String: 1, Time: 1, Duration: 1},
+		Note{Fret: 0, String: 2, Time: 1, Duration: 1},
+		Note{Fret: 2, String: 3, Time: 1, Duration: 1},
+		Note{Fret: 2, String: 4, Time: 1, Duration: 1},
+		Note{Fret: 0, String: 5, Time: 1, Duration: 1},
+	}, chord.Notes())
+
+	down := chord.Strummed(StrumDown, 0.25).Notes()
+	assert.Equal(t, Note{Fret: 0, String: 5, Time: 1, Duration: 1}, down[0])
+	assert.Equal(t, Note{Fret: 0, String: 1, Time: 2, Duration: 0}, down[4])
+
+	up := chord.Strummed(StrumUp, 0.1).Notes()
+	assert.Equal(t, 1, up[0].StringNumber())
+	assert.InDelta(t, 1.4, up[4].StartTime(), 1e-4)
+
+	arpeggio, err := chord.Arpeggiate([]int{5, 3, 2, 1}, 0.25)
+	assert.NoError(t, err)
+	assert.Equal(t, Note{Fret: 2, String: 3, Time: 1.25, Duration: 0.75}, arpeggio[1])
+
+	_, err = chord.Arpeggiate([]int{0}, 0.25)
+	assert.Error(t, err)
+}
+
+func TestWriteNotesChord(t *testing.T) {
+	tb, _ := NewTabWriter([]string{"e", "B", "G", "D", "A", "E"}, WithStrumArrows(), WithChordNames())
+	err := tb.WriteNotes(
+		NewChord("C", []int{0, 1, 0, 2, 3, ChordMuted}, 0).Strummed(StrumDown, 0.01),
+		NewChord("G", []int{3, 0, 0, 0, 2, 3}, 0.4).Strummed(StrumUp, 0.01),
+	)
+	assert.NoError(t, err)
+
+	expected := "" +
+		"  ↓ ↑\n" +
+		"  C G\n" +
+		"e|0-3-\n" +
+		"B|1-0-\n" +
+		"G|0-0-\n" +
+		"D|2-0-\n" +
+		"A|3-2-\n" +
+		"E|x-3-\n"
+	assert.Equal(t, expected, tb.Tab())
+
+	assert.Error(t, tb.WriteNotes(NewChord("", []int{ChordSkip}, 1)))
+}
+
+func TestChordEncoding(t *testing.T) {
+	ps := []Playable{testC.Strummed(StrumUp, 0.02)}
+
+	data, err := MarshalPlayables(ps)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"type":"chord","name":"C","frets":[0,1,0,2,3,-2],"strum":"up","spread":0.02,"time":1}]`, string(data))
+
+	decoded, err := UnmarshalPlayables(data)
+	assert.NoError(t, err)
+	assert.Equal(t, ps, decoded)
+
+	data, err = MarshalPlayablesYAML(ps)
+	assert.NoError(t, err)
+	decoded, err = UnmarshalPlayablesYAML(data)
+	assert.NoError(t, err)
+	assert.Equal(t, ps, decoded)
+}
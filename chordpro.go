@@ -0,0 +1,483 @@
+package guitar
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// chordProSectionGap is the silence, in seconds, between two tab sections
+// played one after the other: one column at the default tab time step.
+const chordProSectionGap = 0.2
+
+// ChordProSheet is a song sheet in the ChordPro format: metadata
+// directives, lyric lines with inline chords and tab sections.
+type ChordProSheet struct {
+	Title    string
+	Subtitle string
+	Artist   string
+	Key      string
+	Capo     int
+	Tempo    float32
+	Time     TimeSignature
+
+	// Tuning reads and writes the tab sections.
+	Tuning   Tuning
+	Sections []ChordProSection
+}
+
+// ChordProSection is a run of lines between {start_of_...} and
+// {end_of_...}, Kind being "verse", "chorus", "tab" and so on, or
+// empty for lines outside any section.
+type ChordProSection struct {
+	Kind  string
+	Label string
+	Lines []ChordProLine
+	// Tab holds the Playables of a tab section, read with ParseTab.
+	Tab []Playable
+}
+
+// ChordProLine is a lyric line with its chords, or a directive such as
+// a comment kept in place when Directive is set.
+type ChordProLine struct {
+	Text      string
+	Chords    []ChordProChord
+	Directive string
+}
+
+// ChordProChord is a chord written before the rune at Offset in the
+// text of its line, e.g. "[Am]".
+type ChordProChord struct {
+	Name   string
+	Offset int
+}
+
+// chordProAliases are the short forms of directives.
+var chordProAliases = map[string]string{
+	"t": "title", "st": "subtitle", "c": "comment", "ci": "comment_italic", "cb": "comment_box",
+	"soc": "start_of_chorus", "eoc": "end_of_chorus", "sov": "start_of_verse", "eov": "end_of_verse",
+	"sob": "start_of_bridge", "eob": "end_of_bridge", "sot": "start_of_tab", "eot": "end_of_tab",
+	"sog": "start_of_grid", "eog": "end_of_grid",
+}
+
+type ChordProOption func(*ChordProSheet)
+
+// WithChordProTuning sets the tuning tab sections are read with. The
+// default is standard tuning.
+func WithChordProTuning(tuning Tuning) ChordProOption {
+	return func(c *ChordProSheet) {
+		c.Tuning = tuning
+	}
+}
+
+// ReadChordPro reads a ChordPro sheet. Tab sections are parsed into
+// Playables, lines starting with "#" are skipped.
+func ReadChordPro(r io.Reader, opts ...ChordProOption) (*ChordProSheet, error) {
+	sheet := &ChordProSheet{}
+	for _, opt := range opts {
+		opt(sheet)
+	}
+	if len(sheet.Tuning) == 0 {
+		tuning, err := ParseTuning(StandardTuning)
+		if err != nil {
+			return nil, err
+		}
+		sheet.Tuning = tuning
+	}
+
+	section := ChordProSection{}
+	sectionLine := 0
+	closeSection := func() error {
+		if section.Kind == "tab" {
+			if err := sheet.parseTab(&section, sectionLine); err != nil {
+				return err
+			}
+		}
+		if section.Kind == "" {
+			// blank lines between sections are not kept
+			for len(section.Lines) > 0 && section.Lines[len(section.Lines)-1].String() == "" {
+				section.Lines = section.Lines[:len(section.Lines)-1]
+			}
+		}
+		if section.Kind != "" || len(section.Lines) > 0 {
+			sheet.Sections = append(sheet.Sections, section)
+		}
+		section = ChordProSection{}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		text := strings.TrimRight(scanner.Text(), " \t\r")
+
+		if section.Kind == "tab" || section.Kind == "grid" {
+			name, _, ok := chordProDirective(text)
+			if !ok || name != "end_of_"+section.Kind {
+				section.Lines = append(section.Lines, ChordProLine{Text: text})
+				continue
+			}
+		}
+		if strings.HasPrefix(strings.TrimSpace(text), "#") {
+			continue
+		}
+
+		name, value, ok := chordProDirective(text)
+		if !ok {
+			line, err := parseChordProLine(text)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			section.Lines = append(section.Lines, line)
+			continue
+		}
+
+		if kind, ok := strings.CutPrefix(name, "start_of_"); ok {
+			if err := closeSection(); err != nil {
+				return nil, err
+			}
+			section.Kind, section.Label = kind, chordProLabel(value)
+			sectionLine = lineNumber
+			continue
+		}
+		if kind, ok := strings.CutPrefix(name, "end_of_"); ok {
+			if kind != section.Kind {
+				return nil, fmt.Errorf("line %d: {%s} closes no %s section", lineNumber, name, kind)
+			}
+			if err := closeSection(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var err error
+		switch name {
+		case "title":
+			sheet.Title = value
+		case "subtitle":
+			sheet.Subtitle = value
+		case "artist":
+			sheet.Artist = value
+		case "key":
+			sheet.Key = value
+		case "capo":
+			sheet.Capo, err = strconv.Atoi(value)
+		case "tempo":
+			var bpm float64
+			bpm, err = strconv.ParseFloat(value, 32)
+			sheet.Tempo = float32(bpm)
+		case "time":
+			sheet.Time, err = parseTimeSignature(value)
+		default:
+			section.Lines = append(section.Lines, ChordProLine{Text: value, Directive: name})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: {%s}: %w", lineNumber, name, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := closeSection(); err != nil {
+		return nil, err
+	}
+	return sheet, nil
+}
+
+// parseTab reads the lines of a tab section starting after line start,
+// reporting errors at their line in the sheet.
+func (c *ChordProSheet) parseTab(section *ChordProSection, start int) error {
+	texts := make([]string, len(section.Lines))
+	for i, l := range section.Lines {
+		texts[i] = l.Text
+	}
+	tab, err := ParseTab(strings.NewReader(strings.Join(texts, "\n")), c.Tuning)
+	if err != nil {
+		var pe *TabParseError
+		if errors.As(err, &pe) {
+			moved := *pe
+			moved.Line += start
+			return &moved
+		}
+		return fmt.Errorf("tab at line %d: %w", start, err)
+	}
+	section.Tab = tab
+	return nil
+}
+
+// chordProDirective splits "{name: value}" or "{name value}" into the
+// full directive name and its value.
+func chordProDirective(text string) (string, string, bool) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "{") || !strings.HasSuffix(text, "}") {
+		return "", "", false
+	}
+	body := strings.TrimSpace(text[1 : len(text)-1])
+
+	name, value := body, ""
+	if i := strings.IndexAny(body, ": \t"); i >= 0 {
+		name, value = body[:i], strings.TrimSpace(body[i+1:])
+	}
+	name = strings.ToLower(name)
+	if full, ok := chordProAliases[name]; ok {
+		name = full
+	}
+	return name, value, true
+}
+
+// chordProLabel reads a section label given as is or as label="...".
+func chordProLabel(value string) string {
+	if rest, ok := strings.CutPrefix(value, "label="); ok {
+		return strings.Trim(rest, `"'`)
+	}
+	return value
+}
+
+func parseChordProLine(text string) (ChordProLine, error) {
+	line := ChordProLine{}
+	sb := strings.Builder{}
+	offset := 0
+	for rest := text; rest != ""; {
+		open := strings.IndexByte(rest, '[')
+		if open < 0 {
+			sb.WriteString(rest)
+			break
+		}
+		end := strings.IndexByte(rest[open:], ']')
+		if end < 0 {
+			return line, fmt.Errorf("unclosed chord %q", rest[open:])
+		}
+
+		sb.WriteString(rest[:open])
+		offset += len([]rune(rest[:open]))
+		line.Chords = append(line.Chords, ChordProChord{Name: rest[open+1 : open+end], Offset: offset})
+		rest = rest[open+end+1:]
+	}
+	line.Text = sb.String()
+	return line, nil
+}
+
+func parseTimeSignature(text string) (TimeSignature, error) {
+	beats, unit, ok := strings.Cut(strings.TrimSpace(text), "/")
+	if !ok {
+		return TimeSignature{}, fmt.Errorf("invalid time signature %q", text)
+	}
+	ts := TimeSignature{}
+	var err error
+	if ts.Beats, err = strconv.Atoi(beats); err != nil {
+		return ts, fmt.Errorf("invalid time signature %q", text)
+	}
+	if ts.BeatUnit, err = strconv.Atoi(unit); err != nil {
+		return ts, fmt.Errorf("invalid time signature %q", text)
+	}
+	return ts, ts.Validate()
+}
+
+// String writes the line with its chords in brackets.
+func (l ChordProLine) String() string {
+	if l.Directive != "" {
+		if l.Text == "" {
+			return "{" + l.Directive + "}"
+		}
+		return "{" + l.Directive + ": " + l.Text + "}"
+	}
+
+	sb := strings.Builder{}
+	runes := []rune(l.Text)
+	pos := 0
+	for _, c := range l.Chords {
+		at := min(max(c.Offset, pos), len(runes))
+		sb.WriteString(string(runes[pos:at]))
+		sb.WriteString("[" + c.Name + "]")
+		pos = at
+	}
+	sb.WriteString(string(runes[pos:]))
+	return sb.String()
+}
+
+// Transpose moves the key, every chord and the tab sections by
+// semitones. Chords are spelled with flats when the new key has flats,
+// and tab sections are written again from their moved Playables.
+func (c *ChordProSheet) Transpose(semitones int) error {
+	key, rename := c.Key, TransposeChordName
+	if key != "" {
+		moved, err := transposeKey(key, semitones)
+		if err != nil {
+			return err
+		}
+		fifths, _, _ := parseKey(moved)
+		key = moved
+		rename = func(name string, semitones int) (string, error) {
+			return transposeChordName(name, semitones, fifths < 0)
+		}
+	}
+
+	sections := make([]ChordProSection, len(c.Sections))
+	for i, s := range c.Sections {
+		if s.Kind == "tab" {
+			tab, err := Transpose(s.Tab, semitones)
+			if err != nil {
+				return fmt.Errorf("tab section %d: %w", i+1, err)
+			}
+			text, err := c.tabText(tab)
+			if err != nil {
+				return err
+			}
+			s.Tab, s.Lines = tab, text
+			sections[i] = s
+			continue
+		}
+
+		lines := make([]ChordProLine, len(s.Lines))
+		for j, l := range s.Lines {
+			chords := make([]ChordProChord, len(l.Chords))
+			for k, ch := range l.Chords {
+				var err error
+				if ch.Name, err = rename(ch.Name, semitones); err != nil {
+					return err
+				}
+				chords[k] = ch
+			}
+			l.Chords = chords
+			lines[j] = l
+		}
+		s.Lines = lines
+		sections[i] = s
+	}
+
+	c.Key, c.Sections = key, sections
+	return nil
+}
+
+func (c *ChordProSheet) tabText(tab []Playable, opts ...TabOption) ([]ChordProLine, error) {
+	tb, err := NewTabWriter(c.Tuning.NoteNames(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := tb.WriteNotes(append([]Playable(nil), tab...)...); err != nil {
+		return nil, err
+	}
+
+	lines := []ChordProLine{}
+	for _, text := range strings.Split(strings.TrimRight(tb.Tab(), "\n"), "\n") {
+		lines = append(lines, ChordProLine{Text: text})
+	}
+	return lines, nil
+}
+
+// Song builds a song from the metadata of the sheet and a track playing
+// its tab sections one after another. Lyrics are not kept.
+func (c *ChordProSheet) Song() (*Song, error) {
+	s := NewSong(c.Title, c.Artist)
+	s.Key = c.Key
+	if c.Tempo > 0 {
+		s.SetTempo(0, c.Tempo)
+	}
+	if c.Time != (TimeSignature{}) {
+		s.SetTimeSignature(0, c.Time)
+	}
+
+	tr, err := NewTrack("", c.Tuning, 0)
+	if err != nil {
+		return nil, err
+	}
+	tr.Capo = c.Capo
+
+	// a section starts a gap after the previous one ends
+	offset := float32(0)
+	for _, section := range c.Sections {
+		if len(section.Tab) == 0 {
+			continue
+		}
+		end := offset
+		for _, p := range section.Tab {
+			if _, ok := p.(Movable); !ok {
+				return nil, fmt.Errorf("%T can not be moved in time", p)
+			}
+			end = max(end, offset+endTime(p))
+		}
+		tr.Add(shiftNotes(section.Tab, offset)...)
+		offset = end + chordProSectionGap
+	}
+	s.AddTrack(tr)
+	return s, s.Validate()
+}
+
+// WriteChordPro writes the sheet, metadata directives first.
+func (c *ChordProSheet) WriteChordPro(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	capo, tempo, time := "", "", ""
+	if c.Capo > 0 {
+		capo = strconv.Itoa(c.Capo)
+	}
+	if c.Tempo > 0 {
+		tempo = fmt.Sprint(c.Tempo)
+	}
+	if c.Time != (TimeSignature{}) {
+		time = c.Time.String()
+	}
+
+	header := false
+	directives := [][2]string{
+		{"title", c.Title}, {"subtitle", c.Subtitle}, {"artist", c.Artist}, {"key", c.Key},
+		{"capo", capo}, {"tempo", tempo}, {"time", time},
+	}
+	for _, d := range directives {
+		if d[1] != "" {
+			fmt.Fprintf(bw, "{%s: %s}\n", d[0], d[1])
+			header = true
+		}
+	}
+
+	for i, s := range c.Sections {
+		if header || i > 0 {
+			bw.WriteString("\n")
+		}
+		if s.Kind != "" {
+			if s.Label != "" {
+				fmt.Fprintf(bw, "{start_of_%s: %s}\n", s.Kind, s.Label)
+			} else {
+				fmt.Fprintf(bw, "{start_of_%s}\n", s.Kind)
+			}
+		}
+		for _, l := range s.Lines {
+			bw.WriteString(l.String() + "\n")
+		}
+		if s.Kind != "" {
+			fmt.Fprintf(bw, "{end_of_%s}\n", s.Kind)
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteChordPro writes the song as a ChordPro sheet with its metadata
+// and a tab section per track, chord names above the tab.
+func (s *Song) WriteChordPro(w io.Writer) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	sheet := &ChordProSheet{Title: s.Title, Artist: s.Artist, Key: s.Key, Tempo: s.Tempo(0)}
+	if len(s.TimeSignatures) > 0 {
+		sheet.Time = s.TimeSignature(0)
+	}
+	for i, t := range s.Tracks {
+		if i == 0 {
+			sheet.Capo = t.Capo
+		}
+		tb, err := s.TabWriter(i, WithChordNames())
+		if err != nil {
+			return err
+		}
+		section := ChordProSection{Kind: "tab", Label: t.Name, Tab: t.Events}
+		for _, text := range strings.Split(strings.TrimRight(tb.Tab(), "\n"), "\n") {
+			section.Lines = append(section.Lines, ChordProLine{Text: text})
+		}
+		sheet.Sections = append(sheet.Sections, section)
+	}
+	return sheet.WriteChordPro(w)
+}
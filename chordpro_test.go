@@ -0,0 +1,140 @@
+package guitar
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testChordPro = `{title: Morning}
+{artist: Anon}
+{key: Em}
+{capo: 2}
+{tempo: 90}
+{time: 3/4}
+
+{start_of_verse: Verse 1}
+[Em7]Today is [G]gonna be
+{comment: softly}
+{end_of_verse}
+
+{start_of_chorus}
+[C]And all the [D/F#]roads
+{end_of_chorus}
+
+{start_of_tab: Riff}
+e|-0---------|
+B|---3-------|
+G|-----0-----|
+D|-------2---|
+A|---------2-|
+E|-----------|
+{end_of_tab}
+`
+
+func TestReadChordPro(t *testing.T) {
+	sheet, err := ReadChordPro(strings.NewReader("# a sheet\n" + strings.Replace(testChordPro, "{comment:", "{c:", 1)))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Morning", sheet.Title)
+	assert.Equal(t, "Anon", sheet.Artist)
+	assert.Equal(t, "Em", sheet.Key)
+	assert.Equal(t, 2, sheet.Capo)
+	assert.Equal(t, float32(90), sheet.Tempo)
+	assert.Equal(t, TimeSignature{3, 4}, sheet.Time)
+
+	assert.Len(t, sheet.Sections, 3)
+	verse := sheet.Sections[0]
+	assert.Equal(t, "verse", verse.Kind)
+	assert.Equal(t, "Verse 1", verse.Label)
+	assert.Equal(t, []ChordProLine{
+		{Text: "Today is gonna be", Chords: []ChordProChord{{Name: "Em7", Offset: 0}, {Name: "G", Offset: 9}}},
+		{Text: "softly", Directive: "comment"},
+	}, verse.Lines)
+	assert.Equal(t, "chorus", sheet.Sections[1].Kind)
+
+	tab := sheet.Sections[2]
+	assert.Equal(t, "Riff", tab.Label)
+	assert.Len(t, tab.Tab, 5)
+	for i, fret := range []int{0, 3, 0, 2, 2} {
+		n := tab.Tab[i].(Note)
+		assert.Equal(t, fret, n.Fret)
+		assert.Equal(t, i, n.String)
+		assert.InDelta(t, 0.2+0.4*float64(i), n.Time, 1e-6)
+	}
+
+	out := bytes.Buffer{}
+	assert.NoError(t, sheet.WriteChordPro(&out))
+	assert.Equal(t, testChordPro, out.String())
+}
+
+func TestChordProTranspose(t *testing.T) {
+	sheet, err := ReadChordPro(strings.NewReader(testChordPro))
+	assert.NoError(t, err)
+	assert.NoError(t, sheet.Transpose(1))
+
+	assert.Equal(t, "Fm", sheet.Key)
+	assert.Equal(t, []ChordProChord{{Name: "Fm7", Offset: 0}, {Name: "Ab", Offset: 9}}, sheet.Sections[0].Lines[0].Chords)
+	assert.Equal(t, "[Db]And all the [Eb/G]roads", sheet.Sections[1].Lines[0].String())
+
+	tab := sheet.Sections[2]
+	assert.Equal(t, 1, tab.Tab[0].(Note).Fret)
+	assert.Equal(t, "e|-1---------", tab.Lines[0].Text)
+
+	// a fret would go below the nut, nothing is changed
+	assert.Error(t, sheet.Transpose(-2))
+	assert.Equal(t, "Fm", sheet.Key)
+}
+
+func TestChordProSong(t *testing.T) {
+	sheet, err := ReadChordPro(strings.NewReader(testChordPro + "\n{sot}\ne|-5-|\nB|---|\nG|---|\nD|---|\nA|---|\nE|---|\n{eot}\n"))
+	assert.NoError(t, err)
+
+	song, err := sheet.Song()
+	assert.NoError(t, err)
+	assert.Equal(t, "Morning", song.Title)
+	assert.Equal(t, float32(90), song.Tempo(0))
+	assert.Equal(t, TimeSignature{3, 4}, song.TimeSignature(0))
+	tr := song.Tracks[0]
+	assert.Equal(t, 2, tr.Capo)
+	assert.Len(t, tr.Events, 6)
+	// the second tab follows the first one
+	assert.InDelta(t, 2.2, tr.Events[5].StartTime(), 1e-6)
+}
+
+func TestSongWriteChordPro(t *testing.T) {
+	s := testSong(t)
+	s.Tracks[0].Add(NewChord("G", []int{3, 0, 0, 0, 2, 3}, 3))
+
+	out := bytes.Buffer{}
+	assert.NoError(t, s.WriteChordPro(&out))
+	text := out.String()
+	assert.True(t, strings.HasPrefix(text, "{title: Etude}\n{artist: Anon}\n{key: Am}\n{capo: 2}\n{tempo: 120}\n{time: 2/4}\n\n{start_of_tab: Guitar}\n"), text)
+
+	sheet, err := ReadChordPro(&out)
+	assert.NoError(t, err)
+	// the chord is read back as its notes
+	assert.Len(t, sheet.Sections[0].Tab, 9)
+}
+
+func TestReadChordProErrors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		sheet string
+	}{
+		{name: "unclosed chord", sheet: "[Am lyrics"},
+		{name: "capo", sheet: "{capo: two}"},
+		{name: "time", sheet: "{time: 3}"},
+		{name: "end without start", sheet: "{start_of_verse}\n{end_of_chorus}"},
+		{name: "tab", sheet: "{start_of_tab}\ne|-0-|\nB|---|\n{end_of_tab}"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ReadChordPro(strings.NewReader(tc.sheet))
+			assert.Error(t, err)
+		})
+	}
+}
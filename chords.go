@@ -1,24 +1,179 @@
 package guitar
 
 import (
-	"strconv"
+	"fmt"
 	"strings"
+	"unicode"
 )
 
-func ParseChord(chordTab string, time float32) []Playable {
-	notes := strings.Split(chordTab, " ")
-	chord := []Playable{}
+// ChordParseError points at the token of a voicing that could not be read.
+// Column is the 1-based rune position of the token in the voicing.
+type ChordParseError struct {
+	Token  string
+	Column int
+	Msg    string
+}
+
+func (e *ChordParseError) Error() string {
+	if e.Token == "" {
+		return fmt.Sprintf("column %d: %s", e.Column, e.Msg)
+	}
+	return fmt.Sprintf("column %d: %s %q", e.Column, e.Msg, e.Token)
+}
+
+// StringOrder tells which string a voicing starts with. String 0 is the
+// highest one, as in StandardTuning.
+type StringOrder int
+
+const (
+	// StringOrderAuto picks the order from the notation alone: a voicing
+	// with spaces, "0 1 0 2 3 -", starts at string 0 and a compact shape,
+	// "x32010", starts at the lowest string, the way chord charts write
+	// them. The last token of a compact shape is string 0, so a shape
+	// with fewer tokens than strings leaves the lowest strings out; give
+	// an explicit order with WithStringOrder when that is not meant.
+	StringOrderAuto StringOrder = iota
+	HighStringFirst
+	LowStringFirst
+)
+
+type ChordOption func(*chordParser)
+
+func WithStringOrder(order StringOrder) ChordOption {
+	return func(p *chordParser) {
+		p.order = order
+	}
+}
+
+type chordParser struct {
+	order StringOrder
+}
+
+// chordToken is the symbol of one string and where it starts.
+type chordToken struct {
+	text   string
+	column int
+}
+
+// ParseChord reads a voicing into a Playable per played string. Strings
+// are either separated by spaces, "0 2 2 1 0 -", or written as a compact
+// shape, "x02210", where frets above 9 and techniques go in parentheses,
+// "(10)(12)" or "x(5h7)". A token is a fret, "-" for a string not
+// played, "x" for a muted one, or any registered technique such as "7b9".
+// In "x02210" the low E is muted and written "x" in a tab; "-02210"
+// leaves it out.
+func ParseChord(chordTab string, time float32, opts ...ChordOption) ([]Playable, error) {
+	p := &chordParser{}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	var tokens []chordToken
+	var err error
+	compact := !strings.ContainsFunc(strings.TrimSpace(chordTab), unicode.IsSpace)
+	if compact {
+		tokens, err = compactTokens(chordTab)
+	} else {
+		tokens = spacedTokens(chordTab)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, &ChordParseError{Column: 1, Msg: "empty voicing"}
+	}
+
+	lowFirst := p.order == LowStringFirst || (p.order == StringOrderAuto && compact)
+
+	byString := make([][]Playable, len(tokens))
+	for i, token := range tokens {
+		stringNumber := i
+		if lowFirst {
+			stringNumber = len(tokens) - 1 - i
+		}
 
-	for i, note := range notes {
-		num, err := strconv.Atoi(note)
+		notes, err := parseChordToken(token, stringNumber, time)
 		if err != nil {
-			if note != "-" {
-				return []Playable{}
-			}
+			return nil, err
+		}
+		byString[stringNumber] = notes
+	}
+
+	// string 0 first whatever the writing order
+	chord := []Playable{}
+	for _, notes := range byString {
+		chord = append(chord, notes...)
+	}
+	return chord, nil
+}
+
+func spacedTokens(chordTab string) []chordToken {
+	tokens := []chordToken{}
+	runes := []rune(chordTab)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
 			continue
 		}
-		chord = append(chord, Note{Fret: num, String: i, Time: time})
+		start := i
+		for i < len(runes) && !unicode.IsSpace(runes[i]) {
+			i++
+		}
+		tokens = append(tokens, chordToken{text: string(runes[start:i]), column: start + 1})
+	}
+	return tokens
+}
+
+func compactTokens(chordTab string) ([]chordToken, error) {
+	tokens := []chordToken{}
+	offset := len([]rune(chordTab)) - len([]rune(strings.TrimLeftFunc(chordTab, unicode.IsSpace)))
+	runes := []rune(strings.TrimSpace(chordTab))
+
+	for i := 0; i < len(runes); {
+		start := i
+		if runes[i] == '(' {
+			end := i + 1
+			for end < len(runes) && runes[end] != ')' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, &ChordParseError{Token: string(runes[i:]), Column: offset + i + 1, Msg: "unclosed parenthesis"}
+			}
+			i = end + 1
+		} else {
+			i++
+		}
+		tokens = append(tokens, chordToken{text: string(runes[start:i]), column: offset + start + 1})
+	}
+	return tokens, nil
+}
+
+// parseChordToken reads the symbol of one string with the registered
+// techniques. Parentheses around the symbol are dropped.
+func parseChordToken(token chordToken, stringNumber int, time float32) ([]Playable, error) {
+	text := token.text
+	if len(text) > 2 && text[0] == '(' && text[len(text)-1] == ')' {
+		text = text[1 : len(text)-1]
+	}
+
+	switch text {
+	case "-":
+		return nil, nil
+	case "x", "X":
+		return []Playable{DeadNote{String: stringNumber, Time: time}}, nil
 	}
 
-	return chord
+	runes := []rune(text)
+	if c, n := longFret(runes); n > 0 {
+		return nil, &ChordParseError{Token: token.text, Column: token.column,
+			Msg: fmt.Sprintf("fret %q has more than two digits", string(runes[c:c+n]))}
+	}
+	n, notes, err := (&tabParser{frets: map[int]int{}}).parseToken(runes, stringNumber, false, func(int) float32 { return time })
+	if err != nil {
+		return nil, &ChordParseError{Token: token.text, Column: token.column, Msg: err.msg}
+	}
+	if n != len(runes) || len(notes) == 0 {
+		return nil, &ChordParseError{Token: token.text, Column: token.column, Msg: "unknown symbol"}
+	}
+	return notes, nil
 }
@@ -1,6 +1,7 @@
 package guitar
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -56,12 +57,14 @@ func TestParseChord(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result := ParseChord(tc.chordTab, 0)
+			result, err := ParseChord(tc.chordTab, 0)
 
 			if tc.expectError {
+				assert.Error(t, err)
 				assert.Empty(t, result, "Expected error but got notes")
 				return
 			}
+			assert.NoError(t, err)
 
 			assert.Equal(t, len(tc.expected), len(result), "Number of parsed notes mismatch")
 			for i, note := range tc.expected {
@@ -71,3 +74,123 @@ func TestParseChord(t *testing.T) {
 		})
 	}
 }
+
+func mustParseChord(chordTab string, time float32) []Playable {
+	chord, err := ParseChord(chordTab, time)
+	if err != nil {
+		panic(err)
+	}
+	return chord
+}
+
+func TestParseChordNotation(t *testing.T) {
+	testCases := []struct {
+		name     string
+		chordTab string
+		opts     []ChordOption
+		expected []Playable
+	}{
+		{
+			name:     "compact shape",
+			chordTab: "x02210",
+			expected: []Playable{
+				Note{Fret: 0, String: 0},
+				Note{Fret: 1, String: 1},
+				Note{Fret: 2, String: 2},
+				Note{Fret: 2, String: 3},
+				Note{Fret: 0, String: 4},
+				DeadNote{String: 5},
+			},
+		},
+		{
+			name:     "compact shape skipping the low string",
+			chordTab: "-02210",
+			expected: []Playable{
+				Note{Fret: 0, String: 0},
+				Note{Fret: 1, String: 1},
+				Note{Fret: 2, String: 2},
+				Note{Fret: 2, String: 3},
+				Note{Fret: 0, String: 4},
+			},
+		},
+		{
+			name:     "multi-digit frets",
+			chordTab: "(10)(12)(12)x--",
+			opts:     []ChordOption{WithStringOrder(HighStringFirst)},
+			expected: []Playable{
+				Note{Fret: 10, String: 0},
+				Note{Fret: 12, String: 1},
+				Note{Fret: 12, String: 2},
+				DeadNote{String: 3},
+			},
+		},
+		{
+			name:     "spaced low string first",
+			chordTab: "3 x 0 (10)",
+			opts:     []ChordOption{WithStringOrder(LowStringFirst)},
+			expected: []Playable{
+				Note{Fret: 10, String: 0},
+				Note{Fret: 0, String: 1},
+				DeadNote{String: 2},
+				Note{Fret: 3, String: 3},
+			},
+		},
+		{
+			name:     "technique suffixes",
+			chordTab: "5h7 7b9 <12> - 5~",
+			expected: []Playable{
+				HammerOn{FretFrom: 5, FretTo: 7, String: 0},
+				Bend{Fret: 7, Cents: FullStep, String: 1},
+				Harmonic{Fret: 12, String: 2},
+				Articulate(Note{Fret: 5, String: 4}, Vibrato),
+			},
+		},
+		{
+			name:     "compact techniques",
+			chordTab: "x(5h7)0",
+			expected: []Playable{
+				Note{Fret: 0, String: 0},
+				HammerOn{FretFrom: 5, FretTo: 7, String: 1},
+				DeadNote{String: 2},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := ParseChord(tc.chordTab, 0, tc.opts...)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestParseChordErrors(t *testing.T) {
+	testCases := []struct {
+		chordTab string
+		token    string
+		column   int
+	}{
+		{chordTab: "0 2 a 2", token: "a", column: 5},
+		{chordTab: "x0a220", token: "a", column: 3},
+		{chordTab: "  x0(10", token: "(10", column: 5},
+		{chordTab: "0 5h 2", token: "5h", column: 3},
+		{chordTab: "0 5q7 2", token: "5q7", column: 3},
+		{chordTab: "0 123 2", token: "123", column: 3},
+		{chordTab: "   ", column: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.chordTab, func(t *testing.T) {
+			_, err := ParseChord(tc.chordTab, 0)
+
+			parseErr := &ChordParseError{}
+			assert.True(t, errors.As(err, &parseErr))
+			assert.Equal(t, tc.token, parseErr.Token)
+			assert.Equal(t, tc.column, parseErr.Column)
+		})
+	}
+
+	_, err := ParseChord("0 2 a 2", 0)
+	assert.EqualError(t, err, `column 5: unknown symbol "a"`)
+}
@@ -0,0 +1,447 @@
+package guitar
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strings"
+)
+
+// defaultDiagramFrets is how many frets a chord box shows at least.
+const defaultDiagramFrets = 5
+
+// ChordDiagram is a chord box: the frets of a voicing, string 0 first,
+// and the finger holding each string, 0 for none and 1 to 4 from the
+// index finger, 5 for the thumb. A finger on several strings of the same
+// fret is a barre.
+type ChordDiagram struct {
+	Name    string
+	Frets   []int
+	Fingers []int
+}
+
+// DiagramTheme sets the colors, font and size of SVG chord diagrams.
+// Spacings are in pixels.
+type DiagramTheme struct {
+	Background    string
+	Line          string
+	Dot           string
+	DotText       string
+	Text          string
+	FontFamily    string
+	StringSpacing float64
+	FretSpacing   float64
+}
+
+var DefaultDiagramTheme = DiagramTheme{
+	Background:    "#ffffff",
+	Line:          "#000000",
+	Dot:           "#000000",
+	DotText:       "#ffffff",
+	Text:          "#000000",
+	FontFamily:    "sans-serif",
+	StringSpacing: 20,
+	FretSpacing:   24,
+}
+
+// escaped returns the theme with its colors and font quoted for SVG
+// attributes.
+func (th DiagramTheme) escaped() DiagramTheme {
+	for _, v := range []*string{&th.Background, &th.Line, &th.Dot, &th.DotText, &th.Text, &th.FontFamily} {
+		*v = html.EscapeString(*v)
+	}
+	return th
+}
+
+type DiagramOption func(*diagramStyle)
+
+type diagramStyle struct {
+	frets int
+	theme DiagramTheme
+}
+
+// WithDiagramFrets shows at least frets frets, 5 by default. A chord
+// spreading over more frets shows them all.
+func WithDiagramFrets(frets int) DiagramOption {
+	return func(s *diagramStyle) {
+		s.frets = frets
+	}
+}
+
+// WithDiagramTheme draws SVG diagrams with the colors, font and spacing
+// of theme.
+func WithDiagramTheme(theme DiagramTheme) DiagramOption {
+	return func(s *diagramStyle) {
+		s.theme = theme
+	}
+}
+
+// NewChordDiagram fingers a chord the way chord charts usually do. When
+// more than four strings are fretted and the lowest fret is also played
+// on the highest string, the index finger bars it; the other notes take
+// the next fingers, lower frets and lower strings first. Notes needing a
+// fifth finger get none.
+func NewChordDiagram(c Chord) (ChordDiagram, error) {
+	if err := c.Validate(); err != nil {
+		return ChordDiagram{}, err
+	}
+
+	d := ChordDiagram{Name: c.Name, Frets: append([]int(nil), c.Frets...), Fingers: make([]int, len(c.Frets))}
+	fretted := []int{}
+	lowest := 0
+	for i, f := range c.Frets {
+		if f > 0 {
+			fretted = append(fretted, i)
+			if lowest == 0 || f < lowest {
+				lowest = f
+			}
+		}
+	}
+	if len(fretted) == 0 {
+		return d, nil
+	}
+
+	finger := 1
+	if barre := d.barreSpan(lowest); barre > 0 && len(fretted) > 4 {
+		for i := 0; i <= barre; i++ {
+			if d.Frets[i] == lowest {
+				d.Fingers[i] = 1
+			}
+		}
+		finger = 2
+	}
+
+	sort.SliceStable(fretted, func(i, j int) bool {
+		if d.Frets[fretted[i]] != d.Frets[fretted[j]] {
+			return d.Frets[fretted[i]] < d.Frets[fretted[j]]
+		}
+		return fretted[i] > fretted[j]
+	})
+	for _, s := range fretted {
+		if d.Fingers[s] != 0 {
+			continue
+		}
+		if finger <= 4 {
+			d.Fingers[s] = finger
+		}
+		finger++
+	}
+	return d, nil
+}
+
+// barreSpan returns the lowest string a barre at fret reaches from the
+// highest played string, or 0 when the chord is not barred there.
+func (d ChordDiagram) barreSpan(fret int) int {
+	first := -1
+	for i, f := range d.Frets {
+		if f != ChordSkip && f != ChordMuted {
+			first = i
+			break
+		}
+	}
+	if first < 0 || d.Frets[first] != fret {
+		return 0
+	}
+
+	span := 0
+	for i := first + 1; i < len(d.Frets); i++ {
+		if d.Frets[i] < fret {
+			break
+		}
+		if d.Frets[i] == fret {
+			span = i
+		}
+	}
+	return span
+}
+
+// ChordFromVoicing gathers a voicing read by ParseChord into a Chord of
+// count strings. Strings left out are skipped, DeadNotes are muted.
+func ChordFromVoicing(name string, voicing []Playable, count int) (Chord, error) {
+	frets := make([]int, count)
+	for i := range frets {
+		frets[i] = ChordSkip
+	}
+
+	for _, p := range voicing {
+		s := p.StringNumber()
+		if s < 0 || s >= count {
+			return Chord{}, fmt.Errorf("string %d is out of %d strings", s, count)
+		}
+		switch n := Unwrap(p).(type) {
+		case Note:
+			frets[s] = n.Fret
+		case DeadNote:
+			frets[s] = ChordMuted
+		default:
+			return Chord{}, fmt.Errorf("string %d: %T can not be held in a chord", s, n)
+		}
+	}
+
+	var time float32
+	if len(voicing) > 0 {
+		time = voicing[0].StartTime()
+	}
+	c := NewChord(name, frets, time)
+	return c, c.Validate()
+}
+
+// diagramBarre is a finger laid over strings from low to high at fret.
+type diagramBarre struct {
+	finger, fret int
+	low, high    int
+}
+
+// barres returns the fingers holding more than one string at a fret.
+func (d ChordDiagram) barres() []diagramBarre {
+	barres := []diagramBarre{}
+	for i, f := range d.Frets {
+		if f <= 0 || i >= len(d.Fingers) || d.Fingers[i] == 0 {
+			continue
+		}
+		found := false
+		for j := range barres {
+			if barres[j].finger == d.Fingers[i] && barres[j].fret == f {
+				barres[j].low, found = i, true
+			}
+		}
+		if !found {
+			barres = append(barres, diagramBarre{finger: d.Fingers[i], fret: f, low: i, high: i})
+		}
+	}
+
+	result := []diagramBarre{}
+	for _, b := range barres {
+		if b.low != b.high {
+			result = append(result, b)
+		}
+	}
+	return result
+}
+
+// window returns the first fret shown and how many are shown: from the
+// nut when the chord fits, from its lowest fret otherwise.
+func (d ChordDiagram) window(opts []DiagramOption) (int, int, diagramStyle) {
+	style := diagramStyle{frets: defaultDiagramFrets, theme: DefaultDiagramTheme}
+	for _, opt := range opts {
+		opt(&style)
+	}
+
+	lowest, highest := 0, 0
+	for _, f := range d.Frets {
+		if f > 0 {
+			if lowest == 0 || f < lowest {
+				lowest = f
+			}
+			highest = max(highest, f)
+		}
+	}
+
+	start := 1
+	if highest > max(style.frets, 1) {
+		start = lowest
+	}
+	return start, max(style.frets, highest-start+1, 1), style
+}
+
+func (d ChordDiagram) finger(s int) int {
+	if s < len(d.Fingers) {
+		return d.Fingers[s]
+	}
+	return 0
+}
+
+func fingerText(finger int) string {
+	switch {
+	case finger == 5:
+		return "T"
+	case finger > 0:
+		return fmt.Sprint(finger)
+	}
+	return ""
+}
+
+// ASCII draws the diagram as text, lowest string on the left:
+//
+//	     C
+//	x     o   o
+//	===========
+//	| | | | 1 |
+//	| | 2 | | |
+//	| 3 | | | |
+//
+// Fretted strings show their finger, "*" when none is given, and a
+// barre joins its strings with "-". A diagram not starting at the nut
+// has a fret line on top and the first fret written on its left, "5fr".
+// A diagram without strings draws nothing.
+func (d ChordDiagram) ASCII(opts ...DiagramOption) string {
+	if len(d.Frets) == 0 {
+		return ""
+	}
+	start, frets, _ := d.window(opts)
+	n := len(d.Frets)
+	width := 2*n - 1
+
+	margin := ""
+	if start > 1 {
+		margin = strings.Repeat(" ", len(fmt.Sprintf("%dfr ", start)))
+	}
+
+	sb := strings.Builder{}
+	if d.Name != "" {
+		pad := max(0, (width-len([]rune(d.Name)))/2)
+		sb.WriteString(strings.TrimRight(margin+strings.Repeat(" ", pad)+d.Name, " ") + "\n")
+	}
+
+	markers := []string{}
+	for i := n - 1; i >= 0; i-- {
+		switch f := d.Frets[i]; {
+		case f == ChordSkip || f == ChordMuted:
+			markers = append(markers, "x")
+		case f == 0:
+			markers = append(markers, "o")
+		default:
+			markers = append(markers, " ")
+		}
+	}
+	sb.WriteString(strings.TrimRight(margin+strings.Join(markers, " "), " ") + "\n")
+
+	top := "-"
+	if start == 1 {
+		top = "="
+	}
+	sb.WriteString(margin + strings.Repeat(top, width) + "\n")
+
+	barres := d.barres()
+	for fret := start; fret < start+frets; fret++ {
+		row := []byte(strings.TrimSuffix(strings.Repeat("| ", n), " "))
+		for i := n - 1; i >= 0; i-- {
+			if d.Frets[i] != fret {
+				continue
+			}
+			col := 2 * (n - 1 - i)
+			text := fingerText(d.finger(i))
+			if text == "" {
+				text = "*"
+			}
+			row[col] = text[0]
+		}
+		for _, b := range barres {
+			if b.fret != fret {
+				continue
+			}
+			for col := 2 * (n - 1 - b.low); col < 2*(n-1-b.high); col++ {
+				if row[col] == ' ' || row[col] == '|' {
+					row[col] = '-'
+				}
+			}
+		}
+
+		label := margin
+		if fret == start && start > 1 {
+			label = fmt.Sprintf("%dfr ", start)
+		}
+		sb.WriteString(label + string(row) + "\n")
+	}
+	return sb.String()
+}
+
+// SVG draws the diagram as an SVG image with the default theme or the
+// one given by WithDiagramTheme. A diagram without strings draws
+// nothing.
+func (d ChordDiagram) SVG(opts ...DiagramOption) string {
+	if len(d.Frets) == 0 {
+		return ""
+	}
+	start, frets, style := d.window(opts)
+	th := style.theme.escaped()
+	n := len(d.Frets)
+	ss, fs := th.StringSpacing, th.FretSpacing
+	radius := ss * 0.4
+
+	// the grid starts below the name and the open and muted markers, and
+	// right of the starting fret label
+	left, top := ss*1.5, fs*1.2+ss
+	width := left + float64(n-1)*ss + ss
+	height := top + float64(frets)*fs + fs*0.5
+	x := func(s int) float64 { return left + float64(n-1-s)*ss }
+	y := func(fret int) float64 { return top + (float64(fret-start)+0.5)*fs }
+
+	sb := strings.Builder{}
+	// coordinates are rounded to hundredths of a pixel
+	write := func(format string, args ...any) {
+		for i, a := range args {
+			if v, ok := a.(float64); ok {
+				args[i] = math.Round(v*100) / 100
+			}
+		}
+		fmt.Fprintf(&sb, format+"\n", args...)
+	}
+	write(`<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" viewBox="0 0 %g %g" font-family="%s">`,
+		width, height, width, height, th.FontFamily)
+	if th.Background != "" {
+		write(`<rect width="%g" height="%g" fill="%s"/>`, width, height, th.Background)
+	}
+	if d.Name != "" {
+		write(`<text x="%g" y="%g" font-size="%g" text-anchor="middle" fill="%s">%s</text>`,
+			left+float64(n-1)*ss/2, fs*0.9, fs*0.8, th.Text, html.EscapeString(d.Name))
+	}
+
+	// open and muted strings above the nut
+	markerY := top - ss*0.6
+	for i, f := range d.Frets {
+		switch {
+		case f == ChordSkip || f == ChordMuted:
+			r := radius * 0.7
+			write(`<path d="M%g %gL%g %gM%g %gL%g %g" stroke="%s" stroke-width="1.5"/>`,
+				x(i)-r, markerY-r, x(i)+r, markerY+r, x(i)-r, markerY+r, x(i)+r, markerY-r, th.Line)
+		case f == 0:
+			write(`<circle cx="%g" cy="%g" r="%g" fill="none" stroke="%s" stroke-width="1.5"/>`,
+				x(i), markerY, radius*0.7, th.Line)
+		}
+	}
+
+	for f := 0; f <= frets; f++ {
+		fy := top + float64(f)*fs
+		strokeWidth := 1.0
+		if f == 0 && start == 1 {
+			strokeWidth = 4
+		}
+		write(`<line x1="%g" y1="%g" x2="%g" y2="%g" stroke="%s" stroke-width="%g"/>`,
+			x(n-1), fy, x(0), fy, th.Line, strokeWidth)
+	}
+	for i := 0; i < n; i++ {
+		write(`<line x1="%g" y1="%g" x2="%g" y2="%g" stroke="%s" stroke-width="1"/>`,
+			x(i), top, x(i), top+float64(frets)*fs, th.Line)
+	}
+	if start > 1 {
+		write(`<text x="%g" y="%g" font-size="%g" text-anchor="end" fill="%s">%dfr</text>`,
+			left-radius-2, y(start)+fs*0.2, fs*0.5, th.Text, start)
+	}
+
+	barred := map[int]bool{}
+	for _, b := range d.barres() {
+		write(`<rect x="%g" y="%g" width="%g" height="%g" rx="%g" fill="%s"/>`,
+			x(b.low)-radius, y(b.fret)-radius, x(b.high)-x(b.low)+2*radius, 2*radius, radius, th.Dot)
+		write(`<text x="%g" y="%g" font-size="%g" text-anchor="middle" fill="%s">%s</text>`,
+			(x(b.low)+x(b.high))/2, y(b.fret)+radius*0.5, radius*1.3, th.DotText, fingerText(b.finger))
+		for i := b.high; i <= b.low; i++ {
+			if d.Frets[i] == b.fret && d.finger(i) == b.finger {
+				barred[i] = true
+			}
+		}
+	}
+	for i, f := range d.Frets {
+		if f <= 0 || barred[i] {
+			continue
+		}
+		write(`<circle cx="%g" cy="%g" r="%g" fill="%s"/>`, x(i), y(f), radius, th.Dot)
+		if text := fingerText(d.finger(i)); text != "" {
+			write(`<text x="%g" y="%g" font-size="%g" text-anchor="middle" fill="%s">%s</text>`,
+				x(i), y(f)+radius*0.5, radius*1.3, th.DotText, text)
+		}
+	}
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
@@ -0,0 +1,124 @@
+package guitar
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testDiagram(t *testing.T, name, shape string) ChordDiagram {
+	t.Helper()
+
+	voicing, err := ParseChord(shape, 0)
+	assert.NoError(t, err)
+	c, err := ChordFromVoicing(name, voicing, 6)
+	assert.NoError(t, err)
+	d, err := NewChordDiagram(c)
+	assert.NoError(t, err)
+	return d
+}
+
+func TestNewChordDiagram(t *testing.T) {
+	testCases := []struct {
+		shape   string
+		fingers []int
+	}{
+		{shape: "x32010", fingers: []int{0, 1, 0, 2, 3, 0}},
+		{shape: "320003", fingers: []int{3, 0, 0, 0, 1, 2}},
+		{shape: "xx0232", fingers: []int{2, 3, 1, 0, 0, 0}},
+		{shape: "133211", fingers: []int{1, 1, 2, 4, 3, 1}},
+		{shape: "x24432", fingers: []int{1, 2, 4, 3, 1, 0}},
+		{shape: "000000", fingers: []int{0, 0, 0, 0, 0, 0}},
+		// more fingers than a hand has
+		{shape: "123456", fingers: []int{0, 0, 4, 3, 2, 1}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.shape, func(t *testing.T) {
+			d := testDiagram(t, "", tc.shape)
+			assert.Equal(t, tc.fingers, d.Fingers)
+		})
+	}
+
+	_, err := NewChordDiagram(Chord{Frets: []int{ChordSkip, ChordSkip}})
+	assert.Error(t, err)
+}
+
+func TestChordFromVoicing(t *testing.T) {
+	voicing, _ := ParseChord("x 0 2 2 1 -", 1.5, WithStringOrder(LowStringFirst))
+	c, err := ChordFromVoicing("Am", voicing, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, NewChord("Am", []int{ChordSkip, 1, 2, 2, 0, ChordMuted}, 1.5), c)
+
+	_, err = ChordFromVoicing("", voicing, 4)
+	assert.Error(t, err)
+
+	_, err = ChordFromVoicing("", []Playable{Bend{Fret: 7, Cents: FullStep, String: 2}}, 6)
+	assert.Error(t, err)
+
+	_, err = ChordFromVoicing("", nil, 6)
+	assert.Error(t, err)
+}
+
+func TestChordDiagramASCII(t *testing.T) {
+	assert.Equal(t, "     C\n"+
+		"x     o   o\n"+
+		"===========\n"+
+		"| | | | 1 |\n"+
+		"| | 2 | | |\n"+
+		"| 3 | | | |\n"+
+		"| | | | | |\n"+
+		"| | | | | |\n", testDiagram(t, "C", "x32010").ASCII())
+
+	assert.Equal(t, "     F\n"+
+		"\n"+
+		"===========\n"+
+		"1-------1-1\n"+
+		"| | | 2 | |\n"+
+		"| 3 4 | | |\n", testDiagram(t, "F", "133211").ASCII(WithDiagramFrets(3)))
+
+	// a chord up the neck starts at its lowest fret, without fingers the
+	// notes are dots
+	d := testDiagram(t, "A", "x(12)(14)(14)(14)x")
+	d.Fingers = nil
+	assert.Equal(t, "          A\n"+
+		"     x         x\n"+
+		"     -----------\n"+
+		"12fr | * | | | |\n"+
+		"     | | | | | |\n"+
+		"     | | * * * |\n"+
+		"     | | | | | |\n"+
+		"     | | | | | |\n", d.ASCII())
+
+	assert.Empty(t, ChordDiagram{}.ASCII())
+	assert.Empty(t, ChordDiagram{Name: "C"}.SVG())
+}
+
+func TestChordDiagramSVG(t *testing.T) {
+	svg := testDiagram(t, "Bm", "x24432").SVG()
+
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="150" height="180.8"`))
+	assert.True(t, strings.HasSuffix(svg, "</svg>\n"))
+	assert.Contains(t, svg, `<text x="80" y="21.6" font-size="19.2" text-anchor="middle" fill="#000000">Bm</text>`)
+	// the muted low string, the nut and the barre over five strings
+	assert.Contains(t, svg, `<path d="M24.4 31.2L35.6 42.4M24.4 42.4L35.6 31.2" stroke="#000000" stroke-width="1.5"/>`)
+	assert.Contains(t, svg, `<line x1="30" y1="48.8" x2="130" y2="48.8" stroke="#000000" stroke-width="4"/>`)
+	assert.Contains(t, svg, `<rect x="42" y="76.8" width="96" height="16" rx="8" fill="#000000"/>`)
+	assert.Contains(t, svg, `<circle cx="90" cy="132.8" r="8" fill="#000000"/>`)
+	assert.Contains(t, svg, `<text x="90" y="136.8" font-size="10.4" text-anchor="middle" fill="#ffffff">4</text>`)
+	assert.Equal(t, 3, strings.Count(svg, "<circle"))
+
+	theme := DefaultDiagramTheme
+	theme.Background, theme.Dot, theme.FontFamily = "", "#c0392b", `"Fira Sans"`
+	theme.Line = `red" onload="alert(1)`
+	svg = testDiagram(t, "E", "(7)(9)(9)(9)(7)x").SVG(WithDiagramTheme(theme))
+	assert.NotContains(t, svg, "<rect width")
+	assert.Contains(t, svg, `font-family="&#34;Fira Sans&#34;"`)
+	assert.Contains(t, svg, `fill="#c0392b"`)
+	assert.Contains(t, svg, `stroke="red&#34; onload=&#34;alert(1)"`)
+	assert.Contains(t, svg, `text-anchor="end" fill="#000000">7fr</text>`)
+	// open strings are rings above the nut
+	assert.Equal(t, 0, strings.Count(svg, `fill="none"`))
+	assert.Contains(t, testDiagram(t, "Em", "022000").SVG(), `<circle cx="130" cy="36.8" r="5.6" fill="none" stroke="#000000" stroke-width="1.5"/>`)
+}
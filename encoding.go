@@ -0,0 +1,434 @@
+package guitar
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaVersion is the version written with serialized tunings and
+// fingerboards. Decoding rejects data written by a newer schema.
+const SchemaVersion = 1
+
+// playableTypeKey is the discriminator field of a serialized Playable.
+const playableTypeKey = "type"
+
+var playableRegistry = struct {
+	sync.RWMutex
+	types map[string]reflect.Type
+	names map[reflect.Type]string
+}{
+	types: map[string]reflect.Type{},
+	names: map[reflect.Type]string{},
+}
+
+func init() {
+	mustRegisterPlayable("note", Note{})
+	mustRegisterPlayable("slide", Slide{})
+	mustRegisterPlayable("hammer_on", HammerOn{})
+	mustRegisterPlayable("pull_off", PullOff{})
+	mustRegisterPlayable("harmonic", Harmonic{})
+	mustRegisterPlayable("dead_note", DeadNote{})
+	mustRegisterPlayable("bend", Bend{})
+	mustRegisterPlayable("pre_bend", PreBend{})
+	mustRegisterPlayable("bend_release", BendRelease{})
+	mustRegisterPlayable("compound_bend", CompoundBend{})
+	mustRegisterPlayable("articulated", Articulated{})
+	mustRegisterPlayable("tap", Tap{})
+	mustRegisterPlayable("pinch_harmonic", PinchHarmonic{})
+	mustRegisterPlayable("artificial_harmonic", ArtificialHarmonic{})
+	mustRegisterPlayable("tremolo", Tremolo{})
+	mustRegisterPlayable("trill", Trill{})
+	mustRegisterPlayable("legato", Legato{})
+	mustRegisterPlayable("chord", Chord{})
+}
+
+// RegisterPlayable makes a user-defined Playable type serializable under
+// name. The type is decoded into a value of the same type as prototype,
+// so register a pointer to decode into pointers.
+func RegisterPlayable(name string, prototype Playable) error {
+	if name == "" {
+		return errors.New("playable type name can not be empty")
+	}
+	if prototype == nil {
+		return errors.New("playable prototype can not be nil")
+	}
+
+	typ := reflect.TypeOf(prototype)
+
+	playableRegistry.Lock()
+	defer playableRegistry.Unlock()
+
+	if registered, ok := playableRegistry.types[name]; ok && registered != typ {
+		return fmt.Errorf("playable type %q is already registered for %v", name, registered)
+	}
+	if registered, ok := playableRegistry.names[typ]; ok && registered != name {
+		return fmt.Errorf("%v is already registered as %q", typ, registered)
+	}
+
+	playableRegistry.types[name] = typ
+	playableRegistry.names[typ] = name
+	return nil
+}
+
+func mustRegisterPlayable(name string, prototype Playable) {
+	if err := RegisterPlayable(name, prototype); err != nil {
+		panic(err)
+	}
+}
+
+func playableName(p Playable) (string, error) {
+	playableRegistry.RLock()
+	defer playableRegistry.RUnlock()
+
+	name, ok := playableRegistry.names[reflect.TypeOf(p)]
+	if !ok {
+		return "", fmt.Errorf("playable type %T is not registered", p)
+	}
+	return name, nil
+}
+
+func newPlayable(name string) (reflect.Value, error) {
+	playableRegistry.RLock()
+	typ, ok := playableRegistry.types[name]
+	playableRegistry.RUnlock()
+
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("unknown playable type %q", name)
+	}
+	if typ.Kind() == reflect.Pointer {
+		return reflect.New(typ.Elem()), nil
+	}
+	return reflect.New(typ), nil
+}
+
+func playableFrom(ptr reflect.Value, name string) Playable {
+	playableRegistry.RLock()
+	typ := playableRegistry.types[name]
+	playableRegistry.RUnlock()
+
+	if typ.Kind() == reflect.Pointer {
+		return ptr.Interface().(Playable)
+	}
+	return ptr.Elem().Interface().(Playable)
+}
+
+// Playables is a list of Playables that can be encoded to and decoded
+// from JSON and YAML. Every element is written as an object with a "type"
+// field naming its registered type.
+type Playables []Playable
+
+func MarshalPlayable(p Playable) ([]byte, error) {
+	if p == nil {
+		return nil, errors.New("can not marshal nil playable")
+	}
+
+	name, err := playableName(p)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 2 || data[0] != '{' {
+		return nil, fmt.Errorf("playable type %T is not encoded as a JSON object", p)
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteString(`{"` + playableTypeKey + `":`)
+	typeName, _ := json.Marshal(name)
+	buf.Write(typeName)
+	if len(data) > 2 {
+		buf.WriteByte(',')
+	}
+	buf.Write(data[1:])
+
+	return buf.Bytes(), nil
+}
+
+func UnmarshalPlayable(data []byte) (Playable, error) {
+	header := struct {
+		Type string `json:"type"`
+	}{}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+	if header.Type == "" {
+		return nil, errors.New("playable without type")
+	}
+
+	ptr, err := newPlayable(header.Type)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, ptr.Interface()); err != nil {
+		return nil, fmt.Errorf("playable %q: %w", header.Type, err)
+	}
+
+	return playableFrom(ptr, header.Type), nil
+}
+
+func MarshalPlayables(ps []Playable) ([]byte, error) {
+	return json.Marshal(Playables(ps))
+}
+
+func UnmarshalPlayables(data []byte) ([]Playable, error) {
+	ps := Playables{}
+	if err := json.Unmarshal(data, &ps); err != nil {
+		return nil, err
+	}
+	return ps, nil
+}
+
+func MarshalPlayablesYAML(ps []Playable) ([]byte, error) {
+	return yaml.Marshal(Playables(ps))
+}
+
+func UnmarshalPlayablesYAML(data []byte) ([]Playable, error) {
+	ps := Playables{}
+	if err := yaml.Unmarshal(data, &ps); err != nil {
+		return nil, err
+	}
+	return ps, nil
+}
+
+func (ps Playables) MarshalJSON() ([]byte, error) {
+	items := make([]json.RawMessage, len(ps))
+	for i, p := range ps {
+		data, err := MarshalPlayable(p)
+		if err != nil {
+			return nil, fmt.Errorf("playable %d: %w", i, err)
+		}
+		items[i] = data
+	}
+	return json.Marshal(items)
+}
+
+func (ps *Playables) UnmarshalJSON(data []byte) error {
+	items := []json.RawMessage{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+
+	result := make(Playables, len(items))
+	for i, item := range items {
+		p, err := UnmarshalPlayable(item)
+		if err != nil {
+			return fmt.Errorf("playable %d: %w", i, err)
+		}
+		result[i] = p
+	}
+
+	*ps = result
+	return nil
+}
+
+func (ps Playables) MarshalYAML() (any, error) {
+	nodes := make([]*yaml.Node, len(ps))
+	for i, p := range ps {
+		node, err := playableYAMLNode(p)
+		if err != nil {
+			return nil, fmt.Errorf("playable %d: %w", i, err)
+		}
+		nodes[i] = node
+	}
+	return nodes, nil
+}
+
+func (ps *Playables) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.SequenceNode {
+		return fmt.Errorf("line %d: playables must be a sequence", value.Line)
+	}
+
+	result := make(Playables, len(value.Content))
+	for i, node := range value.Content {
+		p, err := playableFromYAML(node)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		result[i] = p
+	}
+
+	*ps = result
+	return nil
+}
+
+func playableYAMLNode(p Playable) (*yaml.Node, error) {
+	if p == nil {
+		return nil, errors.New("can not marshal nil playable")
+	}
+
+	name, err := playableName(p)
+	if err != nil {
+		return nil, err
+	}
+
+	node := &yaml.Node{}
+	if err := node.Encode(p); err != nil {
+		return nil, err
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("playable type %T is not encoded as a YAML mapping", p)
+	}
+
+	node.Content = append([]*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: playableTypeKey},
+		{Kind: yaml.ScalarNode, Value: name},
+	}, node.Content...)
+
+	return node, nil
+}
+
+func playableFromYAML(node *yaml.Node) (Playable, error) {
+	header := struct {
+		Type string `yaml:"type"`
+	}{}
+	if err := node.Decode(&header); err != nil {
+		return nil, err
+	}
+	if header.Type == "" {
+		return nil, errors.New("playable without type")
+	}
+
+	ptr, err := newPlayable(header.Type)
+	if err != nil {
+		return nil, err
+	}
+	if err := node.Decode(ptr.Interface()); err != nil {
+		return nil, fmt.Errorf("playable %q: %w", header.Type, err)
+	}
+
+	return playableFrom(ptr, header.Type), nil
+}
+
+// tuningSchema is the serialized form of a Tuning.
+type tuningSchema struct {
+	Version int      `json:"version" yaml:"version"`
+	Strings []string `json:"strings" yaml:"strings"`
+}
+
+// fingerBoardSchema is the serialized form of a FingerBoard.
+type fingerBoardSchema struct {
+	Version int      `json:"version" yaml:"version"`
+	Strings []string `json:"strings" yaml:"strings"`
+	Frets   int      `json:"frets" yaml:"frets"`
+}
+
+// String returns the tuning in the ParseTuning format, e.g. "E4 B3 G3 D3 A2 E2".
+func (t Tuning) String() string {
+	return strings.Join(t.strings(), " ")
+}
+
+func (t Tuning) strings() []string {
+	names := make([]string, len(t))
+	for i, n := range t {
+		names[i] = fmt.Sprintf("%s%d", n.Name, n.Octave)
+	}
+	return names
+}
+
+func checkSchemaVersion(version int) error {
+	if version < 1 || version > SchemaVersion {
+		return fmt.Errorf("unsupported schema version %d", version)
+	}
+	return nil
+}
+
+func tuningFromSchema(version int, names []string) (Tuning, error) {
+	if err := checkSchemaVersion(version); err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, errors.New("empty tuning")
+	}
+	return ParseTuning(strings.Join(names, " "))
+}
+
+func (t Tuning) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tuningSchema{Version: SchemaVersion, Strings: t.strings()})
+}
+
+func (t *Tuning) UnmarshalJSON(data []byte) error {
+	schema := tuningSchema{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return err
+	}
+
+	tuning, err := tuningFromSchema(schema.Version, schema.Strings)
+	if err != nil {
+		return err
+	}
+	*t = tuning
+	return nil
+}
+
+func (t Tuning) MarshalYAML() (any, error) {
+	return tuningSchema{Version: SchemaVersion, Strings: t.strings()}, nil
+}
+
+func (t *Tuning) UnmarshalYAML(value *yaml.Node) error {
+	schema := tuningSchema{}
+	if err := value.Decode(&schema); err != nil {
+		return err
+	}
+
+	tuning, err := tuningFromSchema(schema.Version, schema.Strings)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", value.Line, err)
+	}
+	*t = tuning
+	return nil
+}
+
+func (fb *FingerBoard) schema() fingerBoardSchema {
+	return fingerBoardSchema{Version: SchemaVersion, Strings: fb.tuning.strings(), Frets: fb.frets}
+}
+
+func (fb *FingerBoard) fromSchema(schema fingerBoardSchema) error {
+	tuning, err := tuningFromSchema(schema.Version, schema.Strings)
+	if err != nil {
+		return err
+	}
+
+	board, err := NewFingerBoard(tuning, schema.Frets)
+	if err != nil {
+		return err
+	}
+	*fb = *board
+	return nil
+}
+
+func (fb *FingerBoard) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fb.schema())
+}
+
+func (fb *FingerBoard) UnmarshalJSON(data []byte) error {
+	schema := fingerBoardSchema{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return err
+	}
+	return fb.fromSchema(schema)
+}
+
+func (fb *FingerBoard) MarshalYAML() (any, error) {
+	return fb.schema(), nil
+}
+
+func (fb *FingerBoard) UnmarshalYAML(value *yaml.Node) error {
+	schema := fingerBoardSchema{}
+	if err := value.Decode(&schema); err != nil {
+		return err
+	}
+	if err := fb.fromSchema(schema); err != nil {
+		return fmt.Errorf("line %d: %w", value.Line, err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package guitar
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+type testBend struct {
+	Fret   int     `json:"fret" yaml:"fret"`
+	Steps  float32 `json:"steps" yaml:"steps"`
+	String int     `json:"string" yaml:"string"`
+	Time   float32 `json:"time" yaml:"time"`
+}
+
+func (b testBend) TabSymbol() string  { return fmt.Sprintf("%db", b.Fret) }
+func (b testBend) StringNumber() int  { return b.String }
+func (b testBend) StartTime() float32 { return b.Time }
+
+var testPlayables = []Playable{
+	Note{Name: "E", Octave: 2, Fret: 0, String: 5, Time: 0, Duration: 0.5},
+	Slide{FretStart: 5, FretEnd: 7, String: 2, Time: 0.5},
+	HammerOn{FretFrom: 2, FretTo: 4, String: 1, Time: 1},
+	PullOff{FretFrom: 5, FretTo: 3, String: 1, Time: 1.5},
+	Harmonic{Fret: 12, String: 0, Time: 2},
+}
+
+func TestPlayablesJSON(t *testing.T) {
+	data, err := MarshalPlayables(testPlayables)
+	assert.NoError(t, err)
+
+	ps, err := UnmarshalPlayables(data)
+	assert.NoError(t, err)
+	assert.Equal(t, testPlayables, ps)
+
+	data, err = MarshalPlayable(Slide{FretStart: 5, FretEnd: 7, String: 2})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"slide","fret_start":5,"fret_end":7,"string":2}`, string(data))
+}
+
+func TestPlayablesYAML(t *testing.T) {
+	data, err := MarshalPlayablesYAML(testPlayables)
+	assert.NoError(t, err)
+
+	ps, err := UnmarshalPlayablesYAML(data)
+	assert.NoError(t, err)
+	assert.Equal(t, testPlayables, ps)
+
+	_, err = UnmarshalPlayablesYAML([]byte("- type: wobble\n  fret: 1\n"))
+	assert.ErrorContains(t, err, "unknown playable type")
+}
+
+func TestPlayableErrors(t *testing.T) {
+	_, err := UnmarshalPlayable([]byte(`{"fret":1}`))
+	assert.Error(t, err)
+
+	_, err = UnmarshalPlayable([]byte(`{"type":"wobble"}`))
+	assert.Error(t, err)
+
+	_, err = MarshalPlayable(testBend{})
+	assert.Error(t, err)
+}
+
+func TestRegisterPlayable(t *testing.T) {
+	assert.NoError(t, RegisterPlayable("test_bend", testBend{}))
+	assert.Error(t, RegisterPlayable("test_bend", Note{}))
+	assert.Error(t, RegisterPlayable("", testBend{}))
+
+	bend := testBend{Fret: 7, Steps: 1, String: 2, Time: 1}
+	data, err := MarshalPlayables([]Playable{bend})
+	assert.NoError(t, err)
+
+	ps, err := UnmarshalPlayables(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []Playable{bend}, ps)
+}
+
+func TestTuningSerialization(t *testing.T) {
+	tuning, _ := ParseTuning(DropD)
+
+	data, err := json.Marshal(tuning)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"version":1,"strings":["E4","B3","G3","D3","A2","D2"]}`, string(data))
+
+	decoded := Tuning{}
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, tuning, decoded)
+
+	yamlData, err := yaml.Marshal(tuning)
+	assert.NoError(t, err)
+	decoded = Tuning{}
+	assert.NoError(t, yaml.Unmarshal(yamlData, &decoded))
+	assert.Equal(t, tuning, decoded)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"version":2,"strings":["E4"]}`), &decoded))
+	assert.Error(t, json.Unmarshal([]byte(`{"version":1,"strings":[]}`), &decoded))
+}
+
+func TestFingerBoardSerialization(t *testing.T) {
+	tuning, _ := ParseTuning(StandardTuning)
+	fb, _ := NewFingerBoard(tuning, 22)
+
+	data, err := json.Marshal(fb)
+	assert.NoError(t, err)
+	decoded := &FingerBoard{}
+	assert.NoError(t, json.Unmarshal(data, decoded))
+	assert.Equal(t, fb, decoded)
+
+	yamlData, err := yaml.Marshal(fb)
+	assert.NoError(t, err)
+	decoded = &FingerBoard{}
+	assert.NoError(t, yaml.Unmarshal(yamlData, decoded))
+	assert.Equal(t, fb, decoded)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"version":1,"strings":["E4"],"frets":-1}`), decoded))
+}
+
+func TestSongSerialization(t *testing.T) {
+	s := testSong(t)
+
+	data, err := json.Marshal(s)
+	assert.NoError(t, err)
+	decoded := &Song{}
+	assert.NoError(t, json.Unmarshal(data, decoded))
+	assert.Equal(t, s, decoded)
+
+	yamlData, err := yaml.Marshal(s)
+	assert.NoError(t, err)
+	decoded = &Song{}
+	assert.NoError(t, yaml.Unmarshal(yamlData, decoded))
+	assert.Equal(t, s, decoded)
+}
@@ -2,8 +2,14 @@ package guitar
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 )
 
+// longNeckFrets is the number of frets of a board whose instrument does
+// not tell its frets.
+const longNeckFrets = 36
+
 type FingerBoard struct {
 	tuning Tuning
 	frets  int
@@ -44,3 +50,82 @@ func (fb *FingerBoard) FindNotes(targetNote string, targetOctave int) Notes {
 
 	return notes
 }
+
+// fingerKey is a MIDI key to place on the board, in seconds.
+type fingerKey struct {
+	start, length float32
+	pitch         int
+}
+
+// finger places every key on a string. Keys starting together are a
+// chord and get a string each, highest pitch first; every key is played
+// at the position closest to the one before.
+func (fb *FingerBoard) finger(keys []fingerKey) ([]Playable, error) {
+	keys = append([]fingerKey(nil), keys...)
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i].start != keys[j].start {
+			return keys[i].start < keys[j].start
+		}
+		return keys[i].pitch > keys[j].pitch
+	})
+
+	notes := []Playable{}
+	previous := Note{Fret: 0, String: len(fb.tuning) / 2}
+
+	for i := 0; i < len(keys); {
+		j := i
+		for j < len(keys) && keys[j].start == keys[i].start {
+			j++
+		}
+
+		used := map[int]bool{}
+		for _, k := range keys[i:j] {
+			candidates := Notes{}
+			for _, n := range fb.positions(k.pitch) {
+				if !used[n.String] {
+					candidates = append(candidates, n)
+				}
+			}
+
+			if len(candidates) == 0 {
+				return nil, fmt.Errorf("no free string for MIDI note %d at %v", k.pitch, k.start)
+			}
+			note, err := candidates.ClosestTo(previous)
+			if err != nil {
+				return nil, err
+			}
+
+			note.Time, note.Duration = k.start, k.length
+			used[note.String] = true
+			notes = append(notes, note)
+			previous = note
+		}
+		i = j
+	}
+	return notes, nil
+}
+
+// positions returns every fret sounding a MIDI key.
+func (fb *FingerBoard) positions(pitch int) Notes {
+	notes := Notes{}
+	for s := range fb.tuning {
+		open, err := fb.tuning.NoteAt(s, 0)
+		if err != nil {
+			continue
+		}
+		openPitch, err := midiPitch(open)
+		if err != nil {
+			continue
+		}
+		fret := pitch - openPitch
+		if fret < 0 || fret > fb.frets {
+			continue
+		}
+		note, err := fb.tuning.NoteAt(s, fret)
+		if err != nil {
+			continue
+		}
+		notes = append(notes, note)
+	}
+	return notes
+}
@@ -0,0 +1,644 @@
+package guitar
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	gpifVersion  = "7"
+	gpifRevision = "13007"
+
+	// gpifBendScale turns cents into the bend values of a .gp file, 100
+	// being a whole tone.
+	gpifBendScale = 2
+
+	gpifSlideLegato    = 2
+	gpifSlideFromBelow = 16
+
+	gpifAccentStaccato = 1
+	gpifAccentNormal   = 8
+
+	// gpDefaultFrets is the neck of tracks without a fret count.
+	gpDefaultFrets = 24
+)
+
+var gpifNoteValues = map[string]string{
+	"whole": "Whole", "half": "Half", "quarter": "Quarter", "eighth": "Eighth",
+	"16th": "16th", "32nd": "32nd", "64th": "64th",
+}
+
+// gpifTremolos are the tremolo speeds by the number of beams.
+var gpifTremolos = []string{1: "1/2", 2: "1/4", 3: "1/8"}
+
+type gpifText struct {
+	Text string `xml:",cdata"`
+}
+
+type gpifEmpty struct{}
+
+type gpifDocument struct {
+	XMLName     xml.Name         `xml:"GPIF"`
+	Version     string           `xml:"version,attr"`
+	GPVersion   string           `xml:"GPVersion"`
+	GPRevision  gpifRevisionInfo `xml:"GPRevision"`
+	Encoding    string           `xml:"Encoding>EncodingDescription"`
+	Score       gpifScore        `xml:"Score"`
+	MasterTrack gpifMasterTrack  `xml:"MasterTrack"`
+	Tracks      []gpifTrack      `xml:"Tracks>Track"`
+	MasterBars  []gpifMasterBar  `xml:"MasterBars>MasterBar"`
+	Bars        []gpifBar        `xml:"Bars>Bar"`
+	Voices      []gpifVoice      `xml:"Voices>Voice"`
+	Beats       []gpifBeat       `xml:"Beats>Beat"`
+	Notes       []gpifNote       `xml:"Notes>Note"`
+	Rhythms     []gpifRhythm     `xml:"Rhythms>Rhythm"`
+}
+
+type gpifRevisionInfo struct {
+	Required    string `xml:"required,attr"`
+	Recommended string `xml:"recommended,attr"`
+	Revision    string `xml:",chardata"`
+}
+
+type gpifScore struct {
+	Title      gpifText `xml:"Title"`
+	SubTitle   gpifText `xml:"SubTitle"`
+	Artist     gpifText `xml:"Artist"`
+	Album      gpifText `xml:"Album"`
+	Words      gpifText `xml:"Words"`
+	Music      gpifText `xml:"Music"`
+	Copyright  gpifText `xml:"Copyright"`
+	Tabber     gpifText `xml:"Tabber"`
+	MultiVoice string   `xml:"MultiVoice"`
+}
+
+type gpifMasterTrack struct {
+	Tracks      string           `xml:"Tracks"`
+	Automations []gpifAutomation `xml:"Automations>Automation"`
+}
+
+type gpifAutomation struct {
+	Type     string `xml:"Type"`
+	Linear   bool   `xml:"Linear"`
+	Bar      int    `xml:"Bar"`
+	Position string `xml:"Position"`
+	Visible  bool   `xml:"Visible"`
+	Value    string `xml:"Value"`
+}
+
+type gpifTrack struct {
+	ID              int               `xml:"id,attr"`
+	Name            gpifText          `xml:"Name"`
+	ShortName       gpifText          `xml:"ShortName"`
+	Color           string            `xml:"Color"`
+	InstrumentSet   gpifInstrumentSet `xml:"InstrumentSet"`
+	Transpose       gpifTranspose     `xml:"Transpose"`
+	Sounds          []gpifSound       `xml:"Sounds>Sound"`
+	MidiConnection  gpifMidiChannels  `xml:"MidiConnection"`
+	PlaybackState   string            `xml:"PlaybackState"`
+	AudioEngine     string            `xml:"AudioEngineState"`
+	Automations     []gpifAutomation  `xml:"Automations>Automation"`
+	StaffProperties []gpifProperty    `xml:"Staves>Staff>Properties>Property"`
+}
+
+type gpifInstrumentSet struct {
+	Name      string `xml:"Name"`
+	Type      string `xml:"Type"`
+	LineCount int    `xml:"LineCount"`
+}
+
+type gpifTranspose struct {
+	Chromatic int `xml:"Chromatic"`
+	Octave    int `xml:"Octave"`
+}
+
+type gpifSound struct {
+	Name    gpifText `xml:"Name"`
+	Label   gpifText `xml:"Label"`
+	Path    string   `xml:"Path"`
+	Role    string   `xml:"Role"`
+	Program int      `xml:"MIDI>Program"`
+}
+
+type gpifMidiChannels struct {
+	Port             int `xml:"Port"`
+	PrimaryChannel   int `xml:"PrimaryChannel"`
+	SecondaryChannel int `xml:"SecondaryChannel"`
+}
+
+// gpifProperty is a named property holding one of its values.
+type gpifProperty struct {
+	Name      string     `xml:"name,attr"`
+	Enable    *gpifEmpty `xml:"Enable"`
+	String    string     `xml:"String,omitempty"`
+	Fret      string     `xml:"Fret,omitempty"`
+	Number    string     `xml:"Number,omitempty"`
+	Flags     string     `xml:"Flags,omitempty"`
+	Float     string     `xml:"Float,omitempty"`
+	HType     string     `xml:"HType,omitempty"`
+	HFret     string     `xml:"HFret,omitempty"`
+	Direction string     `xml:"Direction,omitempty"`
+	Bitset    string     `xml:"Bitset,omitempty"`
+	Pitches   string     `xml:"Pitches,omitempty"`
+	Label     *gpifText  `xml:"Label"`
+}
+
+type gpifMasterBar struct {
+	Key  gpifKey `xml:"Key"`
+	Time string  `xml:"Time"`
+	Bars string  `xml:"Bars"`
+}
+
+type gpifKey struct {
+	AccidentalCount int    `xml:"AccidentalCount"`
+	Mode            string `xml:"Mode"`
+}
+
+type gpifBar struct {
+	ID     int    `xml:"id,attr"`
+	Clef   string `xml:"Clef"`
+	Voices string `xml:"Voices"`
+}
+
+type gpifVoice struct {
+	ID    int    `xml:"id,attr"`
+	Beats string `xml:"Beats"`
+}
+
+type gpifBeat struct {
+	ID         int            `xml:"id,attr"`
+	Dynamic    string         `xml:"Dynamic"`
+	Rhythm     gpifRef        `xml:"Rhythm"`
+	Tremolo    string         `xml:"Tremolo,omitempty"`
+	Notes      string         `xml:"Notes,omitempty"`
+	Properties []gpifProperty `xml:"Properties>Property,omitempty"`
+}
+
+type gpifRef struct {
+	Ref int `xml:"ref,attr"`
+}
+
+type gpifNote struct {
+	ID         int            `xml:"id,attr"`
+	Tie        *gpifTie       `xml:"Tie"`
+	Vibrato    string         `xml:"Vibrato,omitempty"`
+	LetRing    *gpifEmpty     `xml:"LetRing"`
+	AntiAccent string         `xml:"AntiAccent,omitempty"`
+	Accent     int            `xml:"Accent,omitempty"`
+	Trill      int            `xml:"Trill,omitempty"`
+	Properties []gpifProperty `xml:"Properties>Property"`
+}
+
+type gpifTie struct {
+	Origin      bool `xml:"origin,attr"`
+	Destination bool `xml:"destination,attr"`
+}
+
+type gpifRhythm struct {
+	ID        int           `xml:"id,attr"`
+	NoteValue string        `xml:"NoteValue"`
+	Dot       *gpifDotCount `xml:"AugmentationDot"`
+}
+
+type gpifDotCount struct {
+	Count int `xml:"count,attr"`
+}
+
+func gpifEnabled(name string) gpifProperty {
+	return gpifProperty{Name: name, Enable: &gpifEmpty{}}
+}
+
+// gpWriter numbers the bars, voices, beats, notes and rhythms of a
+// document as they are added.
+type gpWriter struct {
+	doc     gpifDocument
+	rhythms map[noteValue]int
+}
+
+// WriteGP7 writes the song in the .gp format of Guitar Pro 7 and later,
+// a zip archive holding the score as XML. Every track has a standard
+// notation and a TAB staff with the tuning, capo and frets of the track.
+func (s *Song) WriteGP7(w io.Writer) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	timing := s.Timing
+	timing.Sort()
+
+	key := gpifKey{Mode: "Major"}
+	if s.Key != "" {
+		fifths, minor, err := s.KeySignature()
+		if err != nil {
+			return err
+		}
+		key.AccidentalCount = fifths
+		if minor {
+			key.Mode = "Minor"
+		}
+	}
+
+	end := 0
+	parts := [][]scoreNote{}
+	boards := []*FingerBoard{}
+	for _, t := range s.Tracks {
+		fb, err := t.FingerBoard()
+		if err != nil {
+			return err
+		}
+		mw := musicXMLWriter{timing: timing, fb: fb}
+		notes, err := mw.scoreNotes(t.Events)
+		if err != nil {
+			return fmt.Errorf("track %q: %w", t.Name, err)
+		}
+		for _, n := range notes {
+			end = max(end, n.start+n.length)
+		}
+		parts = append(parts, notes)
+		boards = append(boards, fb)
+	}
+	measures := scoreMeasures(timing, end)
+
+	gw := gpWriter{rhythms: map[noteValue]int{}}
+	gw.doc = gpifDocument{
+		Version:    gpifVersion,
+		GPVersion:  gpifVersion,
+		GPRevision: gpifRevisionInfo{Required: "12021", Recommended: "13000", Revision: gpifRevision},
+		Encoding:   "GP7",
+		Score: gpifScore{
+			Title:      gpifText{s.Title},
+			Artist:     gpifText{s.Artist},
+			MultiVoice: "0",
+		},
+	}
+
+	ids := []string{}
+	for i, t := range s.Tracks {
+		ids = append(ids, fmt.Sprint(i))
+		gw.doc.Tracks = append(gw.doc.Tracks, gpifTrackOf(t, i))
+	}
+	gw.doc.MasterTrack = gpifMasterTrack{Tracks: strings.Join(ids, " "), Automations: tempoAutomations(timing, measures)}
+
+	for _, m := range measures {
+		bars := []string{}
+		for i := range s.Tracks {
+			id, err := gw.bar(parts[i], m, boards[i])
+			if err != nil {
+				return fmt.Errorf("track %q: %w", s.Tracks[i].Name, err)
+			}
+			bars = append(bars, fmt.Sprint(id))
+		}
+		gw.doc.MasterBars = append(gw.doc.MasterBars, gpifMasterBar{
+			Key:  key,
+			Time: fmt.Sprintf("%d/%d", m.signature.Beats, m.signature.BeatUnit),
+			Bars: strings.Join(bars, " "),
+		})
+	}
+
+	score := bytes.Buffer{}
+	score.WriteString(xml.Header)
+	enc := xml.NewEncoder(&score)
+	enc.Indent("", "  ")
+	if err := enc.Encode(gw.doc); err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"VERSION", []byte("7.0")},
+		{"Content/score.gpif", score.Bytes()},
+		{"Content/PartConfiguration", partConfiguration(len(s.Tracks))},
+	}
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(f.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func gpifTrackOf(t *Track, index int) gpifTrack {
+	name := t.Name
+	if name == "" {
+		name = fmt.Sprintf("Guitar %d", index+1)
+	}
+
+	// pitches go from the lowest string up
+	pitches := make([]string, len(t.Tuning))
+	for i, n := range t.Tuning {
+		pitch, _ := midiPitch(n)
+		pitches[len(t.Tuning)-1-i] = fmt.Sprint(pitch)
+	}
+	frets := t.Frets
+	if frets <= 0 {
+		frets = gpDefaultFrets
+	}
+
+	sound := gpifSound{
+		Name: gpifText{"Steel Guitar"}, Label: gpifText{"Steel Guitar"},
+		Path: "Midi/SteelGuitar", Role: "User", Program: DefaultMIDIProgram,
+	}
+	return gpifTrack{
+		ID:            index,
+		Name:          gpifText{name},
+		ShortName:     gpifText{"gtr."},
+		Color:         "255 0 0",
+		InstrumentSet: gpifInstrumentSet{Name: "Steel Guitar", Type: "steelGuitar", LineCount: 5},
+		// guitar sounds an octave below the written notes
+		Transpose:      gpifTranspose{Octave: -1},
+		Sounds:         []gpifSound{sound},
+		MidiConnection: gpifMidiChannels{PrimaryChannel: 2 * index % 16, SecondaryChannel: (2*index + 1) % 16},
+		PlaybackState:  "Default",
+		AudioEngine:    "MIDI",
+		Automations: []gpifAutomation{{
+			Type: "Sound", Position: "0", Visible: true,
+			Value: fmt.Sprintf("%s;%s;%s", sound.Path, sound.Name.Text, sound.Role),
+		}},
+		StaffProperties: []gpifProperty{
+			{Name: "CapoFret", Fret: fmt.Sprint(t.Capo)},
+			{Name: "FretCount", Number: fmt.Sprint(frets)},
+			{Name: "PartialCapoFret", Fret: "0"},
+			{Name: "PartialCapoStringFlags", Bitset: strings.Repeat("0", len(t.Tuning))},
+			{Name: "Tuning", Pitches: strings.Join(pitches, " "), Label: &gpifText{}},
+		},
+	}
+}
+
+// tempoAutomations places the tempo changes in the measures, at a ratio
+// of the measure length.
+func tempoAutomations(timing Timing, measures []scoreMeasure) []gpifAutomation {
+	tempos := timing.Tempos
+	if len(tempos) == 0 {
+		tempos = []TempoChange{{BPM: DefaultTempo}}
+	}
+
+	automations := []gpifAutomation{}
+	for _, c := range tempos {
+		pos := divisionsAt(timing, c.Time)
+		for i, m := range measures {
+			if pos >= m.end && i < len(measures)-1 {
+				continue
+			}
+			ratio := float64(min(pos, m.end)-m.start) / float64(m.end-m.start)
+			automations = append(automations, gpifAutomation{
+				Type: "Tempo", Bar: i, Position: fmt.Sprint(ratio), Visible: true,
+				// the tempo counts quarter notes
+				Value: fmt.Sprintf("%v 2", c.BPM),
+			})
+			break
+		}
+	}
+	return automations
+}
+
+// partConfiguration shows notation and TAB for all tracks together and
+// for every track alone.
+func partConfiguration(tracks int) []byte {
+	const notationAndTab = 1 | 2
+
+	buf := bytes.Buffer{}
+	_ = binary.Write(&buf, binary.BigEndian, int32(tracks+1))
+	buf.WriteByte(0)
+	_ = binary.Write(&buf, binary.BigEndian, int32(tracks))
+	for range tracks {
+		buf.WriteByte(notationAndTab)
+	}
+	for range tracks {
+		buf.WriteByte(0)
+		_ = binary.Write(&buf, binary.BigEndian, int32(1))
+		buf.WriteByte(notationAndTab)
+	}
+	return buf.Bytes()
+}
+
+func (gw *gpWriter) bar(notes []scoreNote, m scoreMeasure, fb *FingerBoard) (int, error) {
+	beats := layoutVoice(notes, m)
+	if len(beats) == 0 {
+		beats = restBeats(m.end - m.start)
+	}
+
+	ids := []string{}
+	for _, b := range beats {
+		id, err := gw.beat(b, fb)
+		if err != nil {
+			return 0, err
+		}
+		ids = append(ids, fmt.Sprint(id))
+	}
+
+	voice := len(gw.doc.Voices)
+	gw.doc.Voices = append(gw.doc.Voices, gpifVoice{ID: voice, Beats: strings.Join(ids, " ")})
+	id := len(gw.doc.Bars)
+	gw.doc.Bars = append(gw.doc.Bars, gpifBar{ID: id, Clef: "G2", Voices: fmt.Sprintf("%d -1 -1 -1", voice)})
+	return id, nil
+}
+
+func (gw *gpWriter) rhythm(value noteValue) int {
+	if id, ok := gw.rhythms[value]; ok {
+		return id
+	}
+	id := len(gw.doc.Rhythms)
+	r := gpifRhythm{ID: id, NoteValue: gpifNoteValues[value.name]}
+	if value.dots > 0 {
+		r.Dot = &gpifDotCount{Count: value.dots}
+	}
+	gw.doc.Rhythms = append(gw.doc.Rhythms, r)
+	gw.rhythms[value] = id
+	return id
+}
+
+func (gw *gpWriter) beat(b scoreBeat, fb *FingerBoard) (int, error) {
+	beat := gpifBeat{ID: len(gw.doc.Beats), Dynamic: "MF", Rhythm: gpifRef{gw.rhythm(b.value)}}
+
+	notes := []string{}
+	for _, p := range b.pieces {
+		note, err := gw.note(p, b, fb)
+		if err != nil {
+			return 0, err
+		}
+		notes = append(notes, fmt.Sprint(note))
+
+		if !b.firstValue || !p.firstPiece {
+			continue
+		}
+		if p.note.tremolo > 0 {
+			beat.Tremolo = gpifTremolos[min(p.note.tremolo, len(gpifTremolos)-1)]
+		}
+		// an arpeggio up the staff is a down stroke
+		switch p.note.arpeggiate {
+		case "up":
+			beat.Properties = append(beat.Properties, gpifProperty{Name: "Brush", Direction: "Down"})
+		case "down":
+			beat.Properties = append(beat.Properties, gpifProperty{Name: "Brush", Direction: "Up"})
+		}
+	}
+	beat.Notes = strings.Join(notes, " ")
+
+	gw.doc.Beats = append(gw.doc.Beats, beat)
+	return beat.ID, nil
+}
+
+// note writes one value of a piece. Like in MusicXML, marks go on the
+// first value of a note and the link to the next note on its last.
+func (gw *gpWriter) note(p scorePiece, b scoreBeat, fb *FingerBoard) (int, error) {
+	n := p.note
+	pitch, err := fb.tuning.NoteAt(n.stringNumber, n.fret)
+	if err != nil {
+		return 0, err
+	}
+	midi, err := midiPitch(pitch)
+	if err != nil {
+		return 0, err
+	}
+
+	note := gpifNote{ID: len(gw.doc.Notes)}
+	origin, destination := !b.lastValue || p.tieStart, !b.firstValue || p.tieStop
+	if origin || destination {
+		note.Tie = &gpifTie{Origin: origin, Destination: destination}
+	}
+	switch {
+	case n.articulations.Has(WideVibrato):
+		note.Vibrato = "Wide"
+	case n.articulations.Has(Vibrato):
+		note.Vibrato = "Slight"
+	}
+	if n.articulations.Has(LetRing) {
+		note.LetRing = &gpifEmpty{}
+	}
+
+	properties := []gpifProperty{
+		{Name: "String", String: fmt.Sprint(len(fb.tuning) - 1 - n.stringNumber)},
+		{Name: "Fret", Fret: fmt.Sprint(n.fret)},
+		{Name: "Midi", Number: fmt.Sprint(midi)},
+	}
+	if n.articulations.Has(PalmMute) {
+		properties = append(properties, gpifEnabled("PalmMuted"))
+	}
+	if n.dead || n.articulations.Has(Muted) {
+		properties = append(properties, gpifEnabled("Muted"))
+	}
+
+	head := b.firstValue && p.firstPiece
+	tail := b.lastValue && p.lastOne
+	slide := 0
+	if head {
+		if n.articulations.Has(Ghost) {
+			note.AntiAccent = "Normal"
+		}
+		if n.articulations.Has(Accent) {
+			note.Accent |= gpifAccentNormal
+		}
+		if n.articulations.Has(Staccato) {
+			note.Accent |= gpifAccentStaccato
+		}
+		if n.trill {
+			trill, err := fb.tuning.NoteAt(n.stringNumber, n.trillFret)
+			if err != nil {
+				return 0, err
+			}
+			if note.Trill, err = midiPitch(trill); err != nil {
+				return 0, err
+			}
+		}
+
+		if n.tap {
+			properties = append(properties, gpifEnabled("Tapped"))
+		}
+		if n.scoop {
+			slide |= gpifSlideFromBelow
+		}
+		if n.linkFrom == linkHammerOn || n.linkFrom == linkPullOff {
+			properties = append(properties, gpifEnabled("HopoDestination"))
+		}
+		if n.harmonic != "" {
+			kind := "Natural"
+			switch {
+			case n.pinch:
+				kind = "Pinch"
+			case n.harmonic == "artificial":
+				kind = "Artificial"
+			}
+			properties = append(properties,
+				gpifProperty{Name: "HarmonicType", HType: kind},
+				gpifProperty{Name: "HarmonicFret", HFret: fmt.Sprint(n.harmonicFret)})
+		}
+		properties = append(properties, bendProperties(n.curve)...)
+	}
+	if tail {
+		switch n.linkTo {
+		case linkHammerOn, linkPullOff:
+			properties = append(properties, gpifEnabled("HopoOrigin"))
+		case linkSlide:
+			slide |= gpifSlideLegato
+		}
+	}
+	if slide != 0 {
+		properties = append(properties, gpifProperty{Name: "Slide", Flags: fmt.Sprint(slide)})
+	}
+	note.Properties = properties
+
+	gw.doc.Notes = append(gw.doc.Notes, note)
+	return note.ID, nil
+}
+
+// bendProperties writes a curve as the bend of a .gp file: an origin, a
+// destination and the highest level in between, offsets counting from 0
+// to 100 over the note.
+func bendProperties(curve BendCurve) []gpifProperty {
+	if len(curve) == 0 {
+		return nil
+	}
+
+	value := func(name string, cents int) gpifProperty {
+		return gpifProperty{Name: name, Float: fmt.Sprint(cents / gpifBendScale)}
+	}
+	offset := func(name string, position float32) gpifProperty {
+		return gpifProperty{Name: name, Float: fmt.Sprint(position * 100)}
+	}
+
+	first, last := curve[0], curve[len(curve)-1]
+	// the destination is reached where the curve stops moving
+	reached := len(curve) - 1
+	for reached > 0 && curve[reached-1].Cents == last.Cents {
+		reached--
+	}
+
+	properties := []gpifProperty{
+		gpifEnabled("Bended"),
+		value("BendOriginValue", first.Cents),
+		offset("BendOriginOffset", first.Position),
+	}
+
+	middle := -1
+	for i := 1; i < reached; i++ {
+		if middle < 0 || abs32(float32(curve[i].Cents)) > abs32(float32(curve[middle].Cents)) {
+			middle = i
+		}
+	}
+	if middle > 0 {
+		from, to := middle, middle
+		for to+1 < reached && curve[to+1].Cents == curve[middle].Cents {
+			to++
+		}
+		properties = append(properties,
+			value("BendMiddleValue", curve[middle].Cents),
+			offset("BendMiddleOffset1", curve[from].Position),
+			offset("BendMiddleOffset2", curve[to].Position))
+	}
+
+	return append(properties,
+		value("BendDestinationValue", last.Cents),
+		offset("BendDestinationOffset", curve[reached].Position))
+}
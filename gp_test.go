@@ -0,0 +1,152 @@
+package guitar
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestGP7(t *testing.T, s *Song) gpifDocument {
+	t.Helper()
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, s.WriteGP7(&buf))
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		assert.NoError(t, err)
+		files[f.Name], err = io.ReadAll(r)
+		assert.NoError(t, err)
+		r.Close()
+	}
+	assert.Equal(t, "7.0", string(files["VERSION"]))
+	assert.Equal(t, partConfiguration(len(s.Tracks)), files["Content/PartConfiguration"])
+
+	doc := gpifDocument{}
+	assert.NoError(t, xml.Unmarshal(files["Content/score.gpif"], &doc))
+	return doc
+}
+
+func gpifProperties(props []gpifProperty) map[string]gpifProperty {
+	m := map[string]gpifProperty{}
+	for _, p := range props {
+		m[p.Name] = p
+	}
+	return m
+}
+
+func TestWriteGP7(t *testing.T) {
+	doc := writeTestGP7(t, testSong(t))
+
+	assert.Equal(t, "7", doc.Version)
+	assert.Equal(t, "Etude", doc.Score.Title.Text)
+	assert.Equal(t, "Anon", doc.Score.Artist.Text)
+	assert.Equal(t, "0", doc.MasterTrack.Tracks)
+	assert.Equal(t, []gpifAutomation{
+		{Type: "Tempo", Bar: 0, Position: "0", Visible: true, Value: "120 2"},
+		{Type: "Tempo", Bar: 2, Position: "0", Visible: true, Value: "60 2"},
+	}, doc.MasterTrack.Automations)
+
+	assert.Len(t, doc.Tracks, 1)
+	tr := doc.Tracks[0]
+	assert.Equal(t, "Guitar", tr.Name.Text)
+	staff := gpifProperties(tr.StaffProperties)
+	assert.Equal(t, "2", staff["CapoFret"].Fret)
+	assert.Equal(t, "22", staff["FretCount"].Number)
+	assert.Equal(t, "40 45 50 55 59 64", staff["Tuning"].Pitches)
+
+	assert.Len(t, doc.MasterBars, 3)
+	for i, mb := range doc.MasterBars {
+		assert.Equal(t, gpifKey{AccidentalCount: 0, Mode: "Minor"}, mb.Key)
+		assert.Equal(t, "2/4", mb.Time)
+		assert.Equal(t, fmt.Sprint(i), mb.Bars)
+	}
+
+	// the first note rings over the bar line
+	assert.Equal(t, []gpifRhythm{
+		{ID: 0, NoteValue: "Half"},
+		{ID: 1, NoteValue: "Eighth"},
+		{ID: 2, NoteValue: "Quarter"},
+	}, doc.Rhythms[:3])
+	assert.Equal(t, &gpifTie{Origin: true}, doc.Notes[0].Tie)
+	assert.Equal(t, &gpifTie{Destination: true}, doc.Notes[1].Tie)
+	first := gpifProperties(doc.Notes[0].Properties)
+	assert.Equal(t, "5", first["String"].String)
+	assert.Equal(t, "3", first["Fret"].Fret)
+	assert.Equal(t, "69", first["Midi"].Number)
+}
+
+func TestWriteGP7Techniques(t *testing.T) {
+	tun, _ := ParseTuning(StandardTuning)
+	s := NewSong("", "")
+	tr, _ := NewTrack("", tun, 0)
+	tr.Add(
+		HammerOn{FretFrom: 5, FretTo: 7, String: 0, Time: 0, Duration: 0.5},
+		Slide{FretStart: -1, FretEnd: 5, String: 1, Time: 0.5, Duration: 0.25},
+		PinchHarmonic{Fret: 5, String: 2, Time: 0.75, Duration: 0.25},
+		BendRelease{Fret: 7, Cents: FullStep, String: 2, Time: 1, Duration: 0.5},
+		Articulate(DeadNote{String: 4, Time: 1.5, Duration: 0.25}, PalmMute),
+		Articulate(Note{Fret: 3, String: 1, Time: 1.75, Duration: 0.25}, Accent|Staccato|WideVibrato),
+		Chord{Frets: []int{0, 1, 0, 2, 3, ChordSkip}, Strum: StrumDown, Time: 2, Duration: 0.5},
+		Trill{Fret: 5, TrillFret: 7, String: 3, Time: 2.5, Duration: 0.5},
+		Tremolo{Fret: 2, Division: 32, String: 3, Time: 3, Duration: 0.5},
+	)
+	s.AddTrack(tr)
+
+	doc := writeTestGP7(t, s)
+	assert.Equal(t, "24", gpifProperties(doc.Tracks[0].StaffProperties)["FretCount"].Number)
+
+	notes := []map[string]gpifProperty{}
+	for _, n := range doc.Notes {
+		notes = append(notes, gpifProperties(n.Properties))
+	}
+
+	assert.NotNil(t, notes[0]["HopoOrigin"].Enable)
+	assert.NotNil(t, notes[1]["HopoDestination"].Enable)
+	assert.Equal(t, "7", notes[1]["Fret"].Fret)
+	assert.Equal(t, "16", notes[2]["Slide"].Flags)
+	assert.Equal(t, "Pinch", notes[3]["HarmonicType"].HType)
+	assert.Equal(t, "19", notes[3]["HarmonicFret"].HFret)
+
+	bend := notes[4]
+	assert.NotNil(t, bend["Bended"].Enable)
+	assert.Equal(t, "0", bend["BendOriginValue"].Float)
+	assert.Equal(t, "100", bend["BendMiddleValue"].Float)
+	assert.Equal(t, "0", bend["BendDestinationValue"].Float)
+	assert.Equal(t, "100", bend["BendDestinationOffset"].Float)
+
+	assert.NotNil(t, notes[5]["Muted"].Enable)
+	assert.NotNil(t, notes[5]["PalmMuted"].Enable)
+	assert.Equal(t, gpifAccentNormal|gpifAccentStaccato, doc.Notes[6].Accent)
+	assert.Equal(t, "Wide", doc.Notes[6].Vibrato)
+
+	strum := doc.Beats[7]
+	assert.Equal(t, "7 8 9 10 11", strum.Notes)
+	assert.Equal(t, []gpifProperty{{Name: "Brush", Direction: "Down"}}, strum.Properties)
+
+	// the trill goes to the A of the seventh fret on the D string
+	assert.Equal(t, 57, doc.Notes[12].Trill)
+	assert.Equal(t, "1/8", doc.Beats[9].Tremolo)
+}
+
+func TestWriteGP7Errors(t *testing.T) {
+	tun, _ := ParseTuning(StandardTuning)
+
+	s := NewSong("", "")
+	s.Key = "H"
+	assert.Error(t, s.WriteGP7(&bytes.Buffer{}))
+
+	s = NewSong("", "")
+	tr, _ := NewTrack("", tun, 24)
+	tr.Add(Note{Fret: 0, String: 7})
+	s.AddTrack(tr)
+	assert.Error(t, s.WriteGP7(&bytes.Buffer{}))
+}
@@ -0,0 +1,977 @@
+package guitar
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// gpVersions are the versions of the Guitar Pro files read, by the
+// string opening the file.
+var gpVersions = map[string]int{
+	"FICHIER GUITAR PRO v3.00": 300,
+	"FICHIER GUITAR PRO v4.00": 400,
+	"FICHIER GUITAR PRO v4.06": 406,
+	"FICHIER GUITAR PRO L4.06": 406,
+	"FICHIER GUITAR PRO v5.00": 500,
+	"FICHIER GUITAR PRO v5.10": 510,
+}
+
+// Guitar Pro bend points run from position 0 to 60, and 25 is a quarter
+// tone.
+const (
+	gpBendPositions   = 60
+	gpBendQuarterTone = 25
+)
+
+// Note types of a Guitar Pro note.
+const (
+	gpNormalNote = 1
+	gpTiedNote   = 2
+	gpDeadNote   = 3
+)
+
+// Guitar Pro 5 slides, Guitar Pro 3 and 4 ones are converted to them.
+const (
+	gpShiftSlide     = 0x01
+	gpLegatoSlide    = 0x02
+	gpSlideOutDown   = 0x04
+	gpSlideOutUp     = 0x08
+	gpSlideInBelow   = 0x10
+	gpSlideInAbove   = 0x20
+	gpSlideTo        = gpShiftSlide | gpLegatoSlide
+	gpUnsupportedOut = gpSlideOutDown | gpSlideOutUp | gpSlideInAbove
+)
+
+// Harmonics of a Guitar Pro note.
+const (
+	gpNaturalHarmonic = iota + 1
+	gpArtificialHarmonic
+	gpTappedHarmonic
+	gpPinchHarmonic
+	gpSemiHarmonic
+)
+
+// gpTuplets are the notes a tuplet of n takes the time of.
+var gpTuplets = map[int]int{3: 2, 5: 4, 6: 4, 7: 4, 9: 8, 10: 8, 11: 8, 12: 8, 13: 8}
+
+// GPWarning is an effect of a Guitar Pro file left out by ReadGuitarPro.
+// Measure counts from 1 and is 0 for the whole track.
+type GPWarning struct {
+	Track   int
+	Measure int
+	Effect  string
+}
+
+func (w GPWarning) String() string {
+	if w.Measure == 0 {
+		return fmt.Sprintf("track %d: %s not supported", w.Track+1, w.Effect)
+	}
+	return fmt.Sprintf("track %d, measure %d: %s not supported", w.Track+1, w.Measure, w.Effect)
+}
+
+var errGPTruncated = errors.New("truncated Guitar Pro file")
+
+type gpReader struct {
+	quarterTiming
+
+	data    []byte
+	pos     int
+	err     error
+	version int
+
+	warnings []GPWarning
+	warned   map[GPWarning]bool
+}
+
+type gpMeasure struct {
+	start float64
+}
+
+type gpTrack struct {
+	name       string
+	tuning     Tuning
+	frets      int
+	capo       int
+	percussion bool
+	beats      []gpBeat
+}
+
+// gpBeat is a beat of a track in quarter notes from the start.
+type gpBeat struct {
+	measure       int
+	start, length float64
+	notes         []gpNote
+	effects       gpBeatEffects
+	tempo         int
+}
+
+type gpBeatEffects struct {
+	vibrato, wideVibrato bool
+	tap, slapPop         bool
+	tremoloBar           bool
+	harmonic             int
+	stroke               StrumDirection
+	strokeSpeed          int
+}
+
+type gpNote struct {
+	string        int
+	fret          int
+	kind          int
+	ghost, accent bool
+	effects       gpNoteEffects
+}
+
+type gpNoteEffects struct {
+	bend                   BendCurve
+	hammer, letRing        bool
+	staccato, palmMute     bool
+	vibrato                bool
+	grace                  bool
+	slide                  int
+	harmonic, harmonicFret int
+	tremolo                int
+	trillFret              int
+}
+
+// ReadGuitarPro reads a Guitar Pro 3, 4 or 5 file into a Song with a
+// track per guitar track. Effects the library has no Playable for, such
+// as grace notes or the tremolo bar, are left out and reported.
+func ReadGuitarPro(r io.Reader) (*Song, []GPWarning, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	gr := &gpReader{data: data, warned: map[GPWarning]bool{}}
+	song, err := gr.read()
+	if err != nil {
+		return nil, nil, err
+	}
+	return song, gr.warnings, nil
+}
+
+func (gr *gpReader) read() (*Song, error) {
+	version := gr.byteString(30)
+	if gr.err != nil {
+		return nil, errors.New("not a Guitar Pro file")
+	}
+	v, ok := gpVersions[version]
+	if !ok {
+		return nil, fmt.Errorf("unsupported Guitar Pro version %q", version)
+	}
+	gr.version = v
+
+	song := &Song{}
+	gr.readInfo(song)
+	if gr.version < 500 {
+		gr.skip(1) // triplet feel
+	}
+	if gr.version >= 400 {
+		gr.skip(4) // lyrics track
+		for range 5 {
+			gr.skip(4)
+			gr.intString()
+		}
+	}
+	if gr.version >= 510 {
+		gr.skip(19) // master effect
+	}
+	if gr.version >= 500 {
+		gr.skip(30) // page size, margins, score size and header flags
+		for range 10 {
+			gr.intByteString()
+		}
+		gr.intByteString() // tempo name
+	}
+	tempo := gr.int32()
+	if gr.version >= 510 {
+		gr.skip(1) // hide tempo
+	}
+	fifths := gr.int8()
+	gr.skip(3)
+	if gr.version >= 400 {
+		gr.skip(1) // octave
+	}
+	gr.skip(64 * 12) // MIDI channels
+	if gr.version >= 500 {
+		gr.skip(19*2 + 4) // directions and master reverb
+	}
+	if gr.err != nil {
+		return nil, gr.err
+	}
+	if tempo > 0 {
+		gr.addTempo(0, float64(tempo))
+	}
+	song.Key = keyName(fifths, false)
+
+	measureCount, trackCount := gr.count(), gr.count()
+	measures, err := gr.readMeasureHeaders(measureCount, song)
+	if err != nil {
+		return nil, err
+	}
+	tracks, err := gr.readTracks(trackCount)
+	if err != nil {
+		return nil, err
+	}
+	for m, measure := range measures {
+		for i := range tracks {
+			if err := gr.readMeasure(&tracks[i], m, measure); err != nil {
+				return nil, fmt.Errorf("track %d, measure %d: %w", i+1, m+1, err)
+			}
+		}
+	}
+	song.Timing = gr.timing()
+
+	for i, t := range tracks {
+		if t.percussion {
+			gr.warn(i, 0, "percussion track")
+			continue
+		}
+		track, err := NewTrack(t.name, t.tuning, t.frets)
+		if err != nil {
+			return nil, fmt.Errorf("track %d: %w", i+1, err)
+		}
+		track.Capo = t.capo
+		track.Add(gr.events(i, t)...)
+		song.AddTrack(track)
+	}
+	return song, nil
+}
+
+func (gr *gpReader) readInfo(song *Song) {
+	song.Title = gr.intByteString()
+	gr.intByteString() // subtitle
+	song.Artist = gr.intByteString()
+	gr.intByteString() // album
+	gr.intByteString() // words
+	if gr.version >= 500 {
+		gr.intByteString() // music
+	}
+	gr.intByteString() // copyright
+	gr.intByteString() // tab author
+	gr.intByteString() // instructions
+	for range gr.count() {
+		gr.intByteString() // notice
+	}
+}
+
+func (gr *gpReader) readMeasureHeaders(n int, song *Song) ([]gpMeasure, error) {
+	measures := make([]gpMeasure, 0, n)
+	signature := DefaultTimeSignature
+	start := 0.0
+
+	for i := range n {
+		if gr.version >= 500 && i > 0 {
+			gr.skip(1)
+		}
+		flags := gr.byte()
+		if flags&0x01 != 0 {
+			signature.Beats = gr.int8()
+		}
+		if flags&0x02 != 0 {
+			signature.BeatUnit = gr.int8()
+		}
+		if flags&0x08 != 0 {
+			gr.skip(1) // repeat count
+		}
+		if flags&0x10 != 0 && gr.version < 500 {
+			gr.skip(1) // alternate ending
+		}
+		if flags&0x20 != 0 {
+			gr.intByteString() // marker and its color
+			gr.skip(4)
+		}
+		if flags&0x40 != 0 {
+			fifths, minor := gr.int8(), gr.byte() == 1
+			if i == 0 {
+				song.Key = keyName(fifths, minor)
+			}
+		}
+		if gr.version >= 500 {
+			if flags&0x10 != 0 {
+				gr.skip(1) // alternate endings
+			}
+			if flags&0x03 != 0 {
+				gr.skip(4) // beams
+			}
+			if flags&0x10 == 0 {
+				gr.skip(1)
+			}
+			gr.skip(1) // triplet feel
+		}
+		if gr.err != nil {
+			return nil, gr.err
+		}
+
+		if err := signature.Validate(); err != nil {
+			return nil, fmt.Errorf("measure %d: %w", i+1, err)
+		}
+		if i == 0 || flags&0x03 != 0 {
+			gr.addSignature(start, signature)
+		}
+		length := float64(signature.Beats) * 4 / float64(signature.BeatUnit)
+		measures = append(measures, gpMeasure{start: start})
+		start += length
+	}
+	return measures, nil
+}
+
+func (gr *gpReader) readTracks(n int) ([]gpTrack, error) {
+	tracks := make([]gpTrack, 0, n)
+	for i := range n {
+		if gr.version >= 500 && (i == 0 || gr.version == 500) {
+			gr.skip(1)
+		}
+		t := gpTrack{}
+		t.percussion = gr.byte()&0x01 != 0
+		t.name = gr.byteString(40)
+
+		strings := gr.int32()
+		if gr.err == nil && (strings < 1 || strings > 7) {
+			return nil, fmt.Errorf("track %d: invalid string count %d", i+1, strings)
+		}
+		pitches := make([]int, 7)
+		for s := range pitches {
+			pitches[s] = gr.int32()
+		}
+		gr.skip(12) // MIDI port, channel and effect channel
+		t.frets = gr.int32()
+		t.capo = gr.int32()
+		gr.skip(4) // color
+
+		if gr.version >= 500 {
+			gr.skip(4)  // display flags, auto accentuation and MIDI bank
+			gr.skip(25) // RSE humanize and settings
+			gr.skip(12) // RSE instrument and sound bank
+			if gr.version == 500 {
+				gr.skip(3)
+			} else {
+				gr.skip(8) // effect number and equalizer
+				gr.intByteString()
+				gr.intByteString()
+			}
+		}
+		if gr.err != nil {
+			return nil, gr.err
+		}
+
+		for s := range strings {
+			name, octave := pitchNote(pitches[s])
+			t.tuning = append(t.tuning, Note{Name: name, Octave: octave, String: s})
+		}
+		tracks = append(tracks, t)
+	}
+	if gr.version >= 500 {
+		gr.skip(1)
+		if gr.version == 500 {
+			gr.skip(1)
+		}
+	}
+	return tracks, gr.err
+}
+
+// readMeasure reads a measure of a track, one voice before Guitar Pro 5
+// and two since.
+func (gr *gpReader) readMeasure(t *gpTrack, m int, measure gpMeasure) error {
+	voices := 1
+	if gr.version >= 500 {
+		voices = 2
+	}
+	for range voices {
+		pos := measure.start
+		for range gr.count() {
+			beat, err := gr.readBeat(len(t.tuning))
+			if err != nil {
+				return err
+			}
+			beat.measure, beat.start = m, pos
+			if beat.tempo > 0 {
+				gr.addTempo(pos, float64(beat.tempo))
+			}
+			pos += beat.length
+			if len(beat.notes) > 0 {
+				t.beats = append(t.beats, beat)
+			}
+		}
+	}
+	if gr.version >= 500 {
+		gr.skip(1) // line break
+	}
+	return gr.err
+}
+
+func (gr *gpReader) readBeat(strings int) (gpBeat, error) {
+	beat := gpBeat{}
+	flags := gr.byte()
+	empty := false
+	if flags&0x40 != 0 {
+		empty = gr.byte() == 0
+	}
+
+	value := gr.int8() + 2
+	if gr.err == nil && (value < 0 || value > 6) {
+		return beat, fmt.Errorf("invalid beat duration %d", value-2)
+	}
+	beat.length = 4 / float64(int(1)<<max(0, value))
+	if flags&0x01 != 0 {
+		beat.length *= 1.5
+	}
+	if flags&0x20 != 0 {
+		n := gr.int32()
+		if times, ok := gpTuplets[n]; ok {
+			beat.length *= float64(times) / float64(n)
+		}
+	}
+
+	if flags&0x02 != 0 {
+		gr.skipChord()
+	}
+	if flags&0x04 != 0 {
+		gr.intByteString() // text
+	}
+	if flags&0x08 != 0 {
+		beat.effects = gr.readBeatEffects()
+	}
+	beat.tempo = -1
+	if flags&0x10 != 0 {
+		beat.tempo = gr.readMixTable()
+	}
+
+	stringFlags := gr.byte()
+	for s := range strings {
+		if stringFlags&(1<<(6-s)) != 0 {
+			n := gr.readNote()
+			n.string = s
+			beat.notes = append(beat.notes, n)
+		}
+	}
+	if gr.version >= 500 {
+		if gr.int16()&0x0800 != 0 {
+			gr.skip(1) // secondary beams
+		}
+	}
+
+	if empty {
+		beat.length = 0
+	}
+	return beat, gr.err
+}
+
+// skipChord skips a chord diagram, old or new style.
+func (gr *gpReader) skipChord() {
+	if gr.version >= 500 {
+		gr.skip(17)
+		gr.skip(22 + 4 + 4 + 7*4 + 32)
+		return
+	}
+	if gr.byte() == 0 {
+		gr.intByteString()
+		if gr.int32() > 0 {
+			gr.skip(6 * 4) // the old style always has six strings
+		}
+		return
+	}
+	if gr.version >= 400 {
+		gr.skip(16 + 22 + 4 + 4 + 7*4 + 32)
+		return
+	}
+	gr.skip(25 + 35 + 4 + 6*4 + 36)
+}
+
+func (gr *gpReader) readBeatEffects() gpBeatEffects {
+	e := gpBeatEffects{}
+	flags1, flags2 := gr.byte(), 0
+	if gr.version >= 400 {
+		flags2 = gr.byte()
+	}
+
+	if gr.version < 400 {
+		e.vibrato = flags1&0x01 != 0
+		e.wideVibrato = flags1&0x02 != 0
+		if flags1&0x04 != 0 {
+			e.harmonic = gpNaturalHarmonic
+		}
+		if flags1&0x08 != 0 {
+			e.harmonic = gpArtificialHarmonic
+		}
+	} else {
+		e.vibrato = flags1&0x02 != 0
+	}
+
+	if flags1&0x20 != 0 {
+		switch gr.int8() {
+		case 0:
+			e.tremoloBar = true
+		case 1:
+			e.tap = true
+		default:
+			e.slapPop = true
+		}
+		if gr.version < 400 {
+			gr.skip(4)
+		}
+	}
+	if flags2&0x04 != 0 {
+		gr.readBend()
+		e.tremoloBar = true
+	}
+	if flags1&0x40 != 0 {
+		down, up := gr.int8(), gr.int8()
+		if gr.version >= 500 {
+			down, up = up, down
+		}
+		switch {
+		case down > 0:
+			e.stroke, e.strokeSpeed = StrumDown, down
+		case up > 0:
+			e.stroke, e.strokeSpeed = StrumUp, up
+		}
+	}
+	if flags2&0x02 != 0 {
+		gr.skip(1) // pick stroke
+	}
+	return e
+}
+
+// readMixTable returns the tempo of a mix table change, -1 when it does
+// not change, skipping the rest of it.
+func (gr *gpReader) readMixTable() int {
+	gr.skip(1) // instrument
+	if gr.version >= 500 {
+		gr.skip(16)
+	}
+	values := make([]int, 6)
+	for i := range values {
+		values[i] = gr.int8()
+	}
+	if gr.version >= 500 {
+		gr.intByteString()
+	}
+	tempo := gr.int32()
+	for _, v := range values {
+		if v >= 0 {
+			gr.skip(1)
+		}
+	}
+	if tempo >= 0 {
+		gr.skip(1)
+		if gr.version >= 510 {
+			gr.skip(1)
+		}
+	}
+	if gr.version >= 400 {
+		gr.skip(1)
+	}
+	if gr.version >= 500 {
+		gr.skip(1)
+	}
+	if gr.version >= 510 {
+		gr.intByteString()
+		gr.intByteString()
+	}
+	return tempo
+}
+
+func (gr *gpReader) readNote() gpNote {
+	flags := gr.byte()
+	n := gpNote{kind: gpNormalNote}
+	n.ghost = flags&0x04 != 0
+	n.accent = flags&0x40 != 0 || gr.version >= 500 && flags&0x02 != 0
+
+	if flags&0x20 != 0 {
+		n.kind = gr.byte()
+	}
+	if flags&0x01 != 0 && gr.version < 500 {
+		gr.skip(2) // duration and tuplet of the note
+	}
+	if flags&0x10 != 0 {
+		gr.skip(1) // dynamic
+	}
+	if flags&0x20 != 0 {
+		n.fret = gr.int8()
+	}
+	if flags&0x80 != 0 {
+		gr.skip(2) // fingering
+	}
+	if gr.version >= 500 {
+		if flags&0x01 != 0 {
+			gr.skip(8) // duration percent
+		}
+		gr.skip(1)
+	}
+	if flags&0x08 != 0 {
+		n.effects = gr.readNoteEffects()
+	}
+	return n
+}
+
+func (gr *gpReader) readNoteEffects() gpNoteEffects {
+	e := gpNoteEffects{}
+	flags1, flags2 := gr.byte(), 0
+	if gr.version >= 400 {
+		flags2 = gr.byte()
+	}
+	e.hammer = flags1&0x02 != 0
+	e.letRing = flags1&0x08 != 0
+	e.staccato = flags2&0x01 != 0
+	e.palmMute = flags2&0x02 != 0
+	e.vibrato = flags2&0x40 != 0
+
+	if flags1&0x01 != 0 {
+		e.bend = gr.readBend()
+	}
+	if flags1&0x10 != 0 {
+		e.grace = true
+		gr.skip(4)
+		if gr.version >= 500 {
+			gr.skip(1)
+		}
+	}
+	if gr.version < 400 {
+		if flags1&0x04 != 0 {
+			e.slide = gpShiftSlide
+		}
+		return e
+	}
+
+	if flags2&0x04 != 0 {
+		e.tremolo = 4 << max(1, gr.int8())
+	}
+	if flags2&0x08 != 0 {
+		if gr.version >= 500 {
+			e.slide = gr.byte()
+		} else {
+			e.slide = map[int]int{1: gpShiftSlide, 2: gpLegatoSlide, 3: gpSlideOutDown, 4: gpSlideOutUp,
+				-1: gpSlideInBelow, -2: gpSlideInAbove}[gr.int8()]
+		}
+	}
+	if flags2&0x10 != 0 {
+		gr.readHarmonic(&e)
+	}
+	if flags2&0x20 != 0 {
+		e.trillFret = gr.int8()
+		gr.skip(1) // period
+	}
+	return e
+}
+
+func (gr *gpReader) readHarmonic(e *gpNoteEffects) {
+	kind := gr.int8()
+	if gr.version >= 500 {
+		e.harmonic = kind
+		switch kind {
+		case gpArtificialHarmonic:
+			gr.skip(3) // the pitch of the harmonic
+			e.harmonicFret = 12
+		case gpTappedHarmonic:
+			e.harmonicFret = gr.int8()
+		}
+		return
+	}
+
+	switch kind {
+	case 1:
+		e.harmonic = gpNaturalHarmonic
+	case 3:
+		e.harmonic, e.harmonicFret = gpTappedHarmonic, 0
+	case 4:
+		e.harmonic = gpPinchHarmonic
+	case 5:
+		e.harmonic = gpSemiHarmonic
+	case 15, 17, 22:
+		e.harmonic = gpArtificialHarmonic
+		e.harmonicFret = map[int]int{15: 5, 17: 7, 22: 12}[kind]
+	}
+}
+
+// readBend reads the points of a bend, scaled to positions from 0 to 1
+// and cents.
+func (gr *gpReader) readBend() BendCurve {
+	gr.skip(5) // type and value
+	curve := BendCurve{}
+	for range gr.count() {
+		position, value := gr.int32(), gr.int32()
+		gr.skip(1) // vibrato
+		curve = append(curve, BendPoint{
+			Position: min(1, max(0, float32(position)/gpBendPositions)),
+			Cents:    max(0, value*QuarterStep/gpBendQuarterTone),
+		})
+	}
+	return curve
+}
+
+func (gr *gpReader) warn(track, measure int, effect string) {
+	w := GPWarning{Track: track, Measure: measure, Effect: effect}
+	if !gr.warned[w] {
+		gr.warned[w] = true
+		gr.warnings = append(gr.warnings, w)
+	}
+}
+
+// events turns the beats of a track into Playables, lengthening tied
+// notes and joining hammer-ons, pull-offs and slides to the next note on
+// their string.
+func (gr *gpReader) events(track int, t gpTrack) []Playable {
+	// the voices of a measure are read one after the other
+	sort.SliceStable(t.beats, func(i, j int) bool { return t.beats[i].start < t.beats[j].start })
+
+	tl := newTabLinks()
+	frets := map[int]int{}
+
+	for _, b := range t.beats {
+		start := gr.seconds(b.start)
+		end := gr.seconds(b.start + b.length)
+		warn := func(effect string) { gr.warn(track, b.measure+1, effect) }
+		if b.effects.tremoloBar {
+			warn("tremolo bar")
+		}
+		if b.effects.slapPop {
+			warn("slap and pop")
+		}
+
+		if chord, ok := gr.strum(b, start, end); ok {
+			for _, n := range b.notes {
+				tl.clear(n.string)
+				frets[n.string] = n.fret
+			}
+			tl.events = append(tl.events, chord)
+			continue
+		}
+		if b.effects.stroke != NoStrum {
+			warn("stroke of notes with effects")
+		}
+
+		for _, n := range b.notes {
+			s, e := n.string, n.effects
+			if n.kind == gpTiedNote {
+				if tl.tie(s, start, end) {
+					continue
+				}
+				n.fret = frets[s]
+			}
+			frets[s] = n.fret
+
+			if e.grace {
+				warn("grace note")
+			}
+			if kind, ok := tl.pendingLink(s, start); ok && n.kind == gpNormalNote && isPlainGPNote(n, b.effects) {
+				if kind == LegatoHammerOn && n.fret < tl.lastFret(s) {
+					kind = LegatoPullOff
+				}
+				if tl.link(s, kind, n.fret, start, end) {
+					gr.startLink(tl, n)
+					continue
+				}
+			}
+
+			tl.add(s, gr.playable(n, b.effects, start, end-start, warn), end)
+			gr.startLink(tl, n)
+		}
+	}
+	return tl.events
+}
+
+func (gr *gpReader) startLink(tl *tabLinks, n gpNote) {
+	switch {
+	case n.effects.slide&gpSlideTo != 0:
+		tl.startLink(n.string, LegatoSlide)
+	case n.effects.hammer:
+		tl.startLink(n.string, LegatoHammerOn)
+	}
+}
+
+// isPlainGPNote tells whether a note can be the target of a link.
+func isPlainGPNote(n gpNote, b gpBeatEffects) bool {
+	e := n.effects
+	return len(e.bend) == 0 && e.harmonic == 0 && e.tremolo == 0 && e.trillFret == 0 &&
+		e.slide&^gpSlideTo == 0 && !b.tap && b.harmonic == 0
+}
+
+// strum returns a stroked beat of plain notes as a strummed Chord.
+func (gr *gpReader) strum(b gpBeat, start, end float32) (Chord, bool) {
+	if b.effects.stroke == NoStrum || len(b.notes) < 2 {
+		return Chord{}, false
+	}
+	frets := []int{}
+	for _, n := range b.notes {
+		e := n.effects
+		if n.kind != gpNormalNote || !isPlainGPNote(n, b.effects) || n.ghost || n.accent ||
+			e.hammer || e.letRing || e.staccato || e.palmMute || e.vibrato || e.grace || e.slide != 0 {
+			return Chord{}, false
+		}
+		for len(frets) <= n.string {
+			frets = append(frets, ChordSkip)
+		}
+		frets[n.string] = n.fret
+	}
+
+	// the whole stroke lasts from a 128th note at speed 1 to a quarter
+	// note at speed 6
+	length := 4 / float64(int(128)>>(min(6, b.effects.strokeSpeed)-1))
+	spread := (gr.seconds(b.start+length) - start) / float32(len(b.notes)-1)
+	return Chord{Frets: frets, Strum: b.effects.stroke, Spread: spread, Time: start, Duration: end - start}, true
+}
+
+// playable builds the Playable of a note from its effects and the ones
+// of its beat.
+func (gr *gpReader) playable(n gpNote, b gpBeatEffects, time, duration float32, warn func(string)) Playable {
+	e := n.effects
+	s, fret := n.string, n.fret
+	harmonic, harmonicFret := e.harmonic, e.harmonicFret
+	if harmonic == 0 && b.harmonic != 0 {
+		harmonic, harmonicFret = b.harmonic, 12
+	}
+
+	var p Playable = Note{Fret: fret, String: s, Time: time, Duration: duration}
+	switch {
+	case n.kind == gpDeadNote:
+		p = DeadNote{String: s, Time: time, Duration: duration}
+	case harmonic == gpNaturalHarmonic:
+		p = Harmonic{Fret: fret, String: s, Time: time, Duration: duration}
+	case harmonic == gpArtificialHarmonic:
+		p = ArtificialHarmonic{Fret: fret, Offset: harmonicFret, String: s, Time: time, Duration: duration}
+	case harmonic == gpTappedHarmonic:
+		offset := 12
+		if harmonicFret > fret {
+			offset = harmonicFret - fret
+		}
+		p = ArtificialHarmonic{Fret: fret, Offset: offset, String: s, Time: time, Duration: duration}
+	case harmonic == gpPinchHarmonic:
+		p = PinchHarmonic{Fret: fret, String: s, Time: time, Duration: duration}
+	case b.tap:
+		p = Tap{Fret: fret, String: s, Time: time, Duration: duration}
+	case len(e.bend) > 0:
+		p = bendFromCurve(e.bend, fret, s, time, duration)
+	case e.slide&gpSlideInBelow != 0:
+		p = Slide{FretStart: -1, FretEnd: fret, String: s, Time: time, Duration: duration}
+	case e.tremolo > 0:
+		p = Tremolo{Fret: fret, Division: e.tremolo, String: s, Time: time, Duration: duration}
+	case e.trillFret > 0:
+		p = Trill{Fret: fret, TrillFret: e.trillFret, String: s, Time: time, Duration: duration}
+	}
+	if harmonic == gpSemiHarmonic {
+		warn("semi harmonic")
+	}
+	if e.slide&gpUnsupportedOut != 0 {
+		warn("slide out or in from above")
+	}
+
+	var a Articulation
+	if n.ghost {
+		a |= Ghost
+	}
+	if n.accent {
+		a |= Accent
+	}
+	if e.staccato {
+		a |= Staccato
+	}
+	if e.palmMute {
+		a |= PalmMute
+	}
+	if e.letRing {
+		a |= LetRing
+	}
+	if b.wideVibrato {
+		a |= WideVibrato
+	} else if e.vibrato || b.vibrato {
+		a |= Vibrato
+	}
+	if a != 0 {
+		return Articulate(p, a)
+	}
+	return p
+}
+
+func (gr *gpReader) skip(n int) {
+	if gr.err != nil {
+		return
+	}
+	if n < 0 || gr.pos+n > len(gr.data) {
+		gr.err = errGPTruncated
+		return
+	}
+	gr.pos += n
+}
+
+func (gr *gpReader) bytes(n int) []byte {
+	start := gr.pos
+	gr.skip(n)
+	if gr.err != nil {
+		return nil
+	}
+	return gr.data[start:gr.pos]
+}
+
+func (gr *gpReader) byte() int {
+	if b := gr.bytes(1); b != nil {
+		return int(b[0])
+	}
+	return 0
+}
+
+func (gr *gpReader) int8() int {
+	return int(int8(gr.byte()))
+}
+
+func (gr *gpReader) int16() int {
+	if b := gr.bytes(2); b != nil {
+		return int(int16(binary.LittleEndian.Uint16(b)))
+	}
+	return 0
+}
+
+func (gr *gpReader) int32() int {
+	if b := gr.bytes(4); b != nil {
+		return int(int32(binary.LittleEndian.Uint32(b)))
+	}
+	return 0
+}
+
+// count reads a count of items, each at least a byte long.
+func (gr *gpReader) count() int {
+	n := gr.int32()
+	if gr.err == nil && (n < 0 || n > len(gr.data)-gr.pos) {
+		gr.err = fmt.Errorf("invalid count %d", n)
+	}
+	if gr.err != nil {
+		return 0
+	}
+	return n
+}
+
+// byteString reads a string of a length byte and size bytes.
+func (gr *gpReader) byteString(size int) string {
+	length := gr.byte()
+	return latin1(gr.bytes(size), length)
+}
+
+// intString reads a string of a 32 bit length and its bytes.
+func (gr *gpReader) intString() string {
+	n := gr.int32()
+	return latin1(gr.bytes(n), n)
+}
+
+// intByteString reads a string of a 32 bit size, a length byte and the
+// rest of the size.
+func (gr *gpReader) intByteString() string {
+	size := gr.int32() - 1
+	length := gr.byte()
+	if size < 0 {
+		size = length
+	}
+	return latin1(gr.bytes(size), length)
+}
+
+// latin1 decodes the first length bytes of b, Guitar Pro strings being
+// single byte encoded.
+func latin1(b []byte, length int) string {
+	runes := make([]rune, 0, len(b))
+	for i := 0; i < len(b) && i < length; i++ {
+		runes = append(runes, rune(b[i]))
+	}
+	return string(runes)
+}
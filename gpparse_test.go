@@ -0,0 +1,360 @@
+package guitar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gpTestWriter writes the parts of a Guitar Pro file.
+type gpTestWriter struct {
+	bytes.Buffer
+	version int
+}
+
+func (w *gpTestWriter) bytes(values ...int) {
+	for _, v := range values {
+		w.WriteByte(byte(v))
+	}
+}
+
+func (w *gpTestWriter) int32s(values ...int) {
+	for _, v := range values {
+		_ = binary.Write(w, binary.LittleEndian, int32(v))
+	}
+}
+
+func (w *gpTestWriter) byteString(s string, size int) {
+	w.bytes(len(s))
+	w.WriteString(s)
+	w.Write(make([]byte, size-len(s)))
+}
+
+func (w *gpTestWriter) intByteString(s string) {
+	w.int32s(len(s) + 1)
+	w.bytes(len(s))
+	w.WriteString(s)
+}
+
+type testGPBeat struct {
+	// duration is -2 for a whole note, 0 for a quarter, 1 for an eighth
+	duration int
+	effects  []int
+	tempo    int
+	notes    map[int][]int
+	// chord is a chord diagram, written as is
+	chord []int
+}
+
+type testGPMeasure struct {
+	flags  int
+	header []int
+	beats  []testGPBeat
+}
+
+// testGP writes a Guitar Pro file with a six string track in standard
+// tuning, capo 2, at 120 bpm.
+func testGP(version string, measures ...testGPMeasure) []byte {
+	w := &gpTestWriter{version: gpVersions[version]}
+	w.byteString(version, 30)
+	for _, s := range []string{"Song", "", "Band", "", ""} {
+		w.intByteString(s)
+	}
+	if w.version >= 500 {
+		w.intByteString("")
+	}
+	for range 3 {
+		w.intByteString("")
+	}
+	w.int32s(0)
+
+	if w.version < 500 {
+		w.bytes(0)
+	}
+	if w.version >= 400 {
+		w.int32s(0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+	}
+	if w.version >= 510 {
+		w.Write(make([]byte, 19))
+	}
+	if w.version >= 500 {
+		w.Write(make([]byte, 30))
+		for range 11 {
+			w.intByteString("")
+		}
+	}
+	w.int32s(120)
+	if w.version >= 510 {
+		w.bytes(0)
+	}
+	w.int32s(0)
+	if w.version >= 400 {
+		w.bytes(0)
+	}
+	w.Write(make([]byte, 64*12))
+	if w.version >= 500 {
+		w.Write(make([]byte, 42))
+	}
+	w.int32s(len(measures), 1)
+
+	for i, m := range measures {
+		if w.version >= 500 && i > 0 {
+			w.bytes(0)
+		}
+		w.bytes(m.flags)
+		w.bytes(m.header...)
+		if w.version >= 500 {
+			if m.flags&0x03 != 0 {
+				w.bytes(0, 0, 0, 0)
+			}
+			w.bytes(0, 0)
+		}
+	}
+
+	if w.version >= 500 {
+		w.bytes(0)
+	}
+	w.bytes(0)
+	w.byteString("Lead", 40)
+	w.int32s(6, 64, 59, 55, 50, 45, 40, 0, 1, 1, 2, 24, 2, 0)
+	switch {
+	case w.version == 500:
+		w.Write(make([]byte, 4+25+12+3+2))
+	case w.version > 500:
+		w.Write(make([]byte, 4+25+12+8))
+		w.intByteString("")
+		w.intByteString("")
+		w.bytes(0)
+	}
+
+	for _, m := range measures {
+		w.int32s(len(m.beats))
+		for _, b := range m.beats {
+			w.beat(b)
+		}
+		if w.version >= 500 {
+			w.int32s(0)
+			w.bytes(0)
+		}
+	}
+	return w.Bytes()
+}
+
+func (w *gpTestWriter) beat(b testGPBeat) {
+	flags := 0
+	if len(b.effects) > 0 {
+		flags |= 0x08
+	}
+	if b.tempo > 0 {
+		flags |= 0x10
+	}
+	if len(b.chord) > 0 {
+		flags |= 0x02
+	}
+	w.bytes(flags, b.duration)
+	w.bytes(b.chord...)
+	w.bytes(b.effects...)
+	if b.tempo > 0 {
+		w.bytes(0xFF)
+		if w.version >= 500 {
+			w.Write(make([]byte, 16))
+		}
+		w.bytes(0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF)
+		if w.version >= 500 {
+			w.intByteString("")
+		}
+		w.int32s(b.tempo)
+		w.bytes(0)
+		if w.version >= 510 {
+			w.bytes(0)
+		}
+		if w.version >= 400 {
+			w.bytes(0)
+		}
+		if w.version >= 500 {
+			w.bytes(0)
+		}
+		if w.version >= 510 {
+			w.intByteString("")
+			w.intByteString("")
+		}
+	}
+
+	stringFlags := 0
+	for s := range b.notes {
+		stringFlags |= 1 << (6 - s)
+	}
+	w.bytes(stringFlags)
+	for s := range 7 {
+		w.bytes(b.notes[s]...)
+	}
+	if w.version >= 500 {
+		w.bytes(0, 0)
+	}
+}
+
+// gp5Note is a note of a type, 1 normal, 2 tied or 3 dead, followed by
+// its effect flags and values.
+func gp5Note(fret, kind int, effects ...int) []int {
+	flags := 0x20
+	if len(effects) > 0 {
+		flags |= 0x08
+	}
+	return append([]int{flags, kind, fret, 0}, effects...)
+}
+
+func gp3Note(fret, kind int, effects ...int) []int {
+	flags := 0x20
+	if len(effects) > 0 {
+		flags |= 0x08
+	}
+	return append([]int{flags, kind, fret}, effects...)
+}
+
+func le32(v int) []int {
+	return []int{v & 0xFF, v >> 8 & 0xFF, v >> 16 & 0xFF, v >> 24 & 0xFF}
+}
+
+// gpBend is a bend of points of position and value, 25 a quarter tone.
+func gpBend(points ...int) []int {
+	b := append([]int{1}, le32(100)...)
+	b = append(b, le32(len(points)/2)...)
+	for i := 0; i < len(points); i += 2 {
+		b = append(b, le32(points[i])...)
+		b = append(b, le32(points[i+1])...)
+		b = append(b, 0)
+	}
+	return b
+}
+
+func TestReadGuitarPro5(t *testing.T) {
+	data := testGP("FICHIER GUITAR PRO v5.10",
+		testGPMeasure{flags: 0x43, header: []int{4, 4, 0xFF, 1}, beats: []testGPBeat{
+			{notes: map[int][]int{0: gp5Note(5, 1, 0x02, 0)}},
+			{notes: map[int][]int{0: gp5Note(7, 1)}},
+			{notes: map[int][]int{2: gp5Note(7, 1, append([]int{0x01, 0}, gpBend(0, 0, 20, 100, 40, 100, 60, 0)...)...)}},
+			{notes: map[int][]int{5: gp5Note(0, 3, 0, 0x02)}},
+		}},
+		testGPMeasure{beats: []testGPBeat{
+			{duration: 1, notes: map[int][]int{1: gp5Note(12, 1, 0, 0x10, 1)}},
+			{duration: 1, tempo: 60, notes: map[int][]int{3: gp5Note(5, 1, 0, 0x08, 0x01)}},
+			{notes: map[int][]int{3: gp5Note(7, 1)}},
+			{notes: map[int][]int{3: gp5Note(7, 2)}},
+			{notes: map[int][]int{4: gp5Note(2, 1, 0x10, 0, 1, 96, 0, 1, 0)}},
+		}},
+	)
+
+	song, warnings, err := ReadGuitarPro(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, "Song", song.Title)
+	assert.Equal(t, "Band", song.Artist)
+	assert.Equal(t, "Dm", song.Key)
+	assert.Equal(t, []TempoChange{{BPM: 120}, {Time: 2.25, BPM: 60}}, song.Tempos)
+	assert.Equal(t, []TimeSignatureChange{{Signature: TimeSignature{4, 4}}}, song.TimeSignatures)
+	assert.Equal(t, []GPWarning{{Track: 0, Measure: 2, Effect: "grace note"}}, warnings)
+
+	tun, _ := ParseTuning(StandardTuning)
+	tr := song.Tracks[0]
+	assert.Equal(t, "Lead", tr.Name)
+	assert.Equal(t, tun, tr.Tuning)
+	assert.Equal(t, 24, tr.Frets)
+	assert.Equal(t, 2, tr.Capo)
+
+	assert.Equal(t, Playables{
+		HammerOn{FretFrom: 5, FretTo: 7, String: 0, Time: 0, Duration: 1},
+		BendRelease{Fret: 7, Cents: FullStep, String: 2, Time: 1, Duration: 0.5},
+		Articulate(DeadNote{String: 5, Time: 1.5, Duration: 0.5}, PalmMute),
+		Harmonic{Fret: 12, String: 1, Time: 2, Duration: 0.25},
+		Slide{FretStart: 5, FretEnd: 7, String: 3, Time: 2.25, Duration: 2.5},
+		Note{Fret: 2, String: 4, Time: 4.75, Duration: 1},
+	}, tr.Events)
+}
+
+func TestReadGuitarPro3(t *testing.T) {
+	data := testGP("FICHIER GUITAR PRO v3.00",
+		testGPMeasure{flags: 0x03, header: []int{3, 4}, beats: []testGPBeat{
+			// a chord strummed down over a 64th note
+			{effects: []int{0x40, 2, 0}, notes: map[int][]int{0: gp3Note(0, 1), 1: gp3Note(1, 1), 2: gp3Note(0, 1)}},
+			{notes: map[int][]int{4: gp3Note(3, 1, 0x04)}},
+			// the tremolo bar of Guitar Pro 3
+			{effects: []int{0x20, 0, 0, 0, 0, 0}, notes: map[int][]int{4: gp3Note(5, 1)}},
+		}},
+		testGPMeasure{beats: []testGPBeat{
+			{notes: map[int][]int{0: gp3Note(7, 1)}},
+			{effects: []int{0x40, 0, 2}, notes: map[int][]int{0: gp3Note(0, 1), 1: gp3Note(1, 1)}},
+			// tied to the open string of the chord
+			{notes: map[int][]int{0: gp3Note(0, 2)}},
+		}},
+	)
+
+	song, warnings, err := ReadGuitarPro(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, "C", song.Key)
+	assert.Equal(t, []TimeSignatureChange{{Signature: TimeSignature{3, 4}}}, song.TimeSignatures)
+	assert.Equal(t, []GPWarning{{Track: 0, Measure: 1, Effect: "tremolo bar"}}, warnings)
+	assert.Equal(t, "track 1, measure 1: tremolo bar not supported", warnings[0].String())
+
+	assert.Equal(t, Playables{
+		Chord{Frets: []int{0, 1, 0}, Strum: StrumDown, Spread: 0.015625, Time: 0, Duration: 0.5},
+		Slide{FretStart: 3, FretEnd: 5, String: 4, Time: 0.5, Duration: 1},
+		Note{Fret: 7, String: 0, Time: 1.5, Duration: 0.5},
+		Chord{Frets: []int{0, 1}, Strum: StrumUp, Spread: 0.03125, Time: 2, Duration: 0.5},
+		Note{Fret: 0, String: 0, Time: 2.5, Duration: 0.5},
+	}, song.Tracks[0].Events)
+}
+
+func TestReadGuitarPro4(t *testing.T) {
+	// an old style chord diagram of six strings, named Am from the first fret
+	chord := append([]int{0}, le32(3)...)
+	chord = append(chord, 2, 'A', 'm')
+	chord = append(chord, le32(1)...)
+	for _, f := range []int{0, 1, 2, 2, 0, -1} {
+		chord = append(chord, le32(f)...)
+	}
+
+	data := testGP("FICHIER GUITAR PRO v4.06",
+		testGPMeasure{flags: 0x03, header: []int{4, 4}, beats: []testGPBeat{
+			{chord: chord, notes: map[int][]int{1: gp3Note(3, 1, 0x02, 0)}},
+			{notes: map[int][]int{1: gp3Note(5, 1)}},
+			{duration: -1, notes: map[int][]int{2: gp3Note(2, 1, 0, 0x01)}},
+		}},
+	)
+
+	song, warnings, err := ReadGuitarPro(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+	assert.Equal(t, []TimeSignatureChange{{Signature: TimeSignature{4, 4}}}, song.TimeSignatures)
+
+	assert.Equal(t, Playables{
+		HammerOn{FretFrom: 3, FretTo: 5, String: 1, Time: 0, Duration: 1},
+		Articulate(Note{Fret: 2, String: 2, Time: 1, Duration: 1}, Staccato),
+	}, song.Tracks[0].Events)
+}
+
+func TestReadGuitarProErrors(t *testing.T) {
+	valid := testGP("FICHIER GUITAR PRO v5.00", testGPMeasure{flags: 0x03, header: []int{4, 4}, beats: []testGPBeat{
+		{notes: map[int][]int{0: gp5Note(5, 1)}},
+	}})
+
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "version", data: testGP("FICHIER GUITAR PRO v2.21")},
+		{name: "truncated", data: valid[:len(valid)-10]},
+		{name: "time signature", data: testGP("FICHIER GUITAR PRO v5.00", testGPMeasure{flags: 0x03, header: []int{4, 3}})},
+		{name: "duration", data: testGP("FICHIER GUITAR PRO v5.00", testGPMeasure{beats: []testGPBeat{{duration: 9}}})},
+	}
+
+	_, _, err := ReadGuitarPro(bytes.NewReader(valid))
+	assert.NoError(t, err)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := ReadGuitarPro(bytes.NewReader(tc.data))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,426 @@
+package guitar
+
+import (
+	"fmt"
+	"strings"
+)
+
+type LegatoKind int
+
+const (
+	LegatoHammerOn LegatoKind = iota
+	LegatoPullOff
+	LegatoSlide
+	LegatoBend
+	LegatoRelease
+	// LegatoVibrato shakes the note reached so far; its fret is ignored.
+	LegatoVibrato
+)
+
+var legatoKindNames = []string{"hammer_on", "pull_off", "slide", "bend", "release", "vibrato"}
+
+func (k LegatoKind) String() string {
+	if k < 0 || int(k) >= len(legatoKindNames) {
+		return fmt.Sprintf("LegatoKind(%d)", int(k))
+	}
+	return legatoKindNames[k]
+}
+
+func (k LegatoKind) MarshalText() ([]byte, error) {
+	if k < 0 || int(k) >= len(legatoKindNames) {
+		return nil, fmt.Errorf("invalid legato kind %d", int(k))
+	}
+	return []byte(k.String()), nil
+}
+
+func (k *LegatoKind) UnmarshalText(text []byte) error {
+	for i, name := range legatoKindNames {
+		if name == string(text) {
+			*k = LegatoKind(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown legato kind %q", text)
+}
+
+// LegatoStep moves a legato chain to Fret, Offset seconds after the
+// chain is picked. For a bend or a release Fret is the fret the pitch
+// sounds like, as in "7b9r7".
+type LegatoStep struct {
+	Kind   LegatoKind `json:"kind" yaml:"kind"`
+	Fret   int        `json:"fret,omitempty" yaml:"fret,omitempty"`
+	Offset float32    `json:"offset,omitempty" yaml:"offset,omitempty"`
+}
+
+// Legato is a run on one string picked once at Fret, e.g. "5h7p5h8" or
+// "3/5h7~". It is written as one tab token and exported as one event per
+// step.
+type Legato struct {
+	Fret  int          `json:"fret" yaml:"fret"`
+	Steps []LegatoStep `json:"steps" yaml:"steps"`
+
+	String int `json:"string" yaml:"string"`
+
+	Time     float32 `json:"time,omitempty" yaml:"time,omitempty"`
+	Duration float32 `json:"duration,omitempty" yaml:"duration,omitempty"`
+}
+
+func NewLegato(fret, stringNumber int, time float32) Legato {
+	return Legato{Fret: fret, String: stringNumber, Time: time}
+}
+
+func (l Legato) HammerOn(fret int, offset float32) Legato {
+	return l.with(LegatoStep{Kind: LegatoHammerOn, Fret: fret, Offset: offset})
+}
+
+func (l Legato) PullOff(fret int, offset float32) Legato {
+	return l.with(LegatoStep{Kind: LegatoPullOff, Fret: fret, Offset: offset})
+}
+
+func (l Legato) SlideTo(fret int, offset float32) Legato {
+	return l.with(LegatoStep{Kind: LegatoSlide, Fret: fret, Offset: offset})
+}
+
+func (l Legato) Bend(fret int, offset float32) Legato {
+	return l.with(LegatoStep{Kind: LegatoBend, Fret: fret, Offset: offset})
+}
+
+func (l Legato) Release(fret int, offset float32) Legato {
+	return l.with(LegatoStep{Kind: LegatoRelease, Fret: fret, Offset: offset})
+}
+
+func (l Legato) Vibrato(offset float32) Legato {
+	return l.with(LegatoStep{Kind: LegatoVibrato, Offset: offset})
+}
+
+func (l Legato) with(step LegatoStep) Legato {
+	l.Steps = append(l.Steps[:len(l.Steps):len(l.Steps)], step)
+	return l
+}
+
+func (l Legato) Validate() error {
+	if l.Fret < 0 {
+		return fmt.Errorf("invalid fret %d", l.Fret)
+	}
+
+	fret, bent, last := l.Fret, false, float32(0)
+	for i, s := range l.Steps {
+		if s.Offset < last {
+			return fmt.Errorf("step %d: steps are not in time order", i)
+		}
+		last = s.Offset
+
+		if s.Kind != LegatoVibrato && s.Fret < 0 {
+			return fmt.Errorf("step %d: invalid fret %d", i, s.Fret)
+		}
+
+		switch s.Kind {
+		case LegatoHammerOn:
+			if s.Fret <= fret {
+				return fmt.Errorf("step %d: hammer-on from fret %d down to %d", i, fret, s.Fret)
+			}
+		case LegatoPullOff:
+			if s.Fret >= fret {
+				return fmt.Errorf("step %d: pull-off from fret %d up to %d", i, fret, s.Fret)
+			}
+		case LegatoSlide:
+		case LegatoBend:
+			if s.Fret <= fret {
+				return fmt.Errorf("step %d: bend from fret %d down to %d", i, fret, s.Fret)
+			}
+			bent = true
+			continue
+		case LegatoRelease:
+			if !bent {
+				return fmt.Errorf("step %d: release without bend", i)
+			}
+			if s.Fret < fret {
+				return fmt.Errorf("step %d: release below fret %d", i, fret)
+			}
+			bent = s.Fret > fret
+			continue
+		case LegatoVibrato:
+			continue
+		default:
+			return fmt.Errorf("step %d: invalid legato kind %d", i, s.Kind)
+		}
+
+		if bent {
+			return fmt.Errorf("step %d: %v on a bent string", i, s.Kind)
+		}
+		fret = s.Fret
+	}
+
+	return nil
+}
+
+func (l Legato) TabSymbol() string {
+	sb := strings.Builder{}
+	sb.WriteString(noteTechnique.Format(l.Fret))
+
+	fret := l.Fret
+	for _, s := range l.Steps {
+		switch s.Kind {
+		case LegatoHammerOn:
+			sb.WriteString("h")
+		case LegatoPullOff:
+			sb.WriteString("p")
+		case LegatoSlide:
+			if s.Fret < fret {
+				sb.WriteString(`\`)
+			} else {
+				sb.WriteString("/")
+			}
+		case LegatoBend:
+			sb.WriteString("b")
+		case LegatoRelease:
+			sb.WriteString("r")
+		case LegatoVibrato:
+			sb.WriteString("~")
+			continue
+		}
+		sb.WriteString(noteTechnique.Format(s.Fret))
+		if s.Kind != LegatoBend && s.Kind != LegatoRelease {
+			fret = s.Fret
+		}
+	}
+	return sb.String()
+}
+
+func (l Legato) StringNumber() int {
+	return l.String
+}
+
+func (l Legato) StartTime() float32 {
+	return l.Time
+}
+
+// EndTime is the end of the duration, or the last step when it comes later.
+func (l Legato) EndTime() float32 {
+	end := l.Time + l.Duration
+	if n := len(l.Steps); n > 0 {
+		end = max(end, l.Time+l.Steps[n-1].Offset)
+	}
+	return end
+}
+
+func (l Legato) AtTime(t float32) Playable {
+	l.Time = t
+	return l
+}
+
+// Events returns the chain as separate Playables: the picked note, then
+// one hammer-on, pull-off, slide or bend per step. A bend released
+// right after becomes one BendRelease and vibrato articulates the event
+// it follows. Each event lasts until the next one.
+func (l Legato) Events() []Playable {
+	events := []Playable{Note{Fret: l.Fret, String: l.String, Time: l.Time}}
+	fret := l.Fret
+
+	for _, s := range l.Steps {
+		time := l.Time + s.Offset
+		last := &events[len(events)-1]
+
+		switch s.Kind {
+		case LegatoHammerOn:
+			events = append(events, HammerOn{FretFrom: fret, FretTo: s.Fret, String: l.String, Time: time})
+			fret = s.Fret
+		case LegatoPullOff:
+			events = append(events, PullOff{FretFrom: fret, FretTo: s.Fret, String: l.String, Time: time})
+			fret = s.Fret
+		case LegatoSlide:
+			events = append(events, Slide{FretStart: fret, FretEnd: s.Fret, String: l.String, Time: time})
+			fret = s.Fret
+		case LegatoBend:
+			events = append(events, Bend{Fret: fret, Cents: (s.Fret - fret) * 100, String: l.String, Time: time})
+		case LegatoRelease:
+			if b, ok := Unwrap(*last).(Bend); ok {
+				var release Playable = BendRelease{Fret: b.Fret, Cents: b.Cents, Release: (s.Fret - fret) * 100,
+					String: b.String, Time: b.Time}
+				if a := ArticulationsOf(*last); a != 0 {
+					release = Articulate(release, a)
+				}
+				*last = release
+				continue
+			}
+			events = append(events, Note{Fret: fret, String: l.String, Time: time})
+		case LegatoVibrato:
+			*last = Articulate(*last, Vibrato)
+		}
+	}
+
+	end := l.EndTime()
+	for i := range events {
+		next := end
+		if i+1 < len(events) {
+			next = events[i+1].StartTime()
+		}
+		events[i] = withDuration(events[i], next-events[i].StartTime())
+	}
+	return events
+}
+
+// withDuration sets the duration of the Playables built by Events.
+func withDuration(p Playable, d float32) Playable {
+	switch n := p.(type) {
+	case Note:
+		n.Duration = d
+		return n
+	case HammerOn:
+		n.Duration = d
+		return n
+	case PullOff:
+		n.Duration = d
+		return n
+	case Slide:
+		n.Duration = d
+		return n
+	case Bend:
+		n.Duration = d
+		return n
+	case BendRelease:
+		n.Duration = d
+		return n
+	case Articulated:
+		n.Playable = withDuration(n.Playable, d)
+		return n
+	}
+	return p
+}
+
+// legatoChain gathers notes joined by hammer-ons, pull-offs and slides
+// on a string, written as one technique or as a Legato for longer runs.
+type legatoChain struct {
+	legato        Legato
+	end           float32
+	articulations Articulation
+}
+
+func (c *legatoChain) playable() Playable {
+	l := c.legato
+	d := c.end - l.Time
+	var p Playable
+	if len(l.Steps) == 1 {
+		s := l.Steps[0]
+		switch s.Kind {
+		case LegatoHammerOn:
+			p = HammerOn{FretFrom: l.Fret, FretTo: s.Fret, String: l.String, Time: l.Time, Duration: d}
+		case LegatoPullOff:
+			p = PullOff{FretFrom: l.Fret, FretTo: s.Fret, String: l.String, Time: l.Time, Duration: d}
+		case LegatoSlide:
+			p = Slide{FretStart: l.Fret, FretEnd: s.Fret, String: l.String, Time: l.Time, Duration: d}
+		}
+	}
+	if p == nil {
+		l.Duration = d
+		p = l
+	}
+	if c.articulations != 0 {
+		p = Articulate(p, c.articulations)
+	}
+	return p
+}
+
+// tabLinks collects the notes of a part read in time order, lengthening
+// tied notes and joining linked ones on each string.
+type tabLinks struct {
+	events  []Playable
+	last    map[int]int
+	ends    map[int]float32
+	pending map[int]LegatoKind
+	chains  map[int]*legatoChain
+}
+
+func newTabLinks() *tabLinks {
+	return &tabLinks{last: map[int]int{}, ends: map[int]float32{}, pending: map[int]LegatoKind{}, chains: map[int]*legatoChain{}}
+}
+
+// previous returns the event last played on a string if it ends at start.
+func (tl *tabLinks) previous(s int, start float32) (int, bool) {
+	i, ok := tl.last[s]
+	return i, ok && abs32(tl.ends[s]-start) < timeEpsilon
+}
+
+// lastFret returns the fret the string was left at.
+func (tl *tabLinks) lastFret(s int) int {
+	i, ok := tl.last[s]
+	if !ok {
+		return 0
+	}
+	if c, ok := tl.chains[i]; ok {
+		return c.legato.Steps[len(c.legato.Steps)-1].Fret
+	}
+	if n, ok := Unwrap(tl.events[i]).(Note); ok {
+		return n.Fret
+	}
+	return 0
+}
+
+// tie lengthens the note ending at start on a string until end.
+func (tl *tabLinks) tie(s int, start, end float32) bool {
+	i, ok := tl.previous(s, start)
+	if !ok {
+		return false
+	}
+	tl.ends[s] = end
+	if c, ok := tl.chains[i]; ok {
+		c.end = end
+		tl.events[i] = c.playable()
+	} else {
+		tl.events[i] = withDuration(tl.events[i], end-tl.events[i].StartTime())
+	}
+	return true
+}
+
+// pendingLink returns the link started by the note ending at start on a
+// string.
+func (tl *tabLinks) pendingLink(s int, start float32) (LegatoKind, bool) {
+	kind, ok := tl.pending[s]
+	if _, adjacent := tl.previous(s, start); !ok || !adjacent {
+		return 0, false
+	}
+	return kind, true
+}
+
+// link continues the note ending at start on a string to fret. Only a
+// plain note or a chain can be continued.
+func (tl *tabLinks) link(s int, kind LegatoKind, fret int, start, end float32) bool {
+	i, ok := tl.previous(s, start)
+	if !ok {
+		return false
+	}
+	c, chained := tl.chains[i]
+	if first, isNote := Unwrap(tl.events[i]).(Note); !chained && isNote {
+		c = &legatoChain{legato: NewLegato(first.Fret, s, first.Time), articulations: ArticulationsOf(tl.events[i])}
+		tl.chains[i], chained = c, true
+	}
+	if !chained {
+		return false
+	}
+
+	c.legato = c.legato.with(LegatoStep{Kind: kind, Fret: fret, Offset: start - c.legato.Time})
+	c.end = end
+	tl.events[i] = c.playable()
+	tl.ends[s] = end
+	delete(tl.pending, s)
+	return true
+}
+
+// add appends an event played on a string until end.
+func (tl *tabLinks) add(s int, p Playable, end float32) {
+	tl.last[s], tl.ends[s] = len(tl.events), end
+	delete(tl.pending, s)
+	tl.events = append(tl.events, p)
+}
+
+// startLink marks the last note on a string as linked to the next one.
+func (tl *tabLinks) startLink(s int, kind LegatoKind) {
+	tl.pending[s] = kind
+}
+
+// clear forgets the last note on a string, e.g. after a chord.
+func (tl *tabLinks) clear(s int) {
+	delete(tl.last, s)
+	delete(tl.ends, s)
+	delete(tl.pending, s)
+}
@@ -0,0 +1,112 @@
+package guitar
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLegatoTabSymbol(t *testing.T) {
+	testCases := []struct {
+		legato   Legato
+		expected string
+	}{
+		{legato: NewLegato(5, 1, 0).HammerOn(7, 0.1).PullOff(5, 0.2).HammerOn(8, 0.3), expected: "5h7p5h8"},
+		{legato: NewLegato(3, 1, 0).SlideTo(5, 0.1).HammerOn(7, 0.2).Vibrato(0.3), expected: "3/5h7~"},
+		{legato: NewLegato(9, 1, 0).SlideTo(7, 0.1).Bend(9, 0.2).Release(7, 0.4), expected: "9\\7b9r7"},
+		{legato: NewLegato(5, 1, 0), expected: "5"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expected, func(t *testing.T) {
+			assert.NoError(t, tc.legato.Validate())
+			assert.Equal(t, tc.expected, tc.legato.TabSymbol())
+		})
+	}
+}
+
+func TestLegatoValidate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		legato Legato
+	}{
+		{name: "hammer down", legato: NewLegato(5, 0, 0).HammerOn(3, 0.1)},
+		{name: "pull up", legato: NewLegato(5, 0, 0).PullOff(7, 0.1)},
+		{name: "release without bend", legato: NewLegato(5, 0, 0).Release(5, 0.1)},
+		{name: "hammer on bent string", legato: NewLegato(5, 0, 0).Bend(7, 0.1).HammerOn(9, 0.2)},
+		{name: "time order", legato: NewLegato(5, 0, 0).HammerOn(7, 0.2).PullOff(5, 0.1)},
+		{name: "negative fret", legato: NewLegato(-1, 0, 0)},
+		{name: "unknown kind", legato: Legato{Steps: []LegatoStep{{Kind: 42, Fret: 1}}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Error(t, tc.legato.Validate())
+		})
+	}
+}
+
+func TestLegatoEvents(t *testing.T) {
+	l := NewLegato(3, 1, 1).SlideTo(5, 0.25).HammerOn(7, 0.5).Bend(9, 0.75).Release(7, 1).Vibrato(1)
+	l.Duration = 1.5
+
+	assert.InDelta(t, 2.5, l.EndTime(), 1e-4)
+	assert.Equal(t, []Playable{
+		Note{Fret: 3, String: 1, Time: 1, Duration: 0.25},
+		Slide{FretStart: 3, FretEnd: 5, String: 1, Time: 1.25, Duration: 0.25},
+		HammerOn{FretFrom: 5, FretTo: 7, String: 1, Time: 1.5, Duration: 0.25},
+		Articulate(BendRelease{Fret: 7, Cents: FullStep, String: 1, Time: 1.75, Duration: 0.75}, Vibrato),
+	}, l.Events())
+}
+
+func TestLegatoEventsVibratoBeforeRelease(t *testing.T) {
+	l := NewLegato(7, 2, 0).Bend(9, 0.25).Vibrato(0.25).Release(7, 0.5)
+	l.Duration = 0.75
+
+	events := l.Events()
+	assert.Equal(t, Articulate(BendRelease{Fret: 7, Cents: FullStep, String: 2, Time: 0.25, Duration: 0.5}, Vibrato), events[1])
+}
+
+func TestLegatoInTab(t *testing.T) {
+	tb, _ := NewTabWriter([]string{"e", "B"})
+	_ = tb.WriteNotes(NewLegato(5, 0, 0).HammerOn(7, 0.1).PullOff(5, 0.2), Note{Fret: 3, String: 1, Time: 0.2})
+
+	assert.Equal(t, "e|5h7p5--\nB|-----3-\n", tb.Tab())
+}
+
+func TestLegatoParseTab(t *testing.T) {
+	tb, _ := NewTabWriter([]string{"e", "B"})
+	_ = tb.WriteNotes(NewLegato(5, 0, 0).HammerOn(7, 0.1).PullOff(5, 0.2), Note{Fret: 3, String: 1, Time: 0.2})
+	tun, _ := ParseTuning("E4 B3")
+
+	ps, err := ParseTab(strings.NewReader(tb.Tab()), tun)
+	assert.NoError(t, err)
+	assert.Equal(t, []Playable{
+		HammerOn{FretFrom: 5, FretTo: 7, String: 0, Time: 0},
+		PullOff{FretFrom: 7, FretTo: 5, String: 0, Time: 0.6},
+		Note{Name: "D", Octave: 4, Fret: 3, String: 1, Time: 1},
+	}, ps)
+}
+
+func TestLegatoEncoding(t *testing.T) {
+	ps := []Playable{NewLegato(5, 0, 1).HammerOn(7, 0.1).Vibrato(0.2)}
+
+	data, err := MarshalPlayables(ps)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"type":"legato","fret":5,"string":0,"time":1,`+
+		`"steps":[{"kind":"hammer_on","fret":7,"offset":0.1},{"kind":"vibrato","offset":0.2}]}]`, string(data))
+
+	decoded, err := UnmarshalPlayables(data)
+	assert.NoError(t, err)
+	assert.Equal(t, ps, decoded)
+
+	data, err = MarshalPlayablesYAML(ps)
+	assert.NoError(t, err)
+	decoded, err = UnmarshalPlayablesYAML(data)
+	assert.NoError(t, err)
+	assert.Equal(t, ps, decoded)
+
+	_, err = UnmarshalPlayables([]byte(`[{"type":"legato","steps":[{"kind":"wobble"}]}]`))
+	assert.Error(t, err)
+}
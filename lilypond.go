@@ -0,0 +1,422 @@
+package guitar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+const lilyPondVersion = "2.24.0"
+
+var (
+	lilySharpNames = []string{"c", "cis", "d", "dis", "e", "f", "fis", "g", "gis", "a", "ais", "b"}
+	lilyFlatNames  = []string{"c", "des", "d", "es", "e", "f", "ges", "g", "as", "a", "bes", "b"}
+
+	lilyDurations = map[string]string{
+		"whole": "1", "half": "2", "quarter": "4", "eighth": "8",
+		"16th": "16", "32nd": "32", "64th": "64",
+	}
+
+	// lilyChordSuffixes writes the suffix of a chord name in chord mode.
+	lilyChordSuffixes = map[string]string{
+		"": "", "m": ":m", "5": ":1.5", "6": ":6", "m6": ":m6", "6/9": ":6.9",
+		"7": ":7", "m7": ":m7", "maj7": ":maj7", "mmaj7": ":m7+", "m7b5": ":m7.5-",
+		"9": ":9", "m9": ":m9", "maj9": ":maj9", "add9": ":5.9", "11": ":11", "13": ":13",
+		"dim": ":dim", "dim7": ":dim7", "aug": ":aug",
+		"sus": ":sus4", "sus2": ":sus2", "sus4": ":sus4", "7sus4": ":7sus4",
+	}
+)
+
+// lilyChordName is a chord name lasting from start, in divisions.
+type lilyChordName struct {
+	start int
+	name  string
+}
+
+type lilyWriter struct {
+	fb    *FingerBoard
+	flats bool
+}
+
+// WriteLilyPond writes the song as a LilyPond score. Every track is a
+// notation staff at sounding pitch over a TabStaff tuned like the track,
+// its frets counted from the capo, with the names of its chords above.
+// Slides are written as glissandos, hammer-ons and pull-offs as slurs,
+// and bends as their fretted note.
+func (s *Song) WriteLilyPond(w io.Writer) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	timing := s.Timing
+	timing.Sort()
+
+	key, fifths := "", 0
+	if s.Key != "" {
+		f, minor, err := s.KeySignature()
+		if err != nil {
+			return err
+		}
+		mode, tonic := "major", f*7
+		if minor {
+			mode, tonic = "minor", tonic+9
+		}
+		names := lilySharpNames
+		if f < 0 {
+			names = lilyFlatNames
+		}
+		key, fifths = fmt.Sprintf("\\key %s \\%s", names[(tonic%12+12)%12], mode), f
+	}
+
+	end := 0
+	parts := [][]scoreNote{}
+	boards := []*FingerBoard{}
+	chords := [][]lilyChordName{}
+	for _, t := range s.Tracks {
+		fb, err := t.FingerBoard()
+		if err != nil {
+			return err
+		}
+		mw := musicXMLWriter{timing: timing, fb: fb}
+		notes, err := mw.scoreNotes(t.Events)
+		if err != nil {
+			return fmt.Errorf("track %q: %w", t.Name, err)
+		}
+		for _, n := range notes {
+			end = max(end, n.start+n.length)
+		}
+
+		names := []lilyChordName{}
+		for _, p := range t.Events {
+			if c, ok := Unwrap(p).(Chord); ok && c.Name != "" {
+				names = append(names, lilyChordName{start: mw.position(c.Time), name: c.Name})
+			}
+		}
+		sort.SliceStable(names, func(i, j int) bool { return names[i].start < names[j].start })
+
+		parts = append(parts, notes)
+		boards = append(boards, fb)
+		chords = append(chords, names)
+	}
+	measures := scoreMeasures(timing, end)
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "\\version %q\n\n", lilyPondVersion)
+	if s.Title != "" || s.Artist != "" {
+		bw.WriteString("\\header {\n")
+		if s.Title != "" {
+			fmt.Fprintf(bw, "  title = %q\n", s.Title)
+		}
+		if s.Artist != "" {
+			fmt.Fprintf(bw, "  composer = %q\n", s.Artist)
+		}
+		bw.WriteString("}\n\n")
+	}
+
+	bw.WriteString("global = {\n")
+	if key != "" {
+		fmt.Fprintf(bw, "  %s\n", key)
+	}
+	bw.WriteString("  " + lilyTempo(s.Tempo(0)) + "\n")
+	for _, m := range measures {
+		bw.WriteString("  " + lilyGlobalMeasure(timing, m) + " |\n")
+	}
+	bw.WriteString("}\n")
+
+	for i := range s.Tracks {
+		lw := lilyWriter{fb: boards[i], flats: fifths < 0}
+		fmt.Fprintf(bw, "\n%s = {\n", lilyVariable(i))
+		for _, m := range measures {
+			beats := layoutVoice(parts[i], m)
+			if len(beats) == 0 {
+				beats = restBeats(m.end - m.start)
+			}
+			symbols := []string{}
+			for _, b := range beats {
+				symbols = append(symbols, lw.beat(b))
+			}
+			bw.WriteString("  " + strings.Join(symbols, " ") + " |\n")
+		}
+		bw.WriteString("}\n")
+
+		if len(chords[i]) > 0 {
+			fmt.Fprintf(bw, "\n%sChords = \\chordmode {\n  \\set chordChanges = ##t\n", lilyVariable(i))
+			bw.WriteString("  " + lilyChordMode(chords[i], end) + "\n}\n")
+		}
+	}
+
+	bw.WriteString("\n\\score {\n  <<\n")
+	for i, t := range s.Tracks {
+		name := t.Name
+		if name == "" {
+			name = fmt.Sprintf("Guitar %d", i+1)
+		}
+		variable := lilyVariable(i)
+		if len(chords[i]) > 0 {
+			fmt.Fprintf(bw, "    \\new ChordNames \\%sChords\n", variable)
+		}
+		fmt.Fprintf(bw, "    \\new StaffGroup \\with { instrumentName = %q } <<\n", name)
+		fmt.Fprintf(bw, "      \\new Staff { \\clef \"treble_8\" << \\global \\%s >> }\n", variable)
+		fmt.Fprintf(bw, "      \\new TabStaff \\with { stringTunings = \\stringTuning %s } { << \\global \\%s >> }\n",
+			lilyTuning(boards[i].tuning), variable)
+		bw.WriteString("    >>\n")
+	}
+	bw.WriteString("  >>\n  \\layout { }\n}\n")
+	return bw.Flush()
+}
+
+// lilyVariable names the music of a track: LilyPond names are letters
+// only, so the first track is "guitarA" and the 27th "guitarAA".
+func lilyVariable(index int) string {
+	suffix := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		suffix = string(rune('A'+(index-1)%26)) + suffix
+	}
+	return "guitar" + suffix
+}
+
+// lilyTempo writes a metronome mark, LilyPond taking whole beats per
+// minute only.
+func lilyTempo(bpm float32) string {
+	return fmt.Sprintf("\\tempo 4 = %d", int(math.Round(float64(bpm))))
+}
+
+// lilyGlobalMeasure writes the time signature and the tempo changes of a
+// measure over skips.
+func lilyGlobalMeasure(timing Timing, m scoreMeasure) string {
+	symbols := []string{}
+	if m.changed {
+		symbols = append(symbols, fmt.Sprintf("\\time %d/%d", m.signature.Beats, m.signature.BeatUnit))
+	}
+
+	pos := m.start
+	for _, c := range timing.Tempos {
+		at := divisionsAt(timing, c.Time)
+		if at <= 0 || at < m.start || at >= m.end {
+			continue
+		}
+		symbols = append(symbols, lilySkips(at-pos)...)
+		symbols = append(symbols, lilyTempo(c.BPM))
+		pos = at
+	}
+	return strings.Join(append(symbols, lilySkips(m.end-pos)...), " ")
+}
+
+func lilySkips(length int) []string {
+	skips := []string{}
+	for _, value := range noteValues(length) {
+		skips = append(skips, "s"+lilyDuration(value))
+	}
+	return skips
+}
+
+func lilyDuration(value noteValue) string {
+	return lilyDurations[value.name] + strings.Repeat(".", value.dots)
+}
+
+// beat writes a note, a chord or a rest with the string numbers and the
+// marks of its notes.
+func (lw *lilyWriter) beat(b scoreBeat) string {
+	duration := lilyDuration(b.value)
+	if len(b.pieces) == 0 {
+		return "r" + duration
+	}
+
+	// harmonics touched at the same fret are written as the stopped notes
+	prefix, byFret := "", true
+	for _, p := range b.pieces {
+		if p.note.harmonic == "" || p.note.harmonicFret != b.pieces[0].note.harmonicFret {
+			byFret = false
+		}
+	}
+	if byFret {
+		prefix = fmt.Sprintf("\\harmonicByFret #%d ", b.pieces[0].note.harmonicFret)
+	}
+
+	notes := []string{}
+	post := ""
+	opens, closes, glissando := false, false, false
+	for _, p := range b.pieces {
+		n := p.note
+		head := b.firstValue && p.firstPiece
+		tail := b.lastValue && p.lastOne
+
+		pitch := n.pitch
+		switch {
+		case byFret && n.harmonic == "natural":
+			pitch, _ = lw.fb.tuning.NoteAt(n.stringNumber, 0)
+		case byFret:
+			pitch, _ = lw.fb.tuning.NoteAt(n.stringNumber, n.fret)
+		}
+
+		note := lw.pitch(pitch)
+		switch {
+		case n.dead || n.articulations.Has(Muted):
+			note = "\\tweak style #'cross " + note
+		case n.articulations.Has(Ghost):
+			note = "\\parenthesize " + note
+		}
+		if n.harmonic != "" && !byFret {
+			note += "\\harmonic"
+		}
+		note += fmt.Sprintf("\\%d", n.stringNumber+1)
+		if !b.lastValue || p.tieStart {
+			note += "~"
+		}
+		notes = append(notes, note)
+
+		if head && lilySlurred(n.linkFrom) && !lilySlurred(n.linkTo) {
+			closes = true
+		}
+		if tail && lilySlurred(n.linkTo) && !lilySlurred(n.linkFrom) {
+			opens = true
+		}
+		if tail && n.linkTo == linkSlide {
+			glissando = true
+		}
+		if !head || post != "" {
+			continue
+		}
+		post = lilyMarks(n, b.pieces[0].note)
+	}
+
+	text := "<" + strings.Join(notes, " ") + ">" + duration
+	if b.pieces[0].note.tremolo > 0 {
+		text += fmt.Sprintf(":%d", 4<<b.pieces[0].note.tremolo)
+	}
+	if closes {
+		text += ")"
+	}
+	if opens {
+		text += "("
+	}
+	if glissando {
+		text += "\\glissando"
+	}
+	return prefix + text + post
+}
+
+// lilyMarks writes the articulations and techniques of a note, and the
+// arpeggio of the chord it starts.
+func lilyMarks(n, first *scoreNote) string {
+	marks := ""
+	if n.articulations.Has(Accent) {
+		marks += "->"
+	}
+	if n.articulations.Has(Staccato) {
+		marks += "-."
+	}
+	if n.articulations.Has(PalmMute) {
+		marks += "^\"P.M.\""
+	}
+	if n.tap {
+		marks += "^\"T\""
+	}
+	if n.trill {
+		marks += "\\trill"
+	}
+	if first.arpeggiate != "" {
+		marks += "\\arpeggio"
+	}
+	return marks
+}
+
+func lilySlurred(link string) bool {
+	return link == linkHammerOn || link == linkPullOff
+}
+
+// pitch writes a note at its octave: C4 is "c'", C3 "c" and C2 "c,".
+func (lw *lilyWriter) pitch(n Note) string {
+	key, err := midiPitch(n)
+	if err != nil {
+		return "c"
+	}
+	return lilyPitch(key, lw.flats)
+}
+
+func lilyPitch(key int, flats bool) string {
+	name := lilySharpNames[key%12]
+	if flats {
+		name = lilyFlatNames[key%12]
+	}
+	octave := key/12 - 4
+	if octave > 0 {
+		return name + strings.Repeat("'", octave)
+	}
+	return name + strings.Repeat(",", -octave)
+}
+
+// lilyTuning writes a tuning from the lowest string, as stringTunings
+// wants it.
+func lilyTuning(tuning Tuning) string {
+	notes := []string{}
+	for i := len(tuning) - 1; i >= 0; i-- {
+		key, err := midiPitch(tuning[i])
+		if err != nil {
+			continue
+		}
+		notes = append(notes, lilyPitch(key, false))
+	}
+	return "<" + strings.Join(notes, " ") + ">"
+}
+
+// lilyChordMode writes chord names in chord mode, each one lasting until
+// the next and the last one until end. Names chord mode can not spell
+// are printed as they are over their root.
+func lilyChordMode(names []lilyChordName, end int) string {
+	symbols := lilySkips(names[0].start)
+	for i, c := range names {
+		next := end
+		if i+1 < len(names) {
+			next = names[i+1].start
+		}
+		if next <= c.start {
+			continue
+		}
+
+		root, suffix := lilyChord(c.name)
+		for _, value := range noteValues(next - c.start) {
+			symbols = append(symbols, root+lilyDuration(value)+suffix)
+		}
+	}
+	return strings.Join(symbols, " ")
+}
+
+// lilyChord splits a chord name into its root and its suffix in chord
+// mode, the duration going between them.
+func lilyChord(name string) (string, string) {
+	m := chordNamePattern.FindStringSubmatch(name)
+	if m == nil {
+		return fmt.Sprintf("\\once \\override ChordName.text = %q c", name), ""
+	}
+
+	root := lilyNoteName(m[1])
+	suffix, ok := lilyChordSuffixes[m[2]]
+	if !ok {
+		return fmt.Sprintf("\\once \\override ChordName.text = %q %s", name, root), ""
+	}
+	if m[3] != "" {
+		suffix += "/" + lilyNoteName(m[3])
+	}
+	return root, suffix
+}
+
+// lilyNoteName writes a note name such as "F#" or "Bb" in LilyPond.
+func lilyNoteName(name string) string {
+	name = accidentalSigns.Replace(name)
+	letter := strings.ToLower(name[:1])
+	switch name[1:] {
+	case "#":
+		return letter + "is"
+	case "b":
+		switch letter {
+		case "a", "e":
+			return letter + "s"
+		}
+		return letter + "es"
+	}
+	return letter
+}
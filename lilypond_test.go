@@ -0,0 +1,131 @@
+package guitar
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteLilyPond(t *testing.T) {
+	s := testSong(t)
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, s.WriteLilyPond(&buf))
+	// the tab is tuned two frets up for the capo
+	assert.Equal(t, `\version "2.24.0"
+
+\header {
+  title = "Etude"
+  composer = "Anon"
+}
+
+global = {
+  \key a \minor
+  \tempo 4 = 120
+  \time 2/4 s2 |
+  s2 |
+  \tempo 4 = 60 s2 |
+}
+
+guitarA = {
+  <a'\1~>2 |
+  <a'\1>8 <fis'\2>4 r8 |
+  r8 <e'\3>4 r8 |
+}
+
+\score {
+  <<
+    \new StaffGroup \with { instrumentName = "Guitar" } <<
+      \new Staff { \clef "treble_8" << \global \guitarA >> }
+      \new TabStaff \with { stringTunings = \stringTuning <fis, b, e a cis' fis'> } { << \global \guitarA >> }
+    >>
+  >>
+  \layout { }
+}
+`, buf.String())
+}
+
+func TestWriteLilyPondTechniques(t *testing.T) {
+	tun, _ := ParseTuning(DropD)
+	s := NewSong("", "")
+	s.Key = "F"
+	tr, _ := NewTrack("Lead", tun, 24)
+	tr.Add(
+		NewChord("Dm7", []int{1, 1, 2, 0, ChordMuted, ChordMuted}, 0),
+		HammerOn{FretFrom: 5, FretTo: 7, String: 0, Time: 0.5, Duration: 0.5},
+		Slide{FretStart: 7, FretEnd: 9, String: 1, Time: 1, Duration: 0.5},
+		Harmonic{Fret: 12, String: 5, Time: 1.5, Duration: 0.5},
+		Articulate(Note{Fret: 3, String: 2, Time: 2, Duration: 0.25}, Accent|PalmMute),
+		DeadNote{String: 3, Time: 2.25, Duration: 0.25},
+		NewChord("Cadd11", []int{0, 1, 0, 2, 3, ChordMuted}, 2.5),
+	)
+	s.AddTrack(tr)
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, s.WriteLilyPond(&buf))
+	ly := buf.String()
+
+	assert.NotContains(t, ly, `\header`)
+	assert.Contains(t, ly, `\key f \major`)
+	// muted strings of a chord are cross noteheads, the hammer-on is a
+	// slur and the harmonic is touched over the open string
+	assert.Contains(t, ly, `  <f'\1 c'\2 a\3 d\4 \tweak style #'cross a,\5 \tweak style #'cross d,\6>4 `+
+		`<a'\1>8( <b'\1>8) <ges'\2>8\glissando <as'\2>8 \harmonicByFret #12 <d,\6>4 |`)
+	assert.Contains(t, ly, `  <bes\3>8->^"P.M." <\tweak style #'cross d\4>8 <e'\1 c'\2 g\3 e\4 c\5 \tweak style #'cross d,\6>4 r2 |`)
+	// chord mode has no add11, the name is printed as it is
+	assert.Contains(t, ly, `  d1:m7 d4:m7 \once \override ChordName.text = "Cadd11" c4`)
+	assert.Contains(t, ly, `    \new ChordNames \guitarAChords
+    \new StaffGroup \with { instrumentName = "Lead" } <<`)
+	assert.Contains(t, ly, `\stringTuning <d, a, d g b e'>`)
+}
+
+func TestWriteLilyPondErrors(t *testing.T) {
+	tun, _ := ParseTuning(StandardTuning)
+
+	s := NewSong("", "")
+	s.Key = "H"
+	assert.Error(t, s.WriteLilyPond(&bytes.Buffer{}))
+
+	s = NewSong("", "")
+	tr, _ := NewTrack("", tun, 24)
+	tr.Add(Note{Fret: 0, String: 7})
+	s.AddTrack(tr)
+	assert.Error(t, s.WriteLilyPond(&bytes.Buffer{}))
+}
+
+func TestWriteLilyPondFractionalTempo(t *testing.T) {
+	s := NewSong("", "")
+	s.SetTempo(0, 92.5)
+	s.SetTempo(60/92.5*2, 100.4)
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, s.WriteLilyPond(&buf))
+	assert.Contains(t, buf.String(), "  \\tempo 4 = 93\n  \\time 4/4 s2 \\tempo 4 = 100 s2 |\n")
+}
+
+func TestLilyChord(t *testing.T) {
+	testCases := []struct {
+		name, root, suffix string
+	}{
+		{name: "C", root: "c"},
+		{name: "F#m7", root: "fis", suffix: ":m7"},
+		{name: "Bb/D", root: "bes", suffix: "/d"},
+		{name: "Ebmaj7", root: "es", suffix: ":maj7"},
+		{name: "Ab6/9", root: "as", suffix: ":6.9"},
+		{name: "G7sus4", root: "g", suffix: ":7sus4"},
+		{name: "N.C.", root: `\once \override ChordName.text = "N.C." c`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root, suffix := lilyChord(tc.name)
+			assert.Equal(t, tc.root, root)
+			assert.Equal(t, tc.suffix, suffix)
+		})
+	}
+
+	assert.Equal(t, "guitarA", lilyVariable(0))
+	assert.Equal(t, "guitarZ", lilyVariable(25))
+	assert.Equal(t, "guitarAA", lilyVariable(26))
+}
@@ -0,0 +1,144 @@
+package guitar
+
+import "fmt"
+
+type TimeSignature struct {
+	Beats    int `json:"beats" yaml:"beats"`
+	BeatUnit int `json:"beat_unit" yaml:"beat_unit"`
+}
+
+func (ts TimeSignature) Validate() error {
+	if ts.Beats <= 0 {
+		return fmt.Errorf("invalid time signature beats: %d", ts.Beats)
+	}
+
+	switch ts.BeatUnit {
+	case 1, 2, 4, 8, 16, 32:
+		return nil
+	default:
+		return fmt.Errorf("invalid time signature beat unit: %d", ts.BeatUnit)
+	}
+}
+
+// MeasureDuration returns the length of one measure in seconds at the
+// given tempo in quarter notes per minute.
+func (ts TimeSignature) MeasureDuration(bpm float32) float32 {
+	quarters := float32(ts.Beats) * 4 / float32(ts.BeatUnit)
+	return quarters * 60 / bpm
+}
+
+func (ts TimeSignature) String() string {
+	return fmt.Sprintf("%d/%d", ts.Beats, ts.BeatUnit)
+}
+
+type BarLine int
+
+const (
+	SingleBar BarLine = iota
+	DoubleBar
+	RepeatStart
+	RepeatEnd
+	// RepeatEndStart closes one repeat and opens the next one.
+	RepeatEndStart
+)
+
+func (b BarLine) Symbol() string {
+	switch b {
+	case DoubleBar:
+		return "||"
+	case RepeatStart:
+		return "|:"
+	case RepeatEnd:
+		return ":|"
+	case RepeatEndStart:
+		return ":|:"
+	default:
+		return "|"
+	}
+}
+
+// merge returns the bar line written when b and other fall at the same time.
+func (b BarLine) merge(other BarLine) BarLine {
+	switch {
+	case b == SingleBar:
+		return other
+	case other == SingleBar:
+		return b
+	case b == RepeatEnd && other == RepeatStart:
+		return RepeatEndStart
+	default:
+		return other
+	}
+}
+
+type BarSplitPolicy int
+
+const (
+	// BarSplitNone leaves notes ringing across the bar line untouched.
+	BarSplitNone BarSplitPolicy = iota
+	// BarSplitTie cuts the note at the bar line and writes the remainder
+	// as a tied note "(5)" at the start of the next measure.
+	BarSplitTie
+	// BarSplitTruncate cuts the note at the bar line and drops the remainder.
+	BarSplitTruncate
+)
+
+func crossesTime(p Playable, t float32) bool {
+	s, ok := p.(Sustained)
+	return ok && s.EndTime() > t+timeEpsilon
+}
+
+// splitPlayable cuts p at time t. The head keeps the original technique,
+// the tail is the plain note left ringing on the fret p ends on. A
+// harmonic rings on as a harmonic. Other techniques are not split.
+func splitPlayable(p Playable, t float32) (Playable, Playable, bool) {
+	var end float32
+	tail := Note{String: p.StringNumber(), Time: t}
+
+	switch n := p.(type) {
+	case Note:
+		end = n.EndTime()
+		n.Duration = t - n.Time
+		tail.Name, tail.Octave, tail.Fret = n.Name, n.Octave, n.Fret
+		p = n
+	case Slide:
+		end = n.EndTime()
+		n.Duration = t - n.Time
+		tail.Fret = n.FretEnd
+		p = n
+	case HammerOn:
+		end = n.EndTime()
+		n.Duration = t - n.Time
+		tail.Fret = n.FretTo
+		p = n
+	case PullOff:
+		end = n.EndTime()
+		n.Duration = t - n.Time
+		tail.Fret = n.FretTo
+		p = n
+	case Harmonic:
+		rest := Harmonic{Fret: n.Fret, String: n.String, Time: t, Duration: n.EndTime() - t}
+		n.Duration = t - n.Time
+		return n, rest, true
+	case Articulated:
+		// the articulations stay with the attack
+		head, rest, ok := splitPlayable(n.Playable, t)
+		if !ok {
+			return p, nil, false
+		}
+		n.Playable = head
+		return n, rest, true
+	default:
+		return p, nil, false
+	}
+
+	tail.Duration = end - t
+	return p, tail, true
+}
+
+func abs32(x float32) float32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
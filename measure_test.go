@@ -0,0 +1,42 @@
+package guitar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMeasureDuration(t *testing.T) {
+	testCases := []struct {
+		name      string
+		signature TimeSignature
+		bpm       float32
+		expected  float32
+	}{
+		{name: "4/4 at 120", signature: TimeSignature{4, 4}, bpm: 120, expected: 2},
+		{name: "3/4 at 60", signature: TimeSignature{3, 4}, bpm: 60, expected: 3},
+		{name: "6/8 at 120", signature: TimeSignature{6, 8}, bpm: 120, expected: 1.5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.InDelta(t, tc.expected, tc.signature.MeasureDuration(tc.bpm), 1e-6)
+		})
+	}
+}
+
+func TestSplitPlayable(t *testing.T) {
+	head, tail, ok := splitPlayable(Slide{FretStart: 5, FretEnd: 7, String: 2, Time: 1, Duration: 2}, 2)
+
+	assert.True(t, ok)
+	assert.Equal(t, Slide{FretStart: 5, FretEnd: 7, String: 2, Time: 1, Duration: 1}, head)
+	assert.Equal(t, Note{Fret: 7, String: 2, Time: 2, Duration: 1}, tail)
+
+	head, tail, ok = splitPlayable(Harmonic{Fret: 12, String: 1, Time: 1, Duration: 2}, 2.5)
+	assert.True(t, ok)
+	assert.Equal(t, Harmonic{Fret: 12, String: 1, Time: 1, Duration: 1.5}, head)
+	assert.Equal(t, Harmonic{Fret: 12, String: 1, Time: 2.5, Duration: 0.5}, tail)
+
+	_, _, ok = splitPlayable(Tap{Fret: 12, Time: 1, Duration: 2}, 2)
+	assert.False(t, ok)
+}
@@ -0,0 +1,750 @@
+package guitar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+const (
+	DefaultPPQ            = 480
+	DefaultMIDIProgram    = 25 // acoustic guitar (steel), counted from 0
+	DefaultBendRange      = 12 // semitones
+	defaultMIDIVelocity   = 96
+	midiDrumChannel       = 9
+	midiChannels          = 16
+	midiPitchBendCenter   = 8192
+	midiBendSampleTime    = 0.02 // seconds between pitch bend events
+	midiVibratoRate       = 5.5  // Hz
+	midiVibratoDepth      = 25   // cents
+	midiWideVibratoDepth  = 50   // cents
+	midiDeadNoteLength    = 0.05 // seconds
+	midiPalmMuteMaxLength = 0.15 // seconds
+)
+
+type MIDIOption func(*midiExporter)
+
+// WithMIDIFormat writes a format 0 file, every event in one track, or a
+// format 1 file, the tempo map in the first track followed by a track
+// per instrument. The default is 1.
+func WithMIDIFormat(format int) MIDIOption {
+	return func(e *midiExporter) {
+		e.format = format
+	}
+}
+
+// WithPPQ sets the ticks per quarter note.
+func WithPPQ(ppq int) MIDIOption {
+	return func(e *midiExporter) {
+		e.ppq = ppq
+	}
+}
+
+// WithMIDITiming sets the tempo map and time signatures of Playables
+// written by WriteMIDI. Songs use their own timing.
+func WithMIDITiming(timing Timing) MIDIOption {
+	return func(e *midiExporter) {
+		e.timing = timing
+	}
+}
+
+// WithMIDIProgram sets the General MIDI program, counted from 0.
+func WithMIDIProgram(program int) MIDIOption {
+	return func(e *midiExporter) {
+		e.program = program
+	}
+}
+
+// WithBendRange sets the pitch bend range in semitones written to every
+// channel. Slides wider than the range are played as two notes.
+func WithBendRange(semitones int) MIDIOption {
+	return func(e *midiExporter) {
+		e.bendRange = semitones
+	}
+}
+
+// WithChannelPerString puts every string on its own channel, so that a
+// bend moves only the string that is bent. It is on by default. Channels
+// are not shared, so a file then holds two six string tracks at most.
+func WithChannelPerString(enabled bool) MIDIOption {
+	return func(e *midiExporter) {
+		e.channelPerString = enabled
+	}
+}
+
+type midiExporter struct {
+	format           int
+	ppq              int
+	timing           Timing
+	program          int
+	bendRange        int
+	channelPerString bool
+
+	nextChannel int
+}
+
+func newMIDIExporter(opts []MIDIOption) (*midiExporter, error) {
+	e := &midiExporter{
+		format:           1,
+		ppq:              DefaultPPQ,
+		program:          DefaultMIDIProgram,
+		bendRange:        DefaultBendRange,
+		channelPerString: true,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	if e.format != 0 && e.format != 1 {
+		return nil, fmt.Errorf("unsupported MIDI format %d", e.format)
+	}
+	if e.ppq <= 0 || e.ppq > 0x7fff {
+		return nil, fmt.Errorf("invalid PPQ %d", e.ppq)
+	}
+	if e.program < 0 || e.program > 127 {
+		return nil, fmt.Errorf("invalid MIDI program %d", e.program)
+	}
+	if e.bendRange <= 0 || e.bendRange > 24 {
+		return nil, fmt.Errorf("invalid pitch bend range %d", e.bendRange)
+	}
+	if err := e.timing.Validate(); err != nil {
+		return nil, err
+	}
+	e.timing.Sort()
+	return e, nil
+}
+
+// WriteMIDI writes Playables on an instrument tuned to tuning as a
+// Standard MIDI File.
+func WriteMIDI(w io.Writer, tuning Tuning, ps []Playable, opts ...MIDIOption) error {
+	e, err := newMIDIExporter(opts)
+	if err != nil {
+		return err
+	}
+
+	fb, err := NewFingerBoard(tuning, longNeckFrets)
+	if err != nil {
+		return err
+	}
+	events, err := e.trackEvents(fb, ps)
+	if err != nil {
+		return err
+	}
+
+	return e.write(w, "", []midiTrack{{events: events}})
+}
+
+// WriteMIDI writes the song as a Standard MIDI File with its tempo map,
+// time signatures and a track per guitar track.
+func (s *Song) WriteMIDI(w io.Writer, opts ...MIDIOption) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	e, err := newMIDIExporter(opts)
+	if err != nil {
+		return err
+	}
+	e.timing = Timing{
+		Tempos:         append([]TempoChange(nil), s.Tempos...),
+		TimeSignatures: append([]TimeSignatureChange(nil), s.TimeSignatures...),
+	}
+	e.timing.Sort()
+
+	tracks := []midiTrack{}
+	for _, t := range s.Tracks {
+		fb, err := t.FingerBoard()
+		if err != nil {
+			return err
+		}
+
+		events, err := e.trackEvents(fb, t.Events)
+		if err != nil {
+			return fmt.Errorf("track %q: %w", t.Name, err)
+		}
+		tracks = append(tracks, midiTrack{name: t.Name, events: events})
+	}
+
+	return e.write(w, s.Title, tracks)
+}
+
+type midiTrack struct {
+	name   string
+	events []midiEvent
+}
+
+// midiEvent is an event at an absolute tick. Events at the same tick are
+// written in priority order: meta events, note offs, controllers and
+// pitch bends, then note ons.
+type midiEvent struct {
+	tick     int
+	priority int
+	data     []byte
+}
+
+const (
+	priorityMeta = iota
+	priorityNoteOff
+	priorityControl
+	priorityNoteOn
+)
+
+// midiNote is a sounding note on one string. Bend returns the cents the
+// note is bent at a time, nil when it is not bent.
+type midiNote struct {
+	start, end float32
+	string     int
+	pitch      int
+	velocity   int
+	bend       func(t float32) float32
+	vibrato    float32
+}
+
+func (e *midiExporter) tick(t float32) int {
+	return int(math.Round(float64(e.timing.Beats(0, t, DefaultTempo) * float32(e.ppq))))
+}
+
+// beat is the length of a quarter note at time, used for Playables
+// without a duration.
+func (e *midiExporter) beat(t float32) float32 {
+	return 60 / e.timing.TempoAt(t, DefaultTempo)
+}
+
+func (e *midiExporter) trackEvents(fb *FingerBoard, ps []Playable) ([]midiEvent, error) {
+	notes := []midiNote{}
+	for _, p := range ps {
+		n, err := e.notes(fb, p)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, n...)
+	}
+
+	// a string sounds one note at a time
+	sort.SliceStable(notes, func(i, j int) bool { return notes[i].start < notes[j].start })
+	last := map[int]int{}
+	for i := range notes {
+		if j, ok := last[notes[i].string]; ok && notes[j].end > notes[i].start {
+			notes[j].end = notes[i].start
+		}
+		last[notes[i].string] = i
+	}
+
+	channels, err := e.channels(len(fb.tuning))
+	if err != nil {
+		return nil, err
+	}
+
+	events := []midiEvent{}
+	for _, ch := range uniqueInts(channels) {
+		events = append(events, e.channelSetup(ch)...)
+	}
+	for _, n := range notes {
+		if n.end <= n.start {
+			continue
+		}
+		events = append(events, e.noteEvents(n, channels[n.string])...)
+	}
+	return events, nil
+}
+
+// channels assigns a channel to every string, skipping the drum channel.
+func (e *midiExporter) channels(strings int) ([]int, error) {
+	channels := make([]int, strings)
+	if !e.channelPerString {
+		ch, err := e.allocChannel()
+		if err != nil {
+			return nil, err
+		}
+		for i := range channels {
+			channels[i] = ch
+		}
+		return channels, nil
+	}
+	for i := range channels {
+		ch, err := e.allocChannel()
+		if err != nil {
+			return nil, fmt.Errorf("%w, a channel per string needs %d", err, strings)
+		}
+		channels[i] = ch
+	}
+	return channels, nil
+}
+
+// allocChannel returns the next free channel. Channels are never shared,
+// so a file holds 15 melodic channels at most.
+func (e *midiExporter) allocChannel() (int, error) {
+	if e.nextChannel == midiDrumChannel {
+		e.nextChannel++
+	}
+	if e.nextChannel >= midiChannels {
+		return 0, errors.New("all 15 melodic MIDI channels are used")
+	}
+	e.nextChannel++
+	return e.nextChannel - 1, nil
+}
+
+func uniqueInts(values []int) []int {
+	seen := map[int]bool{}
+	result := []int{}
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// channelSetup selects the program and sets the pitch bend range with
+// registered parameter 0.
+func (e *midiExporter) channelSetup(ch int) []midiEvent {
+	status := byte(0xB0 | ch)
+	return []midiEvent{
+		{0, priorityControl, []byte{byte(0xC0 | ch), byte(e.program)}},
+		{0, priorityControl, []byte{status, 101, 0}},
+		{0, priorityControl, []byte{status, 100, 0}},
+		{0, priorityControl, []byte{status, 6, byte(e.bendRange)}},
+		{0, priorityControl, []byte{status, 38, 0}},
+		{0, priorityControl, []byte{status, 101, 127}},
+		{0, priorityControl, []byte{status, 100, 127}},
+	}
+}
+
+func (e *midiExporter) noteEvents(n midiNote, ch int) []midiEvent {
+	start, end := e.tick(n.start), e.tick(n.end)
+	if end <= start {
+		end = start + 1
+	}
+
+	events := []midiEvent{
+		{start, priorityNoteOn, []byte{byte(0x90 | ch), byte(n.pitch), byte(n.velocity)}},
+		{end, priorityNoteOff, []byte{byte(0x80 | ch), byte(n.pitch), 0}},
+	}
+
+	if n.bend == nil && n.vibrato == 0 {
+		return events
+	}
+
+	last := -1
+	for t := n.start; t < n.end; t += midiBendSampleTime {
+		var cents float32
+		if n.bend != nil {
+			cents = n.bend(t)
+		}
+		if n.vibrato != 0 {
+			cents += n.vibrato * float32(math.Sin(2*math.Pi*midiVibratoRate*float64(t-n.start)))
+		}
+
+		value := e.pitchBendValue(cents)
+		if value == last {
+			continue
+		}
+		last = value
+		events = append(events, midiEvent{e.tick(t), priorityControl, pitchBendEvent(ch, value)})
+	}
+	events = append(events, midiEvent{end, priorityNoteOff + 1, pitchBendEvent(ch, midiPitchBendCenter)})
+	return events
+}
+
+func (e *midiExporter) pitchBendValue(cents float32) int {
+	value := midiPitchBendCenter + int(math.Round(float64(cents/float32(e.bendRange*100)*8191)))
+	return min(max(value, 0), 16383)
+}
+
+func pitchBendEvent(ch, value int) []byte {
+	return []byte{byte(0xE0 | ch), byte(value & 0x7f), byte(value >> 7)}
+}
+
+// midiPitch returns the MIDI key of a Note, 60 being C4.
+func midiPitch(n Note) (int, error) {
+	if err := n.Validate(); err != nil {
+		return 0, err
+	}
+	for i, name := range notesChromo {
+		if name == n.Name {
+			pitch := (n.Octave+1)*12 + i
+			if pitch < 0 || pitch > 127 {
+				return 0, fmt.Errorf("note %s%d is out of the MIDI range", n.Name, n.Octave)
+			}
+			return pitch, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid note name: %s", n.Name)
+}
+
+// pitchNote returns the name and octave of a MIDI key.
+func pitchNote(pitch int) (string, int) {
+	return notesChromo[(pitch%12+12)%12], pitch/12 - 1
+}
+
+func (e *midiExporter) fretPitch(fb *FingerBoard, stringNumber, fret int) (int, error) {
+	note, err := fb.tuning.NoteAt(stringNumber, fret)
+	if err != nil {
+		return 0, err
+	}
+	return midiPitch(note)
+}
+
+func (e *midiExporter) length(p Playable) float32 {
+	if s, ok := p.(Sustained); ok && s.EndTime() > p.StartTime() {
+		return s.EndTime() - p.StartTime()
+	}
+	return e.beat(p.StartTime())
+}
+
+// notes turns a Playable into the notes it sounds. Legato techniques
+// glide with pitch bends when the interval fits the bend range and play
+// a second, softer note otherwise.
+func (e *midiExporter) notes(fb *FingerBoard, p Playable) ([]midiNote, error) {
+	articulations := ArticulationsOf(p)
+	inner := Unwrap(p)
+
+	start, length := p.StartTime(), e.length(p)
+	base := midiNote{start: start, end: start + length, string: p.StringNumber(), velocity: defaultMIDIVelocity}
+
+	notes := []midiNote{}
+	switch n := inner.(type) {
+	case Chord:
+		return e.expand(fb, n.Notes(), articulations)
+	case Tremolo:
+		return e.expand(fb, n.Notes(e.timing.TempoAt(start, DefaultTempo)), articulations)
+	case Trill:
+		// every stroke sounds once, on the fret it lands on
+		strokes := n.Notes(e.timing.TempoAt(start, DefaultTempo))
+		for i, s := range strokes {
+			switch s := s.(type) {
+			case HammerOn:
+				strokes[i] = Note{Fret: s.FretTo, String: s.String, Time: s.Time, Duration: s.Duration}
+			case PullOff:
+				strokes[i] = Note{Fret: s.FretTo, String: s.String, Time: s.Time, Duration: s.Duration}
+			}
+		}
+		return e.expand(fb, strokes, articulations)
+	case Legato:
+		return e.legato(fb, n, articulations)
+
+	case DeadNote:
+		pitch, err := e.fretPitch(fb, n.String, 0)
+		if err != nil {
+			return nil, err
+		}
+		base.pitch, base.end, base.velocity = pitch, start+min(length, midiDeadNoteLength), defaultMIDIVelocity/2
+		return []midiNote{base}, nil
+
+	case Slide:
+		from := n.FretStart
+		if from < 0 {
+			// slide in from two frets below
+			from = max(0, n.FretEnd-2)
+		}
+		return e.glide(fb, base, from, n.FretEnd, articulations)
+
+	case HammerOn:
+		return e.twoNotes(fb, base, n.FretFrom, n.FretTo, articulations)
+	case PullOff:
+		return e.twoNotes(fb, base, n.FretFrom, n.FretTo, articulations)
+
+	case Bender:
+		if err := validateBender(n); err != nil {
+			return nil, err
+		}
+		pitch, err := e.fretPitch(fb, n.StringNumber(), n.BendFret())
+		if err != nil {
+			return nil, err
+		}
+		curve := n.BendCurve()
+		base.pitch = pitch
+		base.bend = func(t float32) float32 { return curve.At((t - start) / length) }
+		notes = append(notes, base)
+
+	default:
+		note, err := fb.SoundingNote(inner)
+		if err != nil {
+			return nil, err
+		}
+		pitch, err := midiPitch(note)
+		if err != nil {
+			return nil, err
+		}
+		base.pitch = pitch
+		notes = append(notes, base)
+	}
+
+	for i := range notes {
+		notes[i] = articulate(notes[i], articulations)
+	}
+	return notes, nil
+}
+
+func (e *midiExporter) expand(fb *FingerBoard, ps []Playable, articulations Articulation) ([]midiNote, error) {
+	notes := []midiNote{}
+	for _, p := range ps {
+		if articulations != 0 {
+			p = Articulate(p, articulations)
+		}
+		n, err := e.notes(fb, p)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, n...)
+	}
+	return notes, nil
+}
+
+// glide plays from one fret to another, reaching it halfway.
+func (e *midiExporter) glide(fb *FingerBoard, base midiNote, from, to int, articulations Articulation) ([]midiNote, error) {
+	if abs(to-from) > e.bendRange {
+		return e.twoNotes(fb, base, from, to, articulations)
+	}
+
+	pitch, err := e.fretPitch(fb, base.string, from)
+	if err != nil {
+		return nil, err
+	}
+
+	start, half := base.start, (base.end-base.start)/2
+	cents := float32((to - from) * 100)
+	base.pitch = pitch
+	base.bend = func(t float32) float32 { return cents * min(1, (t-start)/half) }
+	return []midiNote{articulate(base, articulations)}, nil
+}
+
+// twoNotes plays the first fret for half the length and the second,
+// softer, for the rest.
+func (e *midiExporter) twoNotes(fb *FingerBoard, base midiNote, from, to int, articulations Articulation) ([]midiNote, error) {
+	first, err := e.fretPitch(fb, base.string, from)
+	if err != nil {
+		return nil, err
+	}
+	second, err := e.fretPitch(fb, base.string, to)
+	if err != nil {
+		return nil, err
+	}
+
+	mid := base.start + (base.end-base.start)/2
+	a, b := base, base
+	a.pitch, a.end = first, mid
+	b.pitch, b.start, b.velocity = second, mid, base.velocity*3/4
+	return []midiNote{articulate(a, articulations), articulate(b, articulations&^(Accent|Ghost))}, nil
+}
+
+// legato plays the picked note and a softer note per hammer-on, pull-off
+// or wide slide. Bends and vibrato bend the note sounding.
+func (e *midiExporter) legato(fb *FingerBoard, l Legato, articulations Articulation) ([]midiNote, error) {
+	notes := []midiNote{}
+	for i, ev := range l.Events() {
+		a := ArticulationsOf(ev)
+		ev = Unwrap(ev)
+
+		base := midiNote{start: ev.StartTime(), end: ev.StartTime() + e.length(ev), string: l.String, velocity: defaultMIDIVelocity}
+		if i > 0 {
+			base.velocity = defaultMIDIVelocity * 3 / 4
+		}
+
+		var n []midiNote
+		var err error
+		switch ev := ev.(type) {
+		case HammerOn:
+			n, err = e.glideOrNote(fb, base, ev.FretTo, ev.FretTo)
+		case PullOff:
+			n, err = e.glideOrNote(fb, base, ev.FretTo, ev.FretTo)
+		case Slide:
+			n, err = e.glideOrNote(fb, base, ev.FretStart, ev.FretEnd)
+		case Bender:
+			if len(notes) > 0 {
+				// the bent note keeps ringing, bent on top of the glides
+				// and bends before
+				last := &notes[len(notes)-1]
+				curve, start, length := ev.BendCurve(), ev.StartTime(), e.length(ev)
+				previous, offset := last.bend, float32(0)
+				if previous != nil {
+					offset = previous(start)
+				}
+				last.end = start + length
+				last.bend = func(t float32) float32 {
+					if t < start {
+						if previous != nil {
+							return previous(t)
+						}
+						return 0
+					}
+					return offset + curve.At((t-start)/length)
+				}
+				if a.Has(WideVibrato) || a.Has(Vibrato) {
+					// the vibrato of an earlier step is kept otherwise
+					*last = articulate(*last, a)
+				}
+				continue
+			}
+			n, err = e.notes(fb, ev)
+		default:
+			n, err = e.notes(fb, ev)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for j := range n {
+			n[j] = articulate(n[j], a|articulations)
+		}
+		notes = append(notes, n...)
+	}
+	return notes, nil
+}
+
+func (e *midiExporter) glideOrNote(fb *FingerBoard, base midiNote, from, to int) ([]midiNote, error) {
+	if from == to {
+		pitch, err := e.fretPitch(fb, base.string, to)
+		if err != nil {
+			return nil, err
+		}
+		base.pitch = pitch
+		return []midiNote{base}, nil
+	}
+	return e.glide(fb, base, from, to, 0)
+}
+
+// articulate applies the articulations that change how a note sounds.
+func articulate(n midiNote, a Articulation) midiNote {
+	switch {
+	case a.Has(Ghost):
+		n.velocity = defaultMIDIVelocity * 2 / 5
+	case a.Has(Accent):
+		n.velocity = 120
+	}
+	if a.Has(PalmMute) {
+		n.velocity = n.velocity * 4 / 5
+		n.end = min(n.end, n.start+midiPalmMuteMaxLength)
+	}
+	if a.Has(Staccato) {
+		n.end = n.start + (n.end-n.start)/2
+	}
+	if a.Has(Muted) {
+		n.velocity = defaultMIDIVelocity / 2
+		n.end = min(n.end, n.start+midiDeadNoteLength)
+	}
+	switch {
+	case a.Has(WideVibrato):
+		n.vibrato = midiWideVibratoDepth
+	case a.Has(Vibrato):
+		n.vibrato = midiVibratoDepth
+	}
+	return n
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// conductorEvents are the tempo and time signature meta events.
+func (e *midiExporter) conductorEvents() []midiEvent {
+	events := []midiEvent{}
+
+	tempos := e.timing.Tempos
+	if len(tempos) == 0 || tempos[0].Time > timeEpsilon {
+		tempos = append([]TempoChange{{BPM: DefaultTempo}}, tempos...)
+	}
+	for _, c := range tempos {
+		us := int(math.Round(60e6 / float64(c.BPM)))
+		events = append(events, midiEvent{e.tick(c.Time), priorityMeta,
+			[]byte{0xFF, 0x51, 3, byte(us >> 16), byte(us >> 8), byte(us)}})
+	}
+
+	for _, c := range e.timing.TimeSignatures {
+		unit := byte(math.Round(math.Log2(float64(c.Signature.BeatUnit))))
+		events = append(events, midiEvent{e.tick(c.Time), priorityMeta,
+			[]byte{0xFF, 0x58, 4, byte(c.Signature.Beats), unit, 24, 8}})
+	}
+	return events
+}
+
+func trackNameEvent(name string) midiEvent {
+	data := append([]byte{0xFF, 0x03}, varLen(len(name))...)
+	return midiEvent{0, priorityMeta, append(data, name...)}
+}
+
+func (e *midiExporter) write(w io.Writer, title string, tracks []midiTrack) error {
+	chunks := [][]byte{}
+
+	if e.format == 0 {
+		events := e.conductorEvents()
+		if title != "" {
+			events = append(events, trackNameEvent(title))
+		}
+		for _, t := range tracks {
+			events = append(events, t.events...)
+		}
+		chunks = append(chunks, encodeTrack(events))
+	} else {
+		conductor := e.conductorEvents()
+		if title != "" {
+			conductor = append(conductor, trackNameEvent(title))
+		}
+		chunks = append(chunks, encodeTrack(conductor))
+		for _, t := range tracks {
+			events := t.events
+			if t.name != "" {
+				events = append([]midiEvent{trackNameEvent(t.name)}, events...)
+			}
+			chunks = append(chunks, encodeTrack(events))
+		}
+	}
+
+	if len(chunks) > 0xffff {
+		return errors.New("too many MIDI tracks")
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteString("MThd")
+	_ = binary.Write(&buf, binary.BigEndian, uint32(6))
+	_ = binary.Write(&buf, binary.BigEndian, uint16(e.format))
+	_ = binary.Write(&buf, binary.BigEndian, uint16(len(chunks)))
+	_ = binary.Write(&buf, binary.BigEndian, uint16(e.ppq))
+
+	for _, chunk := range chunks {
+		buf.WriteString("MTrk")
+		_ = binary.Write(&buf, binary.BigEndian, uint32(len(chunk)))
+		buf.Write(chunk)
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// encodeTrack writes events in time order with delta times and closes
+// the track.
+func encodeTrack(events []midiEvent) []byte {
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].tick != events[j].tick {
+			return events[i].tick < events[j].tick
+		}
+		return events[i].priority < events[j].priority
+	})
+
+	buf := bytes.Buffer{}
+	tick := 0
+	for _, ev := range events {
+		buf.Write(varLen(ev.tick - tick))
+		buf.Write(ev.data)
+		tick = ev.tick
+	}
+	buf.Write([]byte{0, 0xFF, 0x2F, 0})
+	return buf.Bytes()
+}
+
+// varLen encodes a MIDI variable length quantity.
+func varLen(v int) []byte {
+	result := []byte{byte(v & 0x7f)}
+	for v >>= 7; v > 0; v >>= 7 {
+		result = append([]byte{byte(v&0x7f) | 0x80}, result...)
+	}
+	return result
+}
@@ -0,0 +1,333 @@
+package guitar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testMIDIFile struct {
+	format, ppq int
+	tracks      [][]midiEvent
+}
+
+// readTestMIDI splits a file written by WriteMIDI into absolute time events.
+func readTestMIDI(t *testing.T, data []byte) testMIDIFile {
+	t.Helper()
+
+	assert.Equal(t, "MThd", string(data[:4]))
+	f := testMIDIFile{
+		format: int(binary.BigEndian.Uint16(data[8:])),
+		ppq:    int(binary.BigEndian.Uint16(data[12:])),
+	}
+	count := int(binary.BigEndian.Uint16(data[10:]))
+	data = data[14:]
+
+	for range count {
+		assert.Equal(t, "MTrk", string(data[:4]))
+		length := int(binary.BigEndian.Uint32(data[4:]))
+		chunk := data[8 : 8+length]
+		data = data[8+length:]
+
+		events := []midiEvent{}
+		tick := 0
+		for len(chunk) > 0 {
+			delta := 0
+			for {
+				b := chunk[0]
+				chunk = chunk[1:]
+				delta = delta<<7 | int(b&0x7f)
+				if b&0x80 == 0 {
+					break
+				}
+			}
+			tick += delta
+
+			size := 3
+			switch {
+			case chunk[0] == 0xFF:
+				size = 3 + int(chunk[2])
+			case chunk[0]&0xF0 == 0xC0 || chunk[0]&0xF0 == 0xD0:
+				size = 2
+			}
+			events = append(events, midiEvent{tick: tick, data: chunk[:size]})
+			chunk = chunk[size:]
+		}
+		f.tracks = append(f.tracks, events)
+	}
+	return f
+}
+
+// filterMIDI keeps the events whose status has the given high nibble.
+func filterMIDI(events []midiEvent, kind byte) []midiEvent {
+	result := []midiEvent{}
+	for _, e := range events {
+		if e.data[0]&0xF0 == kind && e.data[0] != 0xFF {
+			result = append(result, midiEvent{tick: e.tick, data: e.data})
+		}
+	}
+	return result
+}
+
+func writeTestMIDI(t *testing.T, ps []Playable, opts ...MIDIOption) testMIDIFile {
+	t.Helper()
+
+	tun, _ := ParseTuning(StandardTuning)
+	buf := bytes.Buffer{}
+	assert.NoError(t, WriteMIDI(&buf, tun, ps, opts...))
+	return readTestMIDI(t, buf.Bytes())
+}
+
+func TestVarLen(t *testing.T) {
+	testCases := []struct {
+		value    int
+		expected []byte
+	}{
+		{value: 0, expected: []byte{0}},
+		{value: 0x7f, expected: []byte{0x7f}},
+		{value: 0x80, expected: []byte{0x81, 0}},
+		{value: 480, expected: []byte{0x83, 0x60}},
+		{value: 0x1fffff, expected: []byte{0xff, 0xff, 0x7f}},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, varLen(tc.value))
+	}
+}
+
+func TestMIDIPitch(t *testing.T) {
+	testCases := []struct {
+		note     Note
+		expected int
+	}{
+		{note: Note{Name: "C", Octave: 4}, expected: 60},
+		{note: Note{Name: "E", Octave: 2}, expected: 40},
+		{note: Note{Name: "Bb", Octave: 3}, expected: 58},
+		{note: Note{Name: "A", Octave: -1}, expected: 9},
+	}
+
+	for _, tc := range testCases {
+		pitch, err := midiPitch(tc.note)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, pitch)
+	}
+
+	_, err := midiPitch(Note{Name: "C", Octave: 10})
+	assert.Error(t, err)
+}
+
+func TestWriteMIDINotes(t *testing.T) {
+	f := writeTestMIDI(t, []Playable{
+		Note{Fret: 0, String: 0, Time: 0, Duration: 0.5},
+		Note{Fret: 3, String: 5, Time: 0.5, Duration: 0.25},
+	})
+
+	assert.Equal(t, 1, f.format)
+	assert.Equal(t, DefaultPPQ, f.ppq)
+	assert.Len(t, f.tracks, 2)
+
+	// 120 bpm
+	assert.Equal(t, []byte{0xFF, 0x51, 3, 0x07, 0xA1, 0x20}, f.tracks[0][0].data)
+
+	on := filterMIDI(f.tracks[1], 0x90)
+	off := filterMIDI(f.tracks[1], 0x80)
+	assert.Equal(t, []midiEvent{
+		{tick: 0, data: []byte{0x90, 64, defaultMIDIVelocity}},
+		{tick: 480, data: []byte{0x95, 43, defaultMIDIVelocity}},
+	}, on)
+	assert.Equal(t, []midiEvent{
+		{tick: 480, data: []byte{0x80, 64, 0}},
+		{tick: 720, data: []byte{0x85, 43, 0}},
+	}, off)
+
+	// program and a 12 semitone bend range on every string channel
+	assert.Len(t, filterMIDI(f.tracks[1], 0xC0), 6)
+	assert.Contains(t, f.tracks[1], midiEvent{tick: 0, data: []byte{0xB5, 6, DefaultBendRange}})
+}
+
+func TestWriteMIDIFormat0(t *testing.T) {
+	f := writeTestMIDI(t, []Playable{Note{Fret: 2, String: 3, Time: 0.5}},
+		WithMIDIFormat(0), WithPPQ(96), WithChannelPerString(false),
+		WithMIDITiming(Timing{
+			Tempos:         []TempoChange{{Time: 0, BPM: 60}},
+			TimeSignatures: []TimeSignatureChange{{Time: 0, Signature: TimeSignature{Beats: 6, BeatUnit: 8}}},
+		}))
+
+	assert.Equal(t, 0, f.format)
+	assert.Len(t, f.tracks, 1)
+	assert.Contains(t, f.tracks[0], midiEvent{tick: 0, data: []byte{0xFF, 0x58, 4, 6, 3, 24, 8}})
+	// a note without duration lasts a beat
+	assert.Equal(t, []midiEvent{{tick: 48, data: []byte{0x90, 52, defaultMIDIVelocity}}}, filterMIDI(f.tracks[0], 0x90))
+	assert.Equal(t, []midiEvent{{tick: 144, data: []byte{0x80, 52, 0}}}, filterMIDI(f.tracks[0], 0x80))
+	assert.Len(t, filterMIDI(f.tracks[0], 0xC0), 1)
+}
+
+func TestWriteMIDIStringIsMonophonic(t *testing.T) {
+	f := writeTestMIDI(t, []Playable{
+		Note{Fret: 5, String: 1, Time: 0, Duration: 1},
+		Note{Fret: 7, String: 1, Time: 0.25, Duration: 0.25},
+	})
+
+	assert.Equal(t, []midiEvent{
+		{tick: 240, data: []byte{0x81, 64, 0}},
+		{tick: 480, data: []byte{0x81, 66, 0}},
+	}, filterMIDI(f.tracks[1], 0x80))
+}
+
+func TestWriteMIDITechniques(t *testing.T) {
+	testCases := []struct {
+		name    string
+		p       Playable
+		notes   []byte
+		bends   bool
+		topBend int
+	}{
+		{name: "hammer-on", p: HammerOn{FretFrom: 5, FretTo: 7, String: 0, Duration: 0.5}, notes: []byte{69, 71}},
+		{name: "pull-off", p: PullOff{FretFrom: 7, FretTo: 5, String: 0, Duration: 0.5}, notes: []byte{71, 69}},
+		{name: "slide", p: Slide{FretStart: 5, FretEnd: 7, String: 0, Duration: 0.5}, notes: []byte{69}, bends: true, topBend: 8192 + 1365},
+		{name: "wide slide", p: Slide{FretStart: 2, FretEnd: 17, String: 0, Duration: 0.5}, notes: []byte{66, 81}},
+		{name: "slide in", p: Slide{FretStart: -1, FretEnd: 7, String: 0, Duration: 0.5}, notes: []byte{69}, bends: true, topBend: 8192 + 1365},
+		{name: "harmonic", p: Harmonic{Fret: 12, String: 5, Duration: 0.5}, notes: []byte{52}},
+		{name: "bend", p: Bend{Fret: 7, Cents: FullStep, String: 2, Duration: 0.5}, notes: []byte{62}, bends: true, topBend: 8192 + 1365},
+		{name: "tap", p: Tap{Fret: 12, String: 0, Duration: 0.5}, notes: []byte{76}},
+		{name: "chord", p: NewChord("E5", []int{ChordSkip, ChordSkip, ChordSkip, ChordSkip, 2, 0}, 0), notes: []byte{47, 40}},
+		{name: "legato", p: NewLegato(5, 0, 0).HammerOn(7, 0.25).PullOff(5, 0.5), notes: []byte{69, 71, 69}},
+		{name: "legato bend", p: NewLegato(7, 2, 0).Bend(9, 0.2).Release(7, 0.4), notes: []byte{62}, bends: true, topBend: 8192 + 1365},
+		{name: "vibrato", p: Articulate(Note{Fret: 5, String: 0, Duration: 0.5}, Vibrato), notes: []byte{69}, bends: true, topBend: 8192 + 170},
+		{name: "trill", p: Trill{Fret: 5, TrillFret: 7, Division: 16, String: 0, Duration: 0.5}, notes: []byte{69, 71, 69, 71}},
+		{name: "tremolo", p: Tremolo{Fret: 5, Division: 16, String: 0, Duration: 0.5}, notes: []byte{69, 69, 69, 69}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := writeTestMIDI(t, []Playable{tc.p})
+
+			pitches := []byte{}
+			for _, e := range filterMIDI(f.tracks[1], 0x90) {
+				pitches = append(pitches, e.data[1])
+			}
+			assert.Equal(t, tc.notes, pitches)
+
+			bends := filterMIDI(f.tracks[1], 0xE0)
+			if !tc.bends {
+				assert.Empty(t, bends)
+				return
+			}
+
+			top := 0
+			for _, e := range bends {
+				top = max(top, int(e.data[1])|int(e.data[2])<<7)
+			}
+			assert.InDelta(t, tc.topBend, top, 2)
+			last := bends[len(bends)-1].data
+			assert.Equal(t, midiPitchBendCenter, int(last[1])|int(last[2])<<7)
+		})
+	}
+}
+
+func TestMIDILegatoBendAfterSlide(t *testing.T) {
+	tun, _ := ParseTuning(StandardTuning)
+	fb, _ := NewFingerBoard(tun, 24)
+	e, err := newMIDIExporter(nil)
+	assert.NoError(t, err)
+
+	l := NewLegato(5, 2, 0).SlideTo(7, 0.5).Bend(9, 1.0)
+	l.Duration = 1.5
+	notes, err := e.legato(fb, l, 0)
+	assert.NoError(t, err)
+
+	// the slide glides from fret 5 to 7, the bend goes on from there to 9
+	assert.Len(t, notes, 2)
+	slide := notes[1]
+	assert.Equal(t, 60, slide.pitch)
+	assert.InDelta(t, 100, slide.bend(0.625), 1e-3)
+	assert.InDelta(t, 200, slide.bend(0.8), 1e-3)
+	assert.InDelta(t, 400, slide.bend(1.5), 1e-3)
+
+	// a release keeps the vibrato of the bend
+	l = NewLegato(7, 2, 0).Bend(9, 0.2).Vibrato(0.2).Release(7, 0.4)
+	notes, err = e.legato(fb, l, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, float32(midiVibratoDepth), notes[len(notes)-1].vibrato)
+}
+
+func TestWriteMIDIArticulations(t *testing.T) {
+	f := writeTestMIDI(t, []Playable{
+		Articulate(Note{Fret: 0, String: 0, Time: 0, Duration: 1}, Accent, Staccato),
+		Articulate(Note{Fret: 0, String: 1, Time: 0, Duration: 1}, Ghost, PalmMute),
+	})
+
+	assert.Equal(t, []midiEvent{
+		{tick: 0, data: []byte{0x90, 64, 120}},
+		{tick: 0, data: []byte{0x91, 59, 30}},
+	}, filterMIDI(f.tracks[1], 0x90))
+	assert.Equal(t, []midiEvent{
+		{tick: 144, data: []byte{0x81, 59, 0}},
+		{tick: 480, data: []byte{0x80, 64, 0}},
+	}, filterMIDI(f.tracks[1], 0x80))
+}
+
+func TestSongWriteMIDI(t *testing.T) {
+	tun, _ := ParseTuning(StandardTuning)
+	s := NewSong("Song", "Artist")
+	s.SetTempo(0, 120)
+	s.SetTempo(1, 60)
+
+	lead, _ := NewTrack("Lead", tun, 24)
+	lead.Capo = 2
+	lead.Add(Note{Fret: 0, String: 0, Time: 1, Duration: 1})
+	rhythm, _ := NewTrack("Rhythm", tun, 0)
+	rhythm.Add(Note{Fret: 0, String: 5, Time: 0, Duration: 1})
+	s.AddTrack(lead)
+	s.AddTrack(rhythm)
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, s.WriteMIDI(&buf))
+	f := readTestMIDI(t, buf.Bytes())
+
+	assert.Len(t, f.tracks, 3)
+	assert.Contains(t, f.tracks[0], midiEvent{tick: 0, data: append([]byte{0xFF, 0x03, 4}, "Song"...)})
+	assert.Contains(t, f.tracks[0], midiEvent{tick: 960, data: []byte{0xFF, 0x51, 3, 0x0F, 0x42, 0x40}})
+	assert.Contains(t, f.tracks[1], midiEvent{tick: 0, data: append([]byte{0xFF, 0x03, 4}, "Lead"...)})
+
+	// the capo raises the lead, whose tempo halves after a second
+	assert.Equal(t, []midiEvent{{tick: 960, data: []byte{0x90, 66, defaultMIDIVelocity}}}, filterMIDI(f.tracks[1], 0x90))
+	assert.Equal(t, []midiEvent{{tick: 1440, data: []byte{0x80, 66, 0}}}, filterMIDI(f.tracks[1], 0x80))
+
+	// the rhythm strings get the channels after the lead, skipping drums
+	assert.Equal(t, []midiEvent{{tick: 0, data: []byte{0x9C, 40, defaultMIDIVelocity}}}, filterMIDI(f.tracks[2], 0x90))
+}
+
+func TestWriteMIDIErrors(t *testing.T) {
+	tun, _ := ParseTuning(StandardTuning)
+
+	testCases := []struct {
+		name string
+		ps   []Playable
+		opts []MIDIOption
+	}{
+		{name: "format", opts: []MIDIOption{WithMIDIFormat(2)}},
+		{name: "ppq", opts: []MIDIOption{WithPPQ(0)}},
+		{name: "bend range", opts: []MIDIOption{WithBendRange(0)}},
+		{name: "program", opts: []MIDIOption{WithMIDIProgram(128)}},
+		{name: "string", ps: []Playable{Note{Fret: 0, String: 6}}},
+		{name: "fret", ps: []Playable{Note{Fret: -2, String: 0}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Error(t, WriteMIDI(&bytes.Buffer{}, tun, tc.ps, tc.opts...))
+		})
+	}
+
+	// three tracks of six strings need more channels than a file has
+	s := NewSong("", "")
+	for range 3 {
+		tr, _ := NewTrack("", tun, 24)
+		s.AddTrack(tr)
+	}
+	assert.ErrorContains(t, s.WriteMIDI(&bytes.Buffer{}), "channels are used")
+	assert.NoError(t, s.WriteMIDI(&bytes.Buffer{}, WithChannelPerString(false)))
+}
@@ -0,0 +1,389 @@
+package guitar
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// DefaultQuantize snaps imported notes to sixteenth notes.
+const DefaultQuantize = 16
+
+type MIDIReadOption func(*midiReader)
+
+// WithMIDITrack reads the track at index, counted from 0 in the file.
+// By default the first track with notes is read.
+func WithMIDITrack(index int) MIDIReadOption {
+	return func(r *midiReader) {
+		r.track = index
+	}
+}
+
+// WithMIDIChannel reads only the notes of a channel, counted from 0. By
+// default every channel but the drum channel is read.
+func WithMIDIChannel(channel int) MIDIReadOption {
+	return func(r *midiReader) {
+		r.channel = channel
+	}
+}
+
+// WithQuantize snaps note starts and lengths to a grid of division notes
+// per whole note, 16 for sixteenths. 0 keeps the file timing.
+func WithQuantize(division int) MIDIReadOption {
+	return func(r *midiReader) {
+		r.quantize = division
+	}
+}
+
+type midiReader struct {
+	track    int
+	channel  int
+	quantize int
+
+	ppq        int
+	tempos     []midiTempo
+	signatures []midiSignature
+}
+
+// midiTempo is a tempo change at a tick, in microseconds per quarter note.
+type midiTempo struct {
+	tick int
+	us   int
+}
+
+type midiSignature struct {
+	tick      int
+	signature TimeSignature
+}
+
+type midiFileTrack struct {
+	name   string
+	events []midiEvent
+}
+
+// midiKey is a note read from a track, in ticks.
+type midiKey struct {
+	start, end int
+	pitch      int
+}
+
+// ReadMIDI reads a track of a Standard MIDI File into a Song with the
+// tempo map and time signatures of the file. Notes are fingered on fb,
+// each one close to the previous, so song.Tab(0) writes the part as tab.
+func ReadMIDI(r io.Reader, fb *FingerBoard, opts ...MIDIReadOption) (*Song, error) {
+	if fb == nil || len(fb.tuning) == 0 {
+		return nil, errors.New("empty tuning")
+	}
+
+	mr := &midiReader{track: -1, channel: -1, quantize: DefaultQuantize}
+	for _, opt := range opts {
+		opt(mr)
+	}
+	if mr.channel < -1 || mr.channel >= midiChannels {
+		return nil, fmt.Errorf("invalid MIDI channel %d", mr.channel)
+	}
+	if mr.quantize < 0 {
+		return nil, fmt.Errorf("invalid quantize division %d", mr.quantize)
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	format, tracks, err := mr.parseFile(data)
+	if err != nil {
+		return nil, err
+	}
+
+	song := &Song{}
+	if format == 1 && len(tracks) > 1 {
+		song.Title = tracks[0].name
+	}
+	for _, t := range tracks {
+		mr.readTiming(t.events)
+	}
+	song.Timing = mr.timing()
+
+	index := mr.track
+	if index < 0 {
+		for i, t := range tracks {
+			if len(mr.keys(t.events)) > 0 {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, errors.New("no notes in MIDI file")
+		}
+	}
+	if index >= len(tracks) {
+		return nil, fmt.Errorf("invalid track index %d, file has %d tracks", index, len(tracks))
+	}
+
+	notes, err := fb.finger(mr.fingerKeys(mr.keys(tracks[index].events)))
+	if err != nil {
+		return nil, err
+	}
+
+	track, err := NewTrack(tracks[index].name, fb.tuning, fb.frets)
+	if err != nil {
+		return nil, err
+	}
+	track.Add(notes...)
+	song.AddTrack(track)
+	return song, nil
+}
+
+func (mr *midiReader) parseFile(data []byte) (int, []midiFileTrack, error) {
+	if len(data) < 14 || string(data[:4]) != "MThd" {
+		return 0, nil, errors.New("not a MIDI file")
+	}
+	size := int(binary.BigEndian.Uint32(data[4:]))
+	if size < 6 || len(data) < 8+size {
+		return 0, nil, errors.New("invalid MIDI header")
+	}
+
+	format := int(binary.BigEndian.Uint16(data[8:]))
+	count := int(binary.BigEndian.Uint16(data[10:]))
+	division := int(binary.BigEndian.Uint16(data[12:]))
+	if format > 1 {
+		return 0, nil, fmt.Errorf("unsupported MIDI format %d", format)
+	}
+	if division&0x8000 != 0 || division == 0 {
+		return 0, nil, errors.New("unsupported SMPTE time division")
+	}
+	mr.ppq = division
+
+	tracks := []midiFileTrack{}
+	data = data[8+size:]
+	for len(tracks) < count {
+		if len(data) < 8 {
+			return 0, nil, fmt.Errorf("track %d: truncated chunk", len(tracks))
+		}
+		size := int(binary.BigEndian.Uint32(data[4:]))
+		if len(data) < 8+size {
+			return 0, nil, fmt.Errorf("track %d: truncated chunk", len(tracks))
+		}
+		chunk := data[8 : 8+size]
+		kind := string(data[:4])
+		data = data[8+size:]
+
+		// unknown chunks are skipped
+		if kind != "MTrk" {
+			continue
+		}
+		t, err := parseTrack(chunk)
+		if err != nil {
+			return 0, nil, fmt.Errorf("track %d: %w", len(tracks), err)
+		}
+		tracks = append(tracks, t)
+	}
+	return format, tracks, nil
+}
+
+// parseTrack reads the events of a track chunk with absolute ticks,
+// expanding running status.
+func parseTrack(chunk []byte) (midiFileTrack, error) {
+	t := midiFileTrack{}
+	tick, status := 0, byte(0)
+
+	for pos := 0; pos < len(chunk); {
+		delta, n, err := readVarLen(chunk[pos:])
+		if err != nil {
+			return t, err
+		}
+		tick += delta
+		pos += n
+
+		if pos >= len(chunk) {
+			return t, errors.New("truncated event")
+		}
+		if chunk[pos]&0x80 != 0 {
+			status = chunk[pos]
+			pos++
+		} else if status == 0 {
+			return t, errors.New("data byte without status")
+		}
+
+		switch {
+		case status == 0xFF:
+			if pos >= len(chunk) {
+				return t, errors.New("truncated meta event")
+			}
+			kind := chunk[pos]
+			length, n, err := readVarLen(chunk[pos+1:])
+			if err != nil {
+				return t, err
+			}
+			start := pos + 1 + n
+			if start+length > len(chunk) {
+				return t, errors.New("truncated meta event")
+			}
+			body := chunk[start : start+length]
+			if kind == 0x03 && t.name == "" {
+				t.name = string(body)
+			}
+			t.events = append(t.events, midiEvent{tick: tick, data: append([]byte{0xFF, kind}, body...)})
+			pos = start + length
+			status = 0
+		case status == 0xF0 || status == 0xF7:
+			length, n, err := readVarLen(chunk[pos:])
+			if err != nil {
+				return t, err
+			}
+			pos += n + length
+			status = 0
+		case status > 0xF0:
+			return t, fmt.Errorf("unsupported status %#x", status)
+		default:
+			size := 2
+			if status&0xF0 == 0xC0 || status&0xF0 == 0xD0 {
+				size = 1
+			}
+			if pos+size > len(chunk) {
+				return t, errors.New("truncated channel event")
+			}
+			t.events = append(t.events, midiEvent{tick: tick, data: append([]byte{status}, chunk[pos:pos+size]...)})
+			pos += size
+		}
+	}
+	return t, nil
+}
+
+func readVarLen(data []byte) (int, int, error) {
+	v := 0
+	for i := 0; i < len(data) && i < 4; i++ {
+		v = v<<7 | int(data[i]&0x7f)
+		if data[i]&0x80 == 0 {
+			return v, i + 1, nil
+		}
+	}
+	return 0, 0, errors.New("invalid variable length quantity")
+}
+
+// readTiming collects the tempo and time signature meta events of a
+// track. Meta event bodies follow their two status bytes.
+func (mr *midiReader) readTiming(events []midiEvent) {
+	for _, e := range events {
+		if e.data[0] != 0xFF {
+			continue
+		}
+		body := e.data[2:]
+		switch {
+		case e.data[1] == 0x51 && len(body) == 3:
+			us := int(body[0])<<16 | int(body[1])<<8 | int(body[2])
+			if us > 0 {
+				mr.tempos = append(mr.tempos, midiTempo{tick: e.tick, us: us})
+			}
+		case e.data[1] == 0x58 && len(body) >= 2 && body[1] < 8:
+			ts := TimeSignature{Beats: int(body[0]), BeatUnit: 1 << body[1]}
+			if ts.Validate() == nil {
+				mr.signatures = append(mr.signatures, midiSignature{tick: e.tick, signature: ts})
+			}
+		}
+	}
+}
+
+// timing converts the changes read to seconds.
+func (mr *midiReader) timing() Timing {
+	sort.SliceStable(mr.tempos, func(i, j int) bool { return mr.tempos[i].tick < mr.tempos[j].tick })
+	sort.SliceStable(mr.signatures, func(i, j int) bool { return mr.signatures[i].tick < mr.signatures[j].tick })
+
+	timing := Timing{}
+	for _, t := range mr.tempos {
+		bpm := float32(math.Round(60e6/float64(t.us)*100) / 100)
+		timing.Tempos = append(timing.Tempos, TempoChange{Time: float32(mr.seconds(t.tick)), BPM: bpm})
+	}
+	for _, ts := range mr.signatures {
+		timing.TimeSignatures = append(timing.TimeSignatures, TimeSignatureChange{Time: float32(mr.seconds(ts.tick)), Signature: ts.signature})
+	}
+	return timing
+}
+
+// seconds converts a tick to seconds following the tempo changes.
+func (mr *midiReader) seconds(tick int) float64 {
+	var seconds float64
+	last, us := 0, 60e6/float64(DefaultTempo)
+	for _, t := range mr.tempos {
+		if t.tick >= tick {
+			break
+		}
+		seconds += float64(t.tick-last) * us / 1e6 / float64(mr.ppq)
+		last, us = t.tick, float64(t.us)
+	}
+	seconds += float64(tick-last) * us / 1e6 / float64(mr.ppq)
+	return seconds
+}
+
+// keys pairs note ons and offs of the channels read, first on with first
+// off, and quantizes them.
+func (mr *midiReader) keys(events []midiEvent) []midiKey {
+	keys := []midiKey{}
+	open := map[[2]int][]int{}
+
+	for _, e := range events {
+		kind, ch := e.data[0]&0xF0, int(e.data[0]&0x0F)
+		if e.data[0] == 0xFF || (kind != 0x90 && kind != 0x80) {
+			continue
+		}
+		if mr.channel >= 0 && ch != mr.channel || mr.channel < 0 && ch == midiDrumChannel {
+			continue
+		}
+
+		id := [2]int{ch, int(e.data[1])}
+		if kind == 0x90 && e.data[2] > 0 {
+			open[id] = append(open[id], len(keys))
+			keys = append(keys, midiKey{start: e.tick, end: -1, pitch: int(e.data[1])})
+			continue
+		}
+		if len(open[id]) > 0 {
+			keys[open[id][0]].end = e.tick
+			open[id] = open[id][1:]
+		}
+	}
+
+	result := []midiKey{}
+	for _, k := range keys {
+		if k.end < 0 {
+			// never released
+			continue
+		}
+		result = append(result, mr.quantizeKey(k))
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].start != result[j].start {
+			return result[i].start < result[j].start
+		}
+		return result[i].pitch > result[j].pitch
+	})
+	return result
+}
+
+func (mr *midiReader) quantizeKey(k midiKey) midiKey {
+	if mr.quantize == 0 {
+		return k
+	}
+	grid := max(1, mr.ppq*4/mr.quantize)
+	snap := func(tick int) int {
+		return int(math.Round(float64(tick)/float64(grid))) * grid
+	}
+	start, end := snap(k.start), snap(k.end)
+	if end <= start {
+		end = start + grid
+	}
+	k.start, k.end = start, end
+	return k
+}
+
+// fingerKeys converts the keys read to seconds.
+func (mr *midiReader) fingerKeys(keys []midiKey) []fingerKey {
+	result := make([]fingerKey, len(keys))
+	for i, k := range keys {
+		start := mr.seconds(k.start)
+		result[i] = fingerKey{start: float32(start), length: float32(mr.seconds(k.end) - start), pitch: k.pitch}
+	}
+	return result
+}
@@ -0,0 +1,163 @@
+package guitar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testSMF builds a format 1 file of raw track chunks.
+func testSMF(ppq int, tracks ...[]byte) []byte {
+	buf := bytes.Buffer{}
+	buf.WriteString("MThd")
+	_ = binary.Write(&buf, binary.BigEndian, []uint32{6})
+	_ = binary.Write(&buf, binary.BigEndian, []uint16{1, uint16(len(tracks)), uint16(ppq)})
+	for _, t := range tracks {
+		buf.WriteString("MTrk")
+		_ = binary.Write(&buf, binary.BigEndian, uint32(len(t)))
+		buf.Write(t)
+	}
+	return buf.Bytes()
+}
+
+func standardBoard(t *testing.T) *FingerBoard {
+	t.Helper()
+
+	tun, _ := ParseTuning(StandardTuning)
+	fb, err := NewFingerBoard(tun, 24)
+	assert.NoError(t, err)
+	return fb
+}
+
+func TestReadMIDIRoundTrip(t *testing.T) {
+	tun, _ := ParseTuning(StandardTuning)
+	melody := []Playable{
+		Note{Fret: 0, String: 1, Time: 0, Duration: 0.5},
+		Note{Fret: 1, String: 1, Time: 0.5, Duration: 0.5},
+		Note{Fret: 3, String: 1, Time: 1, Duration: 1},
+	}
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, WriteMIDI(&buf, tun, melody, WithMIDITiming(Timing{
+		Tempos:         []TempoChange{{BPM: 120}, {Time: 1, BPM: 60}},
+		TimeSignatures: []TimeSignatureChange{{Signature: TimeSignature{Beats: 3, BeatUnit: 4}}},
+	})))
+
+	song, err := ReadMIDI(&buf, standardBoard(t))
+	assert.NoError(t, err)
+	assert.Equal(t, []TempoChange{{BPM: 120}, {Time: 1, BPM: 60}}, song.Tempos)
+	assert.Equal(t, []TimeSignatureChange{{Signature: TimeSignature{Beats: 3, BeatUnit: 4}}}, song.TimeSignatures)
+
+	assert.Len(t, song.Tracks, 1)
+	assert.Equal(t, Playables{
+		Note{Name: "B", Octave: 3, Fret: 0, String: 1, Time: 0, Duration: 0.5},
+		Note{Name: "C", Octave: 4, Fret: 1, String: 1, Time: 0.5, Duration: 0.5},
+		Note{Name: "D", Octave: 4, Fret: 3, String: 1, Time: 1, Duration: 1},
+	}, song.Tracks[0].Events)
+}
+
+func TestReadMIDIRunningStatus(t *testing.T) {
+	track := []byte{
+		0x00, 0xFF, 0x03, 4, 'L', 'e', 'a', 'd',
+		0x00, 0x90, 64, 100, // E4
+		0x83, 0x60, 64, 0, // running status note off, a beat later
+		0x00, 0x45, 100, // A4
+		0x83, 0x60, 0x80, 0x45, 0,
+		0x00, 0xFF, 0x2F, 0,
+	}
+	song, err := ReadMIDI(bytes.NewReader(testSMF(480, track)), standardBoard(t))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Lead", song.Tracks[0].Name)
+	assert.Equal(t, Playables{
+		Note{Name: "E", Octave: 4, Fret: 0, String: 0, Time: 0, Duration: 0.5},
+		Note{Name: "A", Octave: 4, Fret: 5, String: 0, Time: 0.5, Duration: 0.5},
+	}, song.Tracks[0].Events)
+}
+
+func TestReadMIDIChord(t *testing.T) {
+	// C major, C3 E3 G3 C4 E4, struck together a little apart
+	track := []byte{
+		0x00, 0x90, 48, 90, 0x05, 0x90, 52, 90, 0x03, 0x90, 55, 90, 0x02, 0x90, 60, 90, 0x01, 0x90, 64, 90,
+		0x83, 0x50, 0x80, 48, 0, 0x00, 0x80, 52, 0, 0x00, 0x80, 55, 0, 0x00, 0x80, 60, 0, 0x00, 0x80, 64, 0,
+	}
+	song, err := ReadMIDI(bytes.NewReader(testSMF(480, track)), standardBoard(t))
+	assert.NoError(t, err)
+
+	strings := map[int]bool{}
+	for _, p := range song.Tracks[0].Events {
+		n := p.(Note)
+		assert.Equal(t, float32(0), n.Time)
+		assert.Equal(t, float32(0.5), n.Duration)
+		assert.False(t, strings[n.String], "string %d used twice", n.String)
+		strings[n.String] = true
+	}
+	assert.Len(t, strings, 5)
+}
+
+func TestReadMIDIOptions(t *testing.T) {
+	track := []byte{
+		0x0A, 0x90, 64, 90, 0x83, 0x50, 0x80, 64, 0, // E4 a little late on channel 0
+		0x00, 0x91, 40, 90, 0x83, 0x60, 0x81, 40, 0, // E2 on channel 1
+		0x00, 0x99, 36, 90, 0x10, 0x89, 36, 0, // a kick drum
+	}
+	data := testSMF(480, []byte{0x00, 0xFF, 0x2F, 0}, track)
+	fb := standardBoard(t)
+
+	song, err := ReadMIDI(bytes.NewReader(data), fb)
+	assert.NoError(t, err)
+	assert.Equal(t, Playables{
+		Note{Name: "E", Octave: 4, Fret: 0, String: 0, Time: 0, Duration: 0.5},
+		Note{Name: "E", Octave: 2, Fret: 0, String: 5, Time: 0.5, Duration: 0.5},
+	}, song.Tracks[0].Events)
+
+	song, err = ReadMIDI(bytes.NewReader(data), fb, WithMIDIChannel(1), WithMIDITrack(1), WithQuantize(0))
+	assert.NoError(t, err)
+	assert.Equal(t, Playables{
+		Note{Name: "E", Octave: 2, Fret: 0, String: 5, Time: float32(10+0x1d0) / 960, Duration: 0.5},
+	}, song.Tracks[0].Events)
+}
+
+func TestReadMIDITab(t *testing.T) {
+	tun, _ := ParseTuning(StandardTuning)
+	buf := bytes.Buffer{}
+	assert.NoError(t, WriteMIDI(&buf, tun, []Playable{
+		Note{Fret: 3, String: 5, Time: 0, Duration: 0.5},
+		Note{Fret: 2, String: 4, Time: 0.5, Duration: 0.5},
+	}))
+
+	song, err := ReadMIDI(&buf, standardBoard(t))
+	assert.NoError(t, err)
+	tab, err := song.Tab(0)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(tab, "A|--2-"), tab)
+	assert.True(t, strings.Contains(tab, "E|3---"), tab)
+}
+
+func TestReadMIDIErrors(t *testing.T) {
+	fb := standardBoard(t)
+	low := []byte{0x00, 0x90, 20, 90, 0x60, 0x80, 20, 0}
+
+	testCases := []struct {
+		name string
+		data []byte
+		opts []MIDIReadOption
+	}{
+		{name: "not midi", data: []byte("RIFF0000WAVEfmt ")},
+		{name: "no notes", data: testSMF(480, []byte{0x00, 0xFF, 0x2F, 0})},
+		{name: "truncated", data: testSMF(480, []byte{0x00, 0x90, 64})},
+		{name: "below the tuning", data: testSMF(480, low)},
+		{name: "track index", data: testSMF(480, low), opts: []MIDIReadOption{WithMIDITrack(3)}},
+		{name: "channel", data: testSMF(480, low), opts: []MIDIReadOption{WithMIDIChannel(16)}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ReadMIDI(bytes.NewReader(tc.data), fb, tc.opts...)
+			assert.Error(t, err)
+		})
+	}
+}
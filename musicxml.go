@@ -0,0 +1,1113 @@
+package guitar
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	musicXMLVersion = "4.0"
+	musicXMLHeader  = `<!DOCTYPE score-partwise PUBLIC "-//Recordare//DTD MusicXML 4.0 Partwise//EN" "http://www.musicxml.org/dtds/partwise.dtd">`
+
+	// musicXMLDivisions per quarter note make a 64th note the shortest value.
+	musicXMLDivisions = 16
+	// gridTolerance is how far from the division grid, in divisions, a
+	// note may start or end and still be written.
+	gridTolerance = 0.05
+
+	notationStaff = 1
+	tabStaff      = 2
+	notationVoice = "1"
+	tabVoice      = "5"
+)
+
+type mxlScore struct {
+	XMLName        xml.Name           `xml:"score-partwise"`
+	Version        string             `xml:"version,attr"`
+	Work           *mxlWork           `xml:"work,omitempty"`
+	MovementTitle  string             `xml:"movement-title,omitempty"`
+	Identification *mxlIdentification `xml:"identification,omitempty"`
+	PartList       mxlPartList        `xml:"part-list"`
+	Parts          []mxlPart          `xml:"part"`
+}
+
+type mxlWork struct {
+	Title string `xml:"work-title"`
+}
+
+type mxlIdentification struct {
+	Creators []mxlCreator `xml:"creator"`
+}
+
+type mxlCreator struct {
+	Type string `xml:"type,attr"`
+	Name string `xml:",chardata"`
+}
+
+type mxlPartList struct {
+	Parts []mxlScorePart `xml:"score-part"`
+}
+
+type mxlScorePart struct {
+	ID   string `xml:"id,attr"`
+	Name string `xml:"part-name"`
+}
+
+type mxlPart struct {
+	ID       string       `xml:"id,attr"`
+	Measures []mxlMeasure `xml:"measure"`
+}
+
+// mxlMeasure keeps its attributes, directions, notes, backups and
+// forwards in document order.
+type mxlMeasure struct {
+	Number string `xml:"number,attr"`
+	Items  []any
+}
+
+type mxlAttributes struct {
+	XMLName      xml.Name          `xml:"attributes"`
+	Divisions    int               `xml:"divisions,omitempty"`
+	Key          *mxlKey           `xml:"key"`
+	Time         *mxlTime          `xml:"time"`
+	Staves       int               `xml:"staves,omitempty"`
+	Clefs        []mxlClef         `xml:"clef"`
+	StaffDetails []mxlStaffDetails `xml:"staff-details"`
+}
+
+type mxlKey struct {
+	Fifths int    `xml:"fifths"`
+	Mode   string `xml:"mode,omitempty"`
+}
+
+type mxlTime struct {
+	Beats    int `xml:"beats"`
+	BeatType int `xml:"beat-type"`
+}
+
+type mxlClef struct {
+	Number       int    `xml:"number,attr,omitempty"`
+	Sign         string `xml:"sign"`
+	Line         int    `xml:"line,omitempty"`
+	OctaveChange int    `xml:"clef-octave-change,omitempty"`
+}
+
+type mxlStaffDetails struct {
+	Number     int              `xml:"number,attr,omitempty"`
+	StaffLines int              `xml:"staff-lines,omitempty"`
+	Tuning     []mxlStaffTuning `xml:"staff-tuning"`
+	Capo       int              `xml:"capo,omitempty"`
+}
+
+type mxlStaffTuning struct {
+	Line   int     `xml:"line,attr"`
+	Step   string  `xml:"tuning-step"`
+	Alter  float64 `xml:"tuning-alter,omitempty"`
+	Octave int     `xml:"tuning-octave"`
+}
+
+type mxlDirection struct {
+	XMLName   xml.Name         `xml:"direction"`
+	Placement string           `xml:"placement,attr,omitempty"`
+	Types     []mxlDirectionOf `xml:"direction-type"`
+	Offset    int              `xml:"offset,omitempty"`
+	Staff     int              `xml:"staff,omitempty"`
+	Sound     *mxlSound        `xml:"sound"`
+}
+
+type mxlDirectionOf struct {
+	Metronome *mxlMetronome `xml:"metronome"`
+	Words     string        `xml:"words,omitempty"`
+}
+
+type mxlMetronome struct {
+	BeatUnit  string  `xml:"beat-unit"`
+	PerMinute float64 `xml:"per-minute"`
+}
+
+type mxlSound struct {
+	Tempo float64 `xml:"tempo,attr,omitempty"`
+}
+
+type mxlBackup struct {
+	XMLName  xml.Name `xml:"backup"`
+	Duration int      `xml:"duration"`
+}
+
+type mxlForward struct {
+	XMLName  xml.Name `xml:"forward"`
+	Duration int      `xml:"duration"`
+	Voice    string   `xml:"voice,omitempty"`
+	Staff    int      `xml:"staff,omitempty"`
+}
+
+type mxlEmpty struct{}
+
+type mxlNote struct {
+	XMLName   xml.Name      `xml:"note"`
+	Grace     *mxlEmpty     `xml:"grace"`
+	Chord     *mxlEmpty     `xml:"chord"`
+	Pitch     *mxlPitch     `xml:"pitch"`
+	Rest      *mxlRest      `xml:"rest"`
+	Duration  int           `xml:"duration"`
+	Ties      []mxlTie      `xml:"tie"`
+	Voice     string        `xml:"voice,omitempty"`
+	Type      string        `xml:"type,omitempty"`
+	Dots      []mxlEmpty    `xml:"dot"`
+	Notehead  *mxlNotehead  `xml:"notehead"`
+	Staff     int           `xml:"staff,omitempty"`
+	Notations *mxlNotations `xml:"notations"`
+}
+
+type mxlPitch struct {
+	Step   string  `xml:"step"`
+	Alter  float64 `xml:"alter,omitempty"`
+	Octave int     `xml:"octave"`
+}
+
+type mxlRest struct {
+	Measure string `xml:"measure,attr,omitempty"`
+}
+
+type mxlTie struct {
+	Type string `xml:"type,attr"`
+}
+
+type mxlNotehead struct {
+	Parentheses string `xml:"parentheses,attr,omitempty"`
+	Value       string `xml:",chardata"`
+}
+
+type mxlNotations struct {
+	Tied          []mxlTie          `xml:"tied"`
+	Slurs         []mxlLine         `xml:"slur"`
+	Slides        []mxlLine         `xml:"slide"`
+	Technical     *mxlTechnical     `xml:"technical"`
+	Articulations *mxlArticulations `xml:"articulations"`
+	Ornaments     *mxlOrnaments     `xml:"ornaments"`
+	Arpeggiate    *mxlArpeggiate    `xml:"arpeggiate"`
+}
+
+type mxlLine struct {
+	Type     string `xml:"type,attr"`
+	Number   int    `xml:"number,attr,omitempty"`
+	LineType string `xml:"line-type,attr,omitempty"`
+}
+
+type mxlLegato struct {
+	Type   string `xml:"type,attr"`
+	Number int    `xml:"number,attr,omitempty"`
+	Text   string `xml:",chardata"`
+}
+
+type mxlTechnical struct {
+	HammerOns      []mxlLegato  `xml:"hammer-on"`
+	PullOffs       []mxlLegato  `xml:"pull-off"`
+	Harmonic       *mxlHarmonic `xml:"harmonic"`
+	Bends          []mxlBend    `xml:"bend"`
+	Tap            *mxlEmpty    `xml:"tap"`
+	String         int          `xml:"string,omitempty"`
+	Fret           *int         `xml:"fret"`
+	OtherTechnical []string     `xml:"other-technical"`
+}
+
+type mxlHarmonic struct {
+	Natural    *mxlEmpty `xml:"natural"`
+	Artificial *mxlEmpty `xml:"artificial"`
+}
+
+type mxlBend struct {
+	Alter   float64   `xml:"bend-alter"`
+	PreBend *mxlEmpty `xml:"pre-bend"`
+	Release *mxlEmpty `xml:"release"`
+}
+
+type mxlArticulations struct {
+	Accent   *mxlEmpty `xml:"accent"`
+	Staccato *mxlEmpty `xml:"staccato"`
+	Scoop    *mxlEmpty `xml:"scoop"`
+}
+
+type mxlOrnaments struct {
+	TrillMark *mxlEmpty   `xml:"trill-mark"`
+	WavyLines []mxlLine   `xml:"wavy-line"`
+	Tremolo   *mxlTremolo `xml:"tremolo"`
+}
+
+type mxlTremolo struct {
+	Type  string `xml:"type,attr"`
+	Marks int    `xml:",chardata"`
+}
+
+type mxlArpeggiate struct {
+	Direction string `xml:"direction,attr,omitempty"`
+}
+
+func (m mxlMeasure) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Attr = []xml.Attr{{Name: xml.Name{Local: "number"}, Value: m.Number}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, item := range m.Items {
+		if err := e.Encode(item); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// UnmarshalXML reads the items of a measure the importer uses; a sound
+// outside a direction is read as a direction without text.
+func (m *mxlMeasure) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, a := range start.Attr {
+		if a.Name.Local == "number" {
+			m.Number = a.Value
+		}
+	}
+
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.EndElement:
+			return nil
+		case xml.StartElement:
+			var item any
+			switch t.Name.Local {
+			case "attributes":
+				v := mxlAttributes{}
+				err, item = d.DecodeElement(&v, &t), v
+			case "direction":
+				v := mxlDirection{}
+				err, item = d.DecodeElement(&v, &t), v
+			case "sound":
+				v := mxlSound{}
+				err, item = d.DecodeElement(&v, &t), mxlDirection{Sound: &v}
+			case "note":
+				v := mxlNote{}
+				err, item = d.DecodeElement(&v, &t), v
+			case "backup":
+				v := mxlBackup{}
+				err, item = d.DecodeElement(&v, &t), v
+			case "forward":
+				v := mxlForward{}
+				err, item = d.DecodeElement(&v, &t), v
+			default:
+				err = d.Skip()
+			}
+			if err != nil {
+				return err
+			}
+			if item != nil {
+				m.Items = append(m.Items, item)
+			}
+		}
+	}
+}
+
+// scoreNote is a note placed in the score, in divisions. Links tie it to
+// the previous or the next note by a hammer-on, pull-off or slide.
+type scoreNote struct {
+	start, length int
+	stringNumber  int
+	fret          int
+	pitch         Note
+
+	linkFrom, linkTo string
+
+	harmonic      string
+	harmonicFret  int
+	pinch         bool
+	bends         []mxlBend
+	curve         BendCurve
+	tap           bool
+	dead          bool
+	scoop         bool
+	trill         bool
+	trillFret     int
+	tremolo       int
+	arpeggiate    string
+	articulations Articulation
+}
+
+const (
+	linkHammerOn = "hammer-on"
+	linkPullOff  = "pull-off"
+	linkSlide    = "slide"
+)
+
+type musicXMLWriter struct {
+	timing Timing
+	fb     *FingerBoard
+}
+
+// WriteMusicXML writes the song as a MusicXML score with a part per
+// track. Every part has a standard notation staff at sounding pitch and
+// a TAB staff with strings, frets counted from the capo and the tuning
+// of the track. Notes must start and end on a 64th note grid; tuplets
+// are not written.
+func (s *Song) WriteMusicXML(w io.Writer) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	timing := s.Timing
+	timing.Sort()
+
+	score := mxlScore{Version: musicXMLVersion}
+	if s.Title != "" {
+		score.Work = &mxlWork{Title: s.Title}
+	}
+	if s.Artist != "" {
+		score.Identification = &mxlIdentification{Creators: []mxlCreator{{Type: "composer", Name: s.Artist}}}
+	}
+
+	key := &mxlKey{}
+	if s.Key != "" {
+		fifths, minor, err := s.KeySignature()
+		if err != nil {
+			return err
+		}
+		key.Fifths, key.Mode = fifths, "major"
+		if minor {
+			key.Mode = "minor"
+		}
+	}
+
+	end := 0
+	parts := [][]scoreNote{}
+	for _, t := range s.Tracks {
+		fb, err := t.FingerBoard()
+		if err != nil {
+			return err
+		}
+		mw := musicXMLWriter{timing: timing, fb: fb}
+		notes, err := mw.scoreNotes(t.Events)
+		if err != nil {
+			return fmt.Errorf("track %q: %w", t.Name, err)
+		}
+		for _, n := range notes {
+			end = max(end, n.start+n.length)
+		}
+		parts = append(parts, notes)
+	}
+
+	measures := scoreMeasures(timing, end)
+	for i, t := range s.Tracks {
+		id := fmt.Sprintf("P%d", i+1)
+		name := t.Name
+		if name == "" {
+			name = fmt.Sprintf("Guitar %d", i+1)
+		}
+		score.PartList.Parts = append(score.PartList.Parts, mxlScorePart{ID: id, Name: name})
+
+		mw := musicXMLWriter{timing: timing}
+		part := mxlPart{ID: id}
+		for j, m := range measures {
+			measure := mxlMeasure{Number: fmt.Sprint(j + 1)}
+			measure.Items = append(measure.Items, mw.attributes(t, m, j, key)...)
+			measure.Items = append(measure.Items, mw.tempoDirections(m)...)
+			measure.Items = append(measure.Items, voice(parts[i], m, notationStaff)...)
+			measure.Items = append(measure.Items, mxlBackup{Duration: m.end - m.start})
+			measure.Items = append(measure.Items, voice(parts[i], m, tabStaff)...)
+			part.Measures = append(part.Measures, measure)
+		}
+		score.Parts = append(score.Parts, part)
+	}
+
+	if _, err := io.WriteString(w, xml.Header+musicXMLHeader+"\n"); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(score); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// scoreMeasure is a measure in divisions.
+type scoreMeasure struct {
+	start, end int
+	signature  TimeSignature
+	changed    bool
+}
+
+// scoreMeasures cuts the score into measures of the time signatures in
+// effect, starting a measure at every time signature change.
+func scoreMeasures(timing Timing, end int) []scoreMeasure {
+	changes := []int{}
+	for _, c := range timing.TimeSignatures {
+		changes = append(changes, divisionsAt(timing, c.Time))
+	}
+
+	measures := []scoreMeasure{}
+	last := TimeSignature{}
+	for pos := 0; pos < end || len(measures) == 0; {
+		ts := DefaultTimeSignature
+		for i, c := range timing.TimeSignatures {
+			if changes[i] <= pos {
+				ts = c.Signature
+			}
+		}
+
+		next := pos + ts.Beats*4*musicXMLDivisions/ts.BeatUnit
+		for _, c := range changes {
+			if c > pos && c < next {
+				next = c
+			}
+		}
+
+		measures = append(measures, scoreMeasure{start: pos, end: next, signature: ts, changed: ts != last})
+		last = ts
+		pos = next
+	}
+	return measures
+}
+
+func divisionsAt(timing Timing, t float32) int {
+	return int(math.Round(float64(timing.Beats(0, t, DefaultTempo) * musicXMLDivisions)))
+}
+
+func (mw *musicXMLWriter) attributes(t *Track, m scoreMeasure, index int, key *mxlKey) []any {
+	if !m.changed {
+		return nil
+	}
+
+	attributes := mxlAttributes{Time: &mxlTime{Beats: m.signature.Beats, BeatType: m.signature.BeatUnit}}
+	if index > 0 {
+		return []any{attributes}
+	}
+
+	attributes.Divisions = musicXMLDivisions
+	attributes.Key = key
+	attributes.Staves = 2
+	attributes.Clefs = []mxlClef{
+		{Number: notationStaff, Sign: "G", Line: 2, OctaveChange: -1},
+		{Number: tabStaff, Sign: "TAB", Line: 5},
+	}
+
+	details := mxlStaffDetails{Number: tabStaff, StaffLines: len(t.Tuning), Capo: t.Capo}
+	for i := range t.Tuning {
+		// staff lines count from the lowest string
+		n := t.Tuning[len(t.Tuning)-1-i]
+		step, alter := pitchStep(n.Name)
+		details.Tuning = append(details.Tuning, mxlStaffTuning{Line: i + 1, Step: step, Alter: alter, Octave: n.Octave})
+	}
+	attributes.StaffDetails = []mxlStaffDetails{details}
+	return []any{attributes}
+}
+
+func (mw *musicXMLWriter) tempoDirections(m scoreMeasure) []any {
+	directions := []any{}
+	tempos := mw.timing.Tempos
+	if len(tempos) == 0 && m.start == 0 {
+		tempos = []TempoChange{{BPM: DefaultTempo}}
+	}
+	for _, c := range tempos {
+		pos := divisionsAt(mw.timing, c.Time)
+		if pos < m.start || pos >= m.end {
+			continue
+		}
+		bpm := float64(c.BPM)
+		directions = append(directions, mxlDirection{
+			Placement: "above",
+			Types:     []mxlDirectionOf{{Metronome: &mxlMetronome{BeatUnit: "quarter", PerMinute: bpm}}},
+			Offset:    pos - m.start,
+			Staff:     notationStaff,
+			Sound:     &mxlSound{Tempo: bpm},
+		})
+	}
+	return directions
+}
+
+// pitchStep splits a note name such as "F#" into its step and alter.
+func pitchStep(name string) (string, float64) {
+	n := Note{Name: name}
+	if err := n.Validate(); err != nil || len(n.Name) == 0 {
+		return name, 0
+	}
+	if strings.HasSuffix(n.Name, "#") {
+		return n.Name[:1], 1
+	}
+	return n.Name, 0
+}
+
+func (mw *musicXMLWriter) position(t float32) int {
+	return divisionsAt(mw.timing, t)
+}
+
+// onGrid checks that times fall on the division grid. Tuplets and other
+// values off the grid can not be written and are not rounded.
+func (mw *musicXMLWriter) onGrid(times ...float32) error {
+	for _, t := range times {
+		d := float64(mw.timing.Beats(0, t, DefaultTempo) * musicXMLDivisions)
+		if math.Abs(d-math.Round(d)) > gridTolerance {
+			return fmt.Errorf("note time %gs is off the 64th note grid, tuplets are not supported", t)
+		}
+	}
+	return nil
+}
+
+// scoreNotes places the Playables of a track. Notes without a duration
+// last until the next note starts, or a quarter note for the last one.
+func (mw *musicXMLWriter) scoreNotes(ps []Playable) ([]scoreNote, error) {
+	notes := []scoreNote{}
+	for _, p := range ps {
+		n, err := mw.place(p)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, n...)
+	}
+
+	sort.SliceStable(notes, func(i, j int) bool { return notes[i].start < notes[j].start })
+	for i := range notes {
+		if notes[i].length > 0 {
+			continue
+		}
+		notes[i].length = musicXMLDivisions
+		for _, next := range notes[i+1:] {
+			if next.start > notes[i].start {
+				notes[i].length = next.start - notes[i].start
+				break
+			}
+		}
+	}
+	return notes, nil
+}
+
+func (mw *musicXMLWriter) note(stringNumber, fret int, start, end float32) (scoreNote, error) {
+	pitch, err := mw.fb.tuning.NoteAt(stringNumber, fret)
+	if err != nil {
+		return scoreNote{}, err
+	}
+	n := scoreNote{start: mw.position(start), stringNumber: stringNumber, fret: fret, pitch: pitch}
+	if end > start {
+		n.length = max(1, mw.position(end)-n.start)
+	}
+	return n, nil
+}
+
+func endTime(p Playable) float32 {
+	if s, ok := p.(Sustained); ok {
+		return s.EndTime()
+	}
+	return p.StartTime()
+}
+
+// linked splits a two note technique in halves joined by link.
+func (mw *musicXMLWriter) linked(p Playable, from, to int, link string) ([]scoreNote, error) {
+	start, end := p.StartTime(), endTime(p)
+	mid := start + (end-start)/2
+	if end <= start {
+		// without a duration the second note follows an eighth later
+		mid = start + 30/mw.timing.TempoAt(start, DefaultTempo)
+		end = mid
+	}
+
+	first, err := mw.note(p.StringNumber(), from, start, mid)
+	if err != nil {
+		return nil, err
+	}
+	second, err := mw.note(p.StringNumber(), to, mid, end)
+	if err != nil {
+		return nil, err
+	}
+	first.linkTo, second.linkFrom = link, link
+	return []scoreNote{first, second}, nil
+}
+
+func (mw *musicXMLWriter) place(p Playable) ([]scoreNote, error) {
+	articulations := ArticulationsOf(p)
+	inner := Unwrap(p)
+	start, end := p.StartTime(), endTime(p)
+	if err := mw.onGrid(start, end); err != nil {
+		return nil, err
+	}
+
+	var notes []scoreNote
+	var err error
+	single := func(fret int) {
+		var n scoreNote
+		n, err = mw.note(p.StringNumber(), fret, start, end)
+		notes = []scoreNote{n}
+	}
+
+	switch n := inner.(type) {
+	case Note:
+		single(n.Fret)
+	case DeadNote:
+		single(0)
+		notes[0].dead = true
+	case Tap:
+		single(n.Fret)
+		notes[0].tap = true
+	case Tremolo:
+		single(n.Fret)
+		notes[0].tremolo = tremoloMarks(n.Division)
+	case Trill:
+		single(n.Fret)
+		notes[0].trill, notes[0].trillFret = true, n.TrillFret
+	case Harmonic, PinchHarmonic, ArtificialHarmonic:
+		notes, err = mw.harmonic(inner, start, end)
+	case Slide:
+		if n.FretStart < 0 {
+			single(n.FretEnd)
+			notes[0].scoop = true
+			break
+		}
+		notes, err = mw.linked(p, n.FretStart, n.FretEnd, linkSlide)
+	case HammerOn:
+		notes, err = mw.linked(p, n.FretFrom, n.FretTo, linkHammerOn)
+	case PullOff:
+		notes, err = mw.linked(p, n.FretFrom, n.FretTo, linkPullOff)
+	case Bender:
+		single(n.BendFret())
+		notes[0].bends, notes[0].curve = bendMarks(inner), n.BendCurve()
+	case Chord:
+		return mw.chord(n, articulations)
+	case Legato:
+		return mw.legato(n, articulations)
+	default:
+		return nil, fmt.Errorf("can not write %T to MusicXML", inner)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range notes {
+		notes[i].articulations |= articulations
+	}
+	return notes, nil
+}
+
+func (mw *musicXMLWriter) harmonic(p Playable, start, end float32) ([]scoreNote, error) {
+	sounding, err := mw.fb.SoundingNote(p)
+	if err != nil {
+		return nil, err
+	}
+
+	// the harmonic fret is where the string is touched, counted from the
+	// fretted note for artificial harmonics
+	fret, harmonicFret, kind, pinch := 0, 0, "natural", false
+	switch h := p.(type) {
+	case Harmonic:
+		fret, harmonicFret = h.Fret, h.Fret
+	case PinchHarmonic:
+		fret, harmonicFret, kind, pinch = h.Fret, harmonicSemitones(h.partial()), "artificial", true
+	case ArtificialHarmonic:
+		fret, harmonicFret, kind = h.Fret, h.Offset, "artificial"
+	}
+
+	n, err := mw.note(p.StringNumber(), fret, start, end)
+	if err != nil {
+		return nil, err
+	}
+	n.pitch, n.harmonic, n.harmonicFret, n.pinch = sounding, kind, harmonicFret, pinch
+	return []scoreNote{n}, nil
+}
+
+// bendMarks writes a bend as MusicXML bends in semitones.
+func bendMarks(p Playable) []mxlBend {
+	switch b := p.(type) {
+	case Bend:
+		return []mxlBend{{Alter: float64(b.Cents) / 100}}
+	case PreBend:
+		return []mxlBend{{Alter: float64(b.Cents) / 100, PreBend: &mxlEmpty{}}}
+	case BendRelease:
+		return []mxlBend{
+			{Alter: float64(b.Cents) / 100},
+			{Alter: float64(b.Release-b.Cents) / 100, Release: &mxlEmpty{}},
+		}
+	case Bender:
+		marks := []mxlBend{}
+		last := 0
+		for _, point := range b.BendCurve() {
+			if point.Cents == last {
+				continue
+			}
+			mark := mxlBend{Alter: float64(point.Cents-last) / 100}
+			if point.Cents < last {
+				mark.Release = &mxlEmpty{}
+			}
+			marks = append(marks, mark)
+			last = point.Cents
+		}
+		return marks
+	}
+	return nil
+}
+
+// tremoloMarks is the number of beams across the stem of a tremolo.
+func tremoloMarks(division int) int {
+	if division <= 0 {
+		division = defaultStrokeDivision
+	}
+	marks := 0
+	for d := division; d > 4; d /= 2 {
+		marks++
+	}
+	return max(1, marks)
+}
+
+func (mw *musicXMLWriter) chord(c Chord, articulations Articulation) ([]scoreNote, error) {
+	notes := []scoreNote{}
+	for _, s := range c.strings() {
+		n, err := mw.place(Articulate(c.note(s, c.Time), articulations))
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, n...)
+	}
+
+	switch c.Strum {
+	case StrumDown:
+		notes[0].arpeggiate = "up"
+	case StrumUp:
+		notes[0].arpeggiate = "down"
+	}
+	return notes, nil
+}
+
+// legato places the events of a chain, linking every hammer-on, pull-off
+// and slide to the note before.
+func (mw *musicXMLWriter) legato(l Legato, articulations Articulation) ([]scoreNote, error) {
+	notes := []scoreNote{}
+	for _, ev := range l.Events() {
+		a := ArticulationsOf(ev) | articulations
+		start, end := ev.StartTime(), endTime(ev)
+
+		link, fret := "", 0
+		switch ev := Unwrap(ev).(type) {
+		case HammerOn:
+			link, fret = linkHammerOn, ev.FretTo
+		case PullOff:
+			link, fret = linkPullOff, ev.FretTo
+		case Slide:
+			link, fret = linkSlide, ev.FretEnd
+		}
+
+		if link == "" {
+			n, err := mw.place(Articulate(Unwrap(ev), a))
+			if err != nil {
+				return nil, err
+			}
+			notes = append(notes, n...)
+			continue
+		}
+
+		if err := mw.onGrid(start, end); err != nil {
+			return nil, err
+		}
+		n, err := mw.note(l.String, fret, start, end)
+		if err != nil {
+			return nil, err
+		}
+		n.articulations, n.linkFrom = a, link
+		if len(notes) > 0 {
+			notes[len(notes)-1].linkTo = link
+		}
+		notes = append(notes, n)
+	}
+	return notes, nil
+}
+
+// scorePiece is the part of a note written in one measure.
+type scorePiece struct {
+	note                *scoreNote
+	start, length       int
+	tieStart, tieStop   bool
+	firstPiece, lastOne bool
+}
+
+// scoreBeat is a chord of pieces, or a rest without them, lasting one
+// note value. A piece longer than one value is written as tied values,
+// firstValue and lastValue marking its ends.
+type scoreBeat struct {
+	pieces                []scorePiece
+	value                 noteValue
+	firstValue, lastValue bool
+}
+
+// layoutVoice cuts the notes of a measure into beats of one voice: notes
+// starting together are a chord lasting until the next onset at most,
+// gaps are rests and notes ringing over the bar line are tied. A measure
+// without notes has no beats.
+func layoutVoice(notes []scoreNote, m scoreMeasure) []scoreBeat {
+	pieces := []scorePiece{}
+	for i := range notes {
+		n := &notes[i]
+		start, end := max(n.start, m.start), min(n.start+n.length, m.end)
+		if start >= end {
+			continue
+		}
+		pieces = append(pieces, scorePiece{
+			note: n, start: start, length: end - start,
+			tieStop: n.start < m.start, tieStart: n.start+n.length > m.end,
+			firstPiece: n.start >= m.start, lastOne: n.start+n.length <= m.end,
+		})
+	}
+	if len(pieces) == 0 {
+		return nil
+	}
+
+	beats := []scoreBeat{}
+	pos := m.start
+	for i := 0; i < len(pieces); {
+		j := i
+		for j < len(pieces) && pieces[j].start == pieces[i].start {
+			j++
+		}
+		group := pieces[i:j]
+		start := group[0].start
+		if start < pos {
+			// the voice is still busy, the onset is dropped
+			i = j
+			continue
+		}
+		beats = append(beats, restBeats(start-pos)...)
+
+		length := group[0].length
+		for _, p := range group {
+			length = min(length, p.length)
+		}
+		if j < len(pieces) {
+			length = min(length, pieces[j].start-start)
+		}
+
+		values := noteValues(length)
+		for v, value := range values {
+			beats = append(beats, scoreBeat{pieces: group, value: value, firstValue: v == 0, lastValue: v == len(values)-1})
+		}
+		pos = start + length
+		i = j
+	}
+	return append(beats, restBeats(m.end-pos)...)
+}
+
+func restBeats(length int) []scoreBeat {
+	beats := []scoreBeat{}
+	for _, value := range noteValues(length) {
+		beats = append(beats, scoreBeat{value: value})
+	}
+	return beats
+}
+
+// voice writes the notes of a measure on a staff as one voice, a measure
+// rest when there are none.
+func voice(notes []scoreNote, m scoreMeasure, staff int) []any {
+	beats := layoutVoice(notes, m)
+	if len(beats) == 0 {
+		return []any{mxlNote{Rest: &mxlRest{Measure: "yes"}, Duration: m.end - m.start,
+			Voice: staffVoice(staff), Staff: staff}}
+	}
+
+	items := []any{}
+	for _, b := range beats {
+		if len(b.pieces) == 0 {
+			items = append(items, mxlNote{Rest: &mxlRest{}, Duration: b.value.length, Voice: staffVoice(staff),
+				Type: b.value.name, Dots: make([]mxlEmpty, b.value.dots), Staff: staff})
+			continue
+		}
+		for k, p := range b.pieces {
+			n := writeNote(p, b.value, staff, b.firstValue, b.lastValue)
+			if k > 0 {
+				n.Chord = &mxlEmpty{}
+			}
+			items = append(items, n)
+		}
+	}
+	return items
+}
+
+func staffVoice(staff int) string {
+	if staff == tabStaff {
+		return tabVoice
+	}
+	return notationVoice
+}
+
+type noteValue struct {
+	name   string
+	length int
+	dots   int
+}
+
+var noteValueNames = []string{"whole", "half", "quarter", "eighth", "16th", "32nd", "64th"}
+
+// noteValues splits a length in divisions into written note values,
+// longest first, to be tied together.
+func noteValues(length int) []noteValue {
+	values := []noteValue{}
+	for length > 0 {
+		best := noteValue{name: noteValueNames[len(noteValueNames)-1], length: length}
+		for i, name := range noteValueNames {
+			plain := musicXMLDivisions * 4 >> i
+			if plain == 0 {
+				break
+			}
+			if dotted := plain + plain/2; plain%2 == 0 && dotted <= length {
+				best = noteValue{name: name, length: dotted, dots: 1}
+				break
+			}
+			if plain <= length {
+				best = noteValue{name: name, length: plain}
+				break
+			}
+		}
+		values = append(values, best)
+		length -= best.length
+	}
+	return values
+}
+
+// writeNote writes one value of a piece. Links and marks go on the first
+// value of a note, ties and the link to the next note on its last.
+func writeNote(p scorePiece, value noteValue, staff int, firstValue, lastValue bool) mxlNote {
+	n := p.note
+	step, alter := pitchStep(n.pitch.Name)
+	note := mxlNote{
+		Pitch:    &mxlPitch{Step: step, Alter: alter, Octave: n.pitch.Octave},
+		Duration: value.length,
+		Voice:    staffVoice(staff),
+		Type:     value.name,
+		Dots:     make([]mxlEmpty, value.dots),
+		Staff:    staff,
+	}
+	notations := &mxlNotations{}
+	technical := &mxlTechnical{}
+
+	tieStop := !firstValue || p.tieStop
+	tieStart := !lastValue || p.tieStart
+	if tieStop {
+		note.Ties = append(note.Ties, mxlTie{Type: "stop"})
+		notations.Tied = append(notations.Tied, mxlTie{Type: "stop"})
+	}
+	if tieStart {
+		note.Ties = append(note.Ties, mxlTie{Type: "start"})
+		notations.Tied = append(notations.Tied, mxlTie{Type: "start"})
+	}
+
+	head := firstValue && p.firstPiece
+	tail := lastValue && p.lastOne
+	number := n.stringNumber + 1
+
+	links := []struct {
+		link, kind string
+		on         bool
+	}{
+		{n.linkFrom, "stop", head},
+		{n.linkTo, "start", tail},
+	}
+	for _, l := range links {
+		if l.link == "" || !l.on {
+			continue
+		}
+		switch l.link {
+		case linkSlide:
+			notations.Slides = append(notations.Slides, mxlLine{Type: l.kind, Number: number, LineType: "solid"})
+		case linkHammerOn:
+			notations.Slurs = append(notations.Slurs, mxlLine{Type: l.kind, Number: number})
+			technical.HammerOns = append(technical.HammerOns, mxlLegato{Type: l.kind, Number: number, Text: legatoText(l.kind, "H")})
+		case linkPullOff:
+			notations.Slurs = append(notations.Slurs, mxlLine{Type: l.kind, Number: number})
+			technical.PullOffs = append(technical.PullOffs, mxlLegato{Type: l.kind, Number: number, Text: legatoText(l.kind, "P")})
+		}
+	}
+
+	if head {
+		switch n.harmonic {
+		case "natural":
+			technical.Harmonic = &mxlHarmonic{Natural: &mxlEmpty{}}
+		case "artificial":
+			technical.Harmonic = &mxlHarmonic{Artificial: &mxlEmpty{}}
+		}
+		technical.Bends = n.bends
+		if n.tap {
+			technical.Tap = &mxlEmpty{}
+		}
+		if n.articulations.Has(PalmMute) {
+			technical.OtherTechnical = append(technical.OtherTechnical, "P.M.")
+		}
+		if n.articulations.Has(LetRing) {
+			technical.OtherTechnical = append(technical.OtherTechnical, "let ring")
+		}
+
+		switch {
+		case n.dead || n.articulations.Has(Muted):
+			note.Notehead = &mxlNotehead{Value: "x"}
+		case n.articulations.Has(Ghost):
+			note.Notehead = &mxlNotehead{Parentheses: "yes", Value: "normal"}
+		}
+
+		if n.articulations.Has(Accent) || n.articulations.Has(Staccato) || n.scoop {
+			a := &mxlArticulations{}
+			if n.articulations.Has(Accent) {
+				a.Accent = &mxlEmpty{}
+			}
+			if n.articulations.Has(Staccato) {
+				a.Staccato = &mxlEmpty{}
+			}
+			if n.scoop {
+				a.Scoop = &mxlEmpty{}
+			}
+			notations.Articulations = a
+		}
+
+		ornaments := &mxlOrnaments{}
+		if n.trill {
+			ornaments.TrillMark = &mxlEmpty{}
+		}
+		if n.tremolo > 0 {
+			ornaments.Tremolo = &mxlTremolo{Type: "single", Marks: n.tremolo}
+		}
+		if n.articulations.Has(Vibrato) || n.articulations.Has(WideVibrato) {
+			ornaments.WavyLines = []mxlLine{{Type: "start", Number: number}, {Type: "stop", Number: number}}
+		}
+		if ornaments.TrillMark != nil || ornaments.Tremolo != nil || len(ornaments.WavyLines) > 0 {
+			notations.Ornaments = ornaments
+		}
+
+		if n.arpeggiate != "" {
+			notations.Arpeggiate = &mxlArpeggiate{Direction: n.arpeggiate}
+		}
+	}
+
+	if staff == tabStaff {
+		fret := n.fret
+		technical.String, technical.Fret = number, &fret
+	} else {
+		// the legato marks and fret details belong to the TAB staff
+		technical.HammerOns, technical.PullOffs, technical.OtherTechnical = nil, nil, nil
+	}
+
+	if !isEmptyTechnical(technical) {
+		notations.Technical = technical
+	}
+	if len(notations.Tied)+len(notations.Slurs)+len(notations.Slides) > 0 || notations.Technical != nil ||
+		notations.Articulations != nil || notations.Ornaments != nil || notations.Arpeggiate != nil {
+		note.Notations = notations
+	}
+	return note
+}
+
+func legatoText(kind, text string) string {
+	if kind == "start" {
+		return text
+	}
+	return ""
+}
+
+func isEmptyTechnical(t *mxlTechnical) bool {
+	return len(t.HammerOns)+len(t.PullOffs)+len(t.Bends)+len(t.OtherTechnical) == 0 &&
+		t.Harmonic == nil && t.Tap == nil && t.Fret == nil
+}
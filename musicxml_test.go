@@ -0,0 +1,240 @@
+package guitar
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testXMLNote struct {
+	Step     string    `xml:"pitch>step"`
+	Alter    float64   `xml:"pitch>alter"`
+	Octave   int       `xml:"pitch>octave"`
+	Rest     *struct{} `xml:"rest"`
+	Chord    *struct{} `xml:"chord"`
+	Duration int       `xml:"duration"`
+	Type     string    `xml:"type"`
+	Staff    int       `xml:"staff"`
+	Ties     []mxlTie  `xml:"tie"`
+	Notehead string    `xml:"notehead"`
+
+	String    int         `xml:"notations>technical>string"`
+	Fret      *int        `xml:"notations>technical>fret"`
+	HammerOns []mxlLegato `xml:"notations>technical>hammer-on"`
+	PullOffs  []mxlLegato `xml:"notations>technical>pull-off"`
+	Harmonic  *struct{}   `xml:"notations>technical>harmonic"`
+	Bends     []mxlBend   `xml:"notations>technical>bend"`
+	Other     []string    `xml:"notations>technical>other-technical"`
+	Slides    []mxlLine   `xml:"notations>slide"`
+	Scoop     *struct{}   `xml:"notations>articulations>scoop"`
+}
+
+type testXMLScore struct {
+	Title string `xml:"work>work-title"`
+	Parts []struct {
+		ID       string `xml:"id,attr"`
+		Measures []struct {
+			Number       string           `xml:"number,attr"`
+			Beats        int              `xml:"attributes>time>beats"`
+			Fifths       int              `xml:"attributes>key>fifths"`
+			StaffTunings []mxlStaffTuning `xml:"attributes>staff-details>staff-tuning"`
+			Capo         int              `xml:"attributes>staff-details>capo"`
+			Sounds       []mxlSound       `xml:"direction>sound"`
+			Notes        []testXMLNote    `xml:"note"`
+		} `xml:"measure"`
+	} `xml:"part"`
+}
+
+func writeTestMusicXML(t *testing.T, s *Song) testXMLScore {
+	t.Helper()
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, s.WriteMusicXML(&buf))
+	assert.True(t, strings.HasPrefix(buf.String(), xml.Header+musicXMLHeader))
+
+	score := testXMLScore{}
+	assert.NoError(t, xml.Unmarshal(buf.Bytes(), &score))
+	return score
+}
+
+// staffNotes returns the notes of a measure written on a staff.
+func staffNotes(notes []testXMLNote, staff int) []testXMLNote {
+	result := []testXMLNote{}
+	for _, n := range notes {
+		if n.Staff == staff {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+func TestNoteValues(t *testing.T) {
+	testCases := []struct {
+		length   int
+		expected []noteValue
+	}{
+		{length: 16, expected: []noteValue{{name: "quarter", length: 16}}},
+		{length: 24, expected: []noteValue{{name: "quarter", length: 24, dots: 1}}},
+		{length: 20, expected: []noteValue{{name: "quarter", length: 16}, {name: "16th", length: 4}}},
+		{length: 64, expected: []noteValue{{name: "whole", length: 64}}},
+		{length: 80, expected: []noteValue{{name: "whole", length: 64}, {name: "quarter", length: 16}}},
+		{length: 3, expected: []noteValue{{name: "32nd", length: 3, dots: 1}}},
+		{length: 1, expected: []noteValue{{name: "64th", length: 1}}},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, noteValues(tc.length))
+	}
+}
+
+func TestScoreMeasures(t *testing.T) {
+	timing := Timing{
+		Tempos:         []TempoChange{{BPM: 120}},
+		TimeSignatures: []TimeSignatureChange{{Time: 2, Signature: TimeSignature{3, 4}}},
+	}
+
+	assert.Equal(t, []scoreMeasure{
+		{start: 0, end: 64, signature: TimeSignature{4, 4}, changed: true},
+		{start: 64, end: 112, signature: TimeSignature{3, 4}, changed: true},
+		{start: 112, end: 160, signature: TimeSignature{3, 4}},
+	}, scoreMeasures(timing, 120))
+}
+
+func TestWriteMusicXML(t *testing.T) {
+	s := testSong(t)
+	score := writeTestMusicXML(t, s)
+
+	assert.Equal(t, "Etude", score.Title)
+	assert.Len(t, score.Parts, 1)
+	measures := score.Parts[0].Measures
+	assert.Len(t, measures, 3)
+
+	first := measures[0]
+	assert.Equal(t, 2, first.Beats)
+	assert.Equal(t, 0, first.Fifths)
+	assert.Equal(t, 2, first.Capo)
+	assert.Equal(t, mxlStaffTuning{Line: 1, Step: "E", Octave: 2}, first.StaffTunings[0])
+	assert.Equal(t, []mxlSound{{Tempo: 120}}, first.Sounds)
+
+	// the note rings until the next one, over the bar line, and sounds
+	// two frets higher with the capo
+	notation := staffNotes(first.Notes, notationStaff)
+	assert.Len(t, notation, 1)
+	assert.Equal(t, "A", notation[0].Step)
+	assert.Equal(t, 4, notation[0].Octave)
+	assert.Equal(t, "half", notation[0].Type)
+	assert.Equal(t, []mxlTie{{Type: "start"}}, notation[0].Ties)
+	assert.Nil(t, notation[0].Fret)
+
+	tab := staffNotes(first.Notes, tabStaff)
+	assert.Equal(t, 1, tab[0].String)
+	assert.Equal(t, 3, *tab[0].Fret)
+
+	// the tempo halves at two seconds, the start of the third measure
+	assert.Equal(t, []mxlSound{{Tempo: 60}}, measures[2].Sounds)
+
+	// a note without duration lasts a quarter note
+	tab = staffNotes(measures[2].Notes, tabStaff)
+	assert.Equal(t, "quarter", tab[1].Type)
+	assert.Equal(t, 3, tab[1].String)
+	assert.Equal(t, 7, *tab[1].Fret)
+}
+
+func TestWriteMusicXMLTechniques(t *testing.T) {
+	tun, _ := ParseTuning(StandardTuning)
+	s := NewSong("", "")
+	tr, _ := NewTrack("", tun, 24)
+	tr.Add(
+		HammerOn{FretFrom: 5, FretTo: 7, String: 0, Time: 0, Duration: 0.5},
+		Slide{FretStart: -1, FretEnd: 5, String: 1, Time: 0.5, Duration: 0.25},
+		Harmonic{Fret: 12, String: 5, Time: 0.75, Duration: 0.25},
+		BendRelease{Fret: 7, Cents: FullStep, String: 2, Time: 1, Duration: 0.5},
+		Articulate(DeadNote{String: 4, Time: 1.5, Duration: 0.25}, PalmMute),
+		NewLegato(5, 3, 1.75).SlideTo(7, 0.125),
+	)
+	s.AddTrack(tr)
+
+	score := writeTestMusicXML(t, s)
+	tab := staffNotes(score.Parts[0].Measures[0].Notes, tabStaff)
+	assert.Len(t, tab, 8)
+
+	assert.Equal(t, []mxlLegato{{Type: "start", Number: 1, Text: "H"}}, tab[0].HammerOns)
+	assert.Equal(t, []mxlLegato{{Type: "stop", Number: 1}}, tab[1].HammerOns)
+	assert.Equal(t, 7, *tab[1].Fret)
+
+	assert.NotNil(t, tab[2].Scoop)
+	assert.Equal(t, 5, *tab[2].Fret)
+
+	assert.NotNil(t, tab[3].Harmonic)
+	assert.Equal(t, "E", tab[3].Step)
+	assert.Equal(t, 3, tab[3].Octave)
+
+	assert.Equal(t, []mxlBend{{Alter: 2}, {Alter: -2, Release: &mxlEmpty{}}}, tab[4].Bends)
+
+	assert.Equal(t, "x", tab[5].Notehead)
+	assert.Equal(t, []string{"P.M."}, tab[5].Other)
+
+	assert.Equal(t, []mxlLine{{Type: "start", Number: 4, LineType: "solid"}}, tab[6].Slides)
+	assert.Equal(t, []mxlLine{{Type: "stop", Number: 4, LineType: "solid"}}, tab[7].Slides)
+	assert.Equal(t, 7, *tab[7].Fret)
+
+	// the legato marks stay on the TAB staff
+	notation := staffNotes(score.Parts[0].Measures[0].Notes, notationStaff)
+	assert.Empty(t, notation[0].HammerOns)
+	assert.Equal(t, "x", notation[5].Notehead)
+}
+
+func TestWriteMusicXMLTies(t *testing.T) {
+	tun, _ := ParseTuning(StandardTuning)
+	s := NewSong("", "")
+	s.SetTimeSignature(0, TimeSignature{2, 4})
+	tr, _ := NewTrack("", tun, 24)
+	// a dotted half at 120 bpm over a 2/4 bar line
+	tr.Add(Chord{Frets: []int{0, 1, ChordSkip, ChordSkip, ChordSkip, ChordSkip}, Time: 0.5, Duration: 1.5})
+	s.AddTrack(tr)
+
+	score := writeTestMusicXML(t, s)
+	measures := score.Parts[0].Measures
+	assert.Len(t, measures, 2)
+
+	first := staffNotes(measures[0].Notes, notationStaff)
+	assert.NotNil(t, first[0].Rest)
+	assert.Equal(t, []mxlTie{{Type: "start"}}, first[1].Ties)
+	assert.NotNil(t, first[2].Chord)
+	assert.Equal(t, []mxlTie{{Type: "start"}}, first[2].Ties)
+
+	second := staffNotes(measures[1].Notes, notationStaff)
+	assert.Equal(t, []mxlTie{{Type: "stop"}}, second[0].Ties)
+	assert.Equal(t, "half", second[0].Type)
+}
+
+func TestWriteMusicXMLErrors(t *testing.T) {
+	tun, _ := ParseTuning(StandardTuning)
+
+	s := NewSong("", "")
+	s.Key = "H"
+	assert.Error(t, s.WriteMusicXML(&bytes.Buffer{}))
+
+	s = NewSong("", "")
+	tr, _ := NewTrack("", tun, 24)
+	tr.Add(Note{Fret: 0, String: 7})
+	s.AddTrack(tr)
+	assert.Error(t, s.WriteMusicXML(&bytes.Buffer{}))
+
+	// eighth note triplets are off the grid of the score
+	for _, triplet := range []Playable{
+		Note{Fret: 5, String: 0, Time: 0, Duration: 1.0 / 6},
+		Note{Fret: 5, String: 0, Time: 1.0 / 6},
+		NewLegato(5, 0, 0).HammerOn(7, 1.0/6),
+	} {
+		s = NewSong("", "")
+		tr, _ = NewTrack("", tun, 24)
+		tr.Add(triplet)
+		s.AddTrack(tr)
+		assert.ErrorContains(t, s.WriteMusicXML(&bytes.Buffer{}), "tuplets are not supported")
+		assert.Error(t, s.WriteLilyPond(&bytes.Buffer{}))
+	}
+}
@@ -0,0 +1,440 @@
+package guitar
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+const defaultMusicXMLFrets = 24
+
+type MusicXMLReadOption func(*musicXMLReader)
+
+// WithMusicXMLFingerBoard fingers the parts without TAB data on fb. The
+// default is a 24 fret board in standard tuning.
+func WithMusicXMLFingerBoard(fb *FingerBoard) MusicXMLReadOption {
+	return func(r *musicXMLReader) {
+		r.fb = fb
+	}
+}
+
+type musicXMLReader struct {
+	quarterTiming
+
+	fb *FingerBoard
+}
+
+// placedNote is a note of a part in quarter notes from the start.
+type placedNote struct {
+	start, length float64
+	note          mxlNote
+}
+
+// xmlPart is what a part holds once its measures are read.
+type xmlPart struct {
+	name   string
+	notes  []placedNote
+	tuning Tuning
+	capo   int
+	hasTab bool
+}
+
+// ReadMusicXML reads a partwise MusicXML score into a Song with a track
+// per part. Notes with string and fret technical elements keep their
+// fingering and techniques; parts without them are fingered on the
+// board given by WithMusicXMLFingerBoard.
+func ReadMusicXML(r io.Reader, opts ...MusicXMLReadOption) (*Song, error) {
+	mr := &musicXMLReader{}
+	for _, opt := range opts {
+		opt(mr)
+	}
+	if mr.fb == nil {
+		tuning, _ := ParseTuning(StandardTuning)
+		mr.fb, _ = NewFingerBoard(tuning, defaultMusicXMLFrets)
+	}
+
+	score := mxlScore{}
+	if err := xml.NewDecoder(r).Decode(&score); err != nil {
+		if strings.Contains(err.Error(), "score-timewise") {
+			return nil, errors.New("timewise MusicXML scores are not supported")
+		}
+		return nil, err
+	}
+
+	song := &Song{Title: score.MovementTitle}
+	if score.Work != nil && score.Work.Title != "" {
+		song.Title = score.Work.Title
+	}
+	if score.Identification != nil {
+		for _, c := range score.Identification.Creators {
+			if c.Type == "composer" || song.Artist == "" {
+				song.Artist = c.Name
+			}
+		}
+	}
+
+	names := map[string]string{}
+	for _, p := range score.PartList.Parts {
+		names[p.ID] = p.Name
+	}
+
+	parts := []xmlPart{}
+	for _, p := range score.Parts {
+		part, err := mr.readPart(p, song)
+		if err != nil {
+			return nil, fmt.Errorf("part %q: %w", p.ID, err)
+		}
+		part.name = names[p.ID]
+		parts = append(parts, part)
+	}
+	song.Timing = mr.timing()
+
+	for _, p := range parts {
+		track, err := mr.track(p)
+		if err != nil {
+			return nil, fmt.Errorf("part %q: %w", p.name, err)
+		}
+		song.AddTrack(track)
+	}
+	return song, nil
+}
+
+// readPart walks the measures of a part, keeping every note with its
+// position. Measures start where the longest voice of the previous one
+// stopped.
+func (mr *musicXMLReader) readPart(p mxlPart, song *Song) (xmlPart, error) {
+	part := xmlPart{}
+	divisions := 1.0
+	measureStart, pos, lastStart := 0.0, 0.0, 0.0
+
+	for _, m := range p.Measures {
+		pos = measureStart
+		measureEnd := measureStart
+
+		for _, item := range m.Items {
+			switch v := item.(type) {
+			case mxlAttributes:
+				if v.Divisions > 0 {
+					divisions = float64(v.Divisions)
+				}
+				if v.Time != nil {
+					ts := TimeSignature{Beats: v.Time.Beats, BeatUnit: v.Time.BeatType}
+					if err := ts.Validate(); err == nil {
+						mr.addSignature(pos, ts)
+					}
+				}
+				if v.Key != nil && song.Key == "" {
+					song.Key = keyName(v.Key.Fifths, v.Key.Mode == "minor")
+				}
+				if err := part.readStaffDetails(v.StaffDetails); err != nil {
+					return part, err
+				}
+
+			case mxlDirection:
+				if v.Sound != nil && v.Sound.Tempo > 0 {
+					at := pos + float64(v.Offset)/divisions
+					mr.addTempo(at, v.Sound.Tempo)
+				}
+
+			case mxlBackup:
+				pos -= float64(v.Duration) / divisions
+			case mxlForward:
+				pos += float64(v.Duration) / divisions
+
+			case mxlNote:
+				if v.Grace != nil {
+					continue
+				}
+				length := float64(v.Duration) / divisions
+				start := pos
+				if v.Chord != nil {
+					start = lastStart
+				} else {
+					lastStart = pos
+					pos += length
+				}
+				if v.Rest != nil || v.Pitch == nil {
+					break
+				}
+				if isTabNote(v) {
+					part.hasTab = true
+				}
+				part.notes = append(part.notes, placedNote{start: start, length: length, note: v})
+			}
+			measureEnd = max(measureEnd, pos)
+		}
+		measureStart = measureEnd
+	}
+	return part, nil
+}
+
+func (p *xmlPart) readStaffDetails(details []mxlStaffDetails) error {
+	for _, d := range details {
+		if len(d.Tuning) == 0 {
+			continue
+		}
+		lines := d.StaffLines
+		if lines == 0 {
+			lines = len(d.Tuning)
+		}
+
+		tuning := make(Tuning, lines)
+		for _, t := range d.Tuning {
+			if t.Line < 1 || t.Line > lines {
+				return fmt.Errorf("invalid staff tuning line %d", t.Line)
+			}
+			name, octave := stepName(t.Step, t.Alter, t.Octave)
+			// line 1 is the lowest string, our string 0 the highest
+			s := lines - t.Line
+			tuning[s] = Note{Name: name, Octave: octave, String: s}
+		}
+		for i, n := range tuning {
+			if n.Name == "" {
+				return fmt.Errorf("no tuning for staff line %d", lines-i)
+			}
+		}
+		p.tuning, p.capo = tuning, d.Capo
+	}
+	return nil
+}
+
+func isTabNote(n mxlNote) bool {
+	return n.Notations != nil && n.Notations.Technical != nil &&
+		n.Notations.Technical.String > 0 && n.Notations.Technical.Fret != nil
+}
+
+var stepIndex = map[string]int{"C": 0, "D": 2, "E": 4, "F": 5, "G": 7, "A": 9, "B": 11}
+
+// stepName returns the note name of a step and alter, moving to the
+// next octave when a B is raised or a C lowered.
+func stepName(step string, alter float64, octave int) (string, int) {
+	return pitchNote((octave+1)*12 + stepIndex[strings.ToUpper(step)] + int(math.Round(alter)))
+}
+
+func xmlPitch(p *mxlPitch) int {
+	return (p.Octave+1)*12 + stepIndex[strings.ToUpper(p.Step)] + int(math.Round(p.Alter))
+}
+
+var (
+	majorKeyNames = []string{"Cb", "Gb", "Db", "Ab", "Eb", "Bb", "F", "C", "G", "D", "A", "E", "B", "F#", "C#"}
+	minorKeyNames = []string{"Ab", "Eb", "Bb", "F", "C", "G", "D", "A", "E", "B", "F#", "C#", "G#", "D#", "A#"}
+)
+
+// keyName is the name of a key signature, "Am" for no sharps in minor.
+func keyName(fifths int, minor bool) string {
+	if fifths < -7 || fifths > 7 {
+		return ""
+	}
+	if minor {
+		return minorKeyNames[fifths+7] + "m"
+	}
+	return majorKeyNames[fifths+7]
+}
+
+func (mr *musicXMLReader) track(p xmlPart) (*Track, error) {
+	tuning, frets := p.tuning, defaultMusicXMLFrets
+	if len(tuning) == 0 {
+		tuning, frets = mr.fb.tuning, mr.fb.frets
+	}
+	track, err := NewTrack(p.name, tuning, frets)
+	if err != nil {
+		return nil, err
+	}
+	track.Capo = p.capo
+
+	if !p.hasTab {
+		fb, err := NewFingerBoard(tuning, frets)
+		if err != nil {
+			return nil, err
+		}
+		events, err := fb.finger(mr.fingerKeys(p.notes))
+		if err != nil {
+			return nil, err
+		}
+		track.Add(events...)
+		return track, nil
+	}
+
+	events, err := mr.tabEvents(p.notes, len(tuning))
+	if err != nil {
+		return nil, err
+	}
+	track.Add(events...)
+	return track, nil
+}
+
+// fingerKeys turns notes into keys, a tied note lengthening the key it
+// continues.
+func (mr *musicXMLReader) fingerKeys(notes []placedNote) []fingerKey {
+	keys := []fingerKey{}
+	ends := map[int]float64{}
+	last := map[int]int{}
+
+	for _, n := range notes {
+		pitch := xmlPitch(n.note.Pitch)
+		if i, ok := last[pitch]; ok && hasTie(n.note, "stop") && math.Abs(ends[pitch]-n.start) < timeEpsilon {
+			ends[pitch] = n.start + n.length
+			keys[i].length = mr.seconds(ends[pitch]) - keys[i].start
+			continue
+		}
+
+		start := mr.seconds(n.start)
+		last[pitch], ends[pitch] = len(keys), n.start+n.length
+		keys = append(keys, fingerKey{start: start, length: mr.seconds(n.start+n.length) - start, pitch: pitch})
+	}
+	return keys
+}
+
+func hasTie(n mxlNote, kind string) bool {
+	for _, t := range n.Ties {
+		if t.Type == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// tabEvents reads the notes of the TAB staff, joining tied notes and
+// legato links on each string.
+func (mr *musicXMLReader) tabEvents(notes []placedNote, stringCount int) ([]Playable, error) {
+	tl := newTabLinks()
+	for _, n := range notes {
+		if !isTabNote(n.note) {
+			continue
+		}
+		tech := n.note.Notations.Technical
+		stringNumber, fret := tech.String-1, *tech.Fret
+		if stringNumber >= stringCount {
+			return nil, fmt.Errorf("string %d is out of the %d strings", tech.String, stringCount)
+		}
+		start := mr.seconds(n.start)
+		end := mr.seconds(n.start + n.length)
+
+		if hasTie(n.note, "stop") && tl.tie(stringNumber, start, end) {
+			continue
+		}
+
+		linked := false
+		if kind, ok := linkKind(n.note, "stop"); ok {
+			pending, ok := tl.pendingLink(stringNumber, start)
+			linked = ok && pending == kind && tl.link(stringNumber, kind, fret, start, end)
+		}
+		if !linked {
+			tl.add(stringNumber, tabPlayable(n.note, stringNumber, fret, start, end-start), end)
+		}
+		if kind, ok := linkKind(n.note, "start"); ok {
+			tl.startLink(stringNumber, kind)
+		}
+	}
+	return tl.events, nil
+}
+
+// linkKind returns the hammer-on, pull-off or slide a note starts or
+// stops.
+func linkKind(n mxlNote, kind string) (LegatoKind, bool) {
+	notations := n.Notations
+	for _, s := range notations.Slides {
+		if s.Type == kind {
+			return LegatoSlide, true
+		}
+	}
+	for _, h := range notations.Technical.HammerOns {
+		if h.Type == kind {
+			return LegatoHammerOn, true
+		}
+	}
+	for _, p := range notations.Technical.PullOffs {
+		if p.Type == kind {
+			return LegatoPullOff, true
+		}
+	}
+	return 0, false
+}
+
+// tabPlayable builds the Playable of a TAB note from its technical
+// elements, noteheads, articulations and ornaments.
+func tabPlayable(n mxlNote, stringNumber, fret int, time, duration float32) Playable {
+	notations := n.Notations
+	tech := notations.Technical
+
+	var p Playable = Note{Fret: fret, String: stringNumber, Time: time, Duration: duration}
+	switch {
+	case n.Notehead != nil && n.Notehead.Value == "x":
+		p = DeadNote{String: stringNumber, Time: time, Duration: duration}
+	case tech.Harmonic != nil && tech.Harmonic.Artificial != nil:
+		p = ArtificialHarmonic{Fret: fret, Offset: 12, String: stringNumber, Time: time, Duration: duration}
+	case tech.Harmonic != nil:
+		p = Harmonic{Fret: fret, String: stringNumber, Time: time, Duration: duration}
+	case tech.Tap != nil:
+		p = Tap{Fret: fret, String: stringNumber, Time: time, Duration: duration}
+	case len(tech.Bends) > 0:
+		p = bendFromMarks(tech.Bends, fret, stringNumber, time, duration)
+	case notations.Articulations != nil && notations.Articulations.Scoop != nil:
+		p = Slide{FretStart: -1, FretEnd: fret, String: stringNumber, Time: time, Duration: duration}
+	case notations.Ornaments != nil && notations.Ornaments.Tremolo != nil:
+		division := 4 << max(1, notations.Ornaments.Tremolo.Marks)
+		p = Tremolo{Fret: fret, Division: division, String: stringNumber, Time: time, Duration: duration}
+	case notations.Ornaments != nil && notations.Ornaments.TrillMark != nil:
+		p = Trill{Fret: fret, TrillFret: fret + 2, String: stringNumber, Time: time, Duration: duration}
+	}
+
+	var a Articulation
+	if n.Notehead != nil && n.Notehead.Parentheses == "yes" {
+		a |= Ghost
+	}
+	if notations.Articulations != nil {
+		if notations.Articulations.Accent != nil {
+			a |= Accent
+		}
+		if notations.Articulations.Staccato != nil {
+			a |= Staccato
+		}
+	}
+	if notations.Ornaments != nil && len(notations.Ornaments.WavyLines) > 0 {
+		a |= Vibrato
+	}
+	for _, other := range tech.OtherTechnical {
+		switch strings.ToLower(strings.TrimSpace(other)) {
+		case "p.m.", "pm", "palm mute":
+			a |= PalmMute
+		case "let ring":
+			a |= LetRing
+		}
+	}
+
+	if a != 0 {
+		return Articulate(p, a)
+	}
+	return p
+}
+
+// bendFromMarks reads MusicXML bends, each bend-alter moving the pitch
+// from where the previous one left it.
+func bendFromMarks(marks []mxlBend, fret, stringNumber int, time, duration float32) Playable {
+	cents := func(m mxlBend) int { return int(math.Round(m.Alter * 100)) }
+
+	switch {
+	case len(marks) == 1 && marks[0].PreBend != nil:
+		return PreBend{Fret: fret, Cents: cents(marks[0]), String: stringNumber, Time: time, Duration: duration}
+	case len(marks) == 1:
+		return Bend{Fret: fret, Cents: cents(marks[0]), String: stringNumber, Time: time, Duration: duration}
+	case len(marks) == 2 && marks[0].PreBend == nil && marks[1].Release != nil:
+		return BendRelease{Fret: fret, Cents: cents(marks[0]), Release: cents(marks[0]) + cents(marks[1]),
+			String: stringNumber, Time: time, Duration: duration}
+	}
+
+	curve := BendCurve{{Position: 0, Cents: 0}}
+	level := 0
+	for i, m := range marks {
+		level = max(0, level+cents(m))
+		if i == 0 && m.PreBend != nil {
+			curve[0].Cents = level
+			continue
+		}
+		curve = append(curve, BendPoint{Position: float32(i+1) / float32(len(marks)), Cents: level})
+	}
+	return CompoundBend{Fret: fret, Curve: curve, String: stringNumber, Time: time, Duration: duration}
+}
@@ -0,0 +1,140 @@
+package guitar
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readTestMusicXML(t *testing.T, s *Song, opts ...MusicXMLReadOption) *Song {
+	t.Helper()
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, s.WriteMusicXML(&buf))
+	song, err := ReadMusicXML(&buf, opts...)
+	assert.NoError(t, err)
+	return song
+}
+
+func TestReadMusicXMLRoundTrip(t *testing.T) {
+	s := testSong(t)
+	song := readTestMusicXML(t, s)
+
+	assert.Equal(t, "Etude", song.Title)
+	assert.Equal(t, "Anon", song.Artist)
+	assert.Equal(t, "Am", song.Key)
+	assert.Equal(t, s.Timing, song.Timing)
+
+	assert.Len(t, song.Tracks, 1)
+	tr := song.Tracks[0]
+	assert.Equal(t, "Guitar", tr.Name)
+	assert.Equal(t, s.Tracks[0].Tuning, tr.Tuning)
+	assert.Equal(t, 2, tr.Capo)
+
+	// notes without duration come back ringing until the next one
+	assert.Equal(t, Playables{
+		Note{Fret: 3, String: 0, Time: 0, Duration: 1.25},
+		Note{Fret: 5, String: 1, Time: 1.25, Duration: 0.5},
+		Note{Fret: 7, String: 2, Time: 2.5, Duration: 1},
+	}, tr.Events)
+}
+
+func TestReadMusicXMLTechniques(t *testing.T) {
+	tun, _ := ParseTuning(StandardTuning)
+	s := NewSong("", "")
+	tr, _ := NewTrack("", tun, 24)
+	legato := NewLegato(5, 3, 1.75).HammerOn(7, 0.125).PullOff(5, 0.25)
+	legato.Duration = 0.5
+	events := Playables{
+		HammerOn{FretFrom: 5, FretTo: 7, String: 0, Time: 0, Duration: 0.5},
+		Slide{FretStart: -1, FretEnd: 5, String: 1, Time: 0.5, Duration: 0.25},
+		Harmonic{Fret: 12, String: 5, Time: 0.75, Duration: 0.25},
+		BendRelease{Fret: 7, Cents: FullStep, Release: 0, String: 2, Time: 1, Duration: 0.5},
+		Articulate(DeadNote{String: 4, Time: 1.5, Duration: 0.25}, PalmMute),
+		legato,
+		Articulate(Note{Fret: 3, String: 1, Time: 2.25, Duration: 0.25}, Accent|Staccato),
+	}
+	tr.Add(events...)
+	s.AddTrack(tr)
+
+	song := readTestMusicXML(t, s)
+	assert.Equal(t, events, song.Tracks[0].Events)
+}
+
+func TestReadMusicXMLFingering(t *testing.T) {
+	// a part of an arranger, pitches only
+	score := `<?xml version="1.0" encoding="UTF-8"?>
+<score-partwise version="4.0">
+  <work><work-title>Air</work-title></work>
+  <part-list><score-part id="P1"><part-name>Lute</part-name></score-part></part-list>
+  <part id="P1">
+    <measure number="1">
+      <attributes>
+        <divisions>2</divisions>
+        <key><fifths>1</fifths></key>
+        <time><beats>3</beats><beat-type>4</beat-type></time>
+      </attributes>
+      <direction><sound tempo="60"/></direction>
+      <note><pitch><step>E</step><octave>4</octave></pitch><duration>2</duration><type>quarter</type></note>
+      <note><chord/><pitch><step>E</step><octave>2</octave></pitch><duration>2</duration><type>quarter</type></note>
+      <note><grace/><pitch><step>F</step><alter>1</alter><octave>4</octave></pitch><type>eighth</type></note>
+      <note><pitch><step>G</step><octave>4</octave></pitch><duration>4</duration><tie type="start"/><type>half</type></note>
+    </measure>
+    <measure number="2">
+      <note><pitch><step>G</step><octave>4</octave></pitch><duration>2</duration><tie type="stop"/><type>quarter</type></note>
+      <note><rest/><duration>4</duration></note>
+    </measure>
+  </part>
+</score-partwise>`
+
+	song, err := ReadMusicXML(strings.NewReader(score))
+	assert.NoError(t, err)
+	assert.Equal(t, "Air", song.Title)
+	assert.Equal(t, "G", song.Key)
+	assert.Equal(t, []TempoChange{{BPM: 60}}, song.Tempos)
+	assert.Equal(t, []TimeSignatureChange{{Signature: TimeSignature{3, 4}}}, song.TimeSignatures)
+
+	tr := song.Tracks[0]
+	assert.Equal(t, "Lute", tr.Name)
+	assert.Equal(t, Playables{
+		Note{Name: "E", Octave: 4, Fret: 0, String: 0, Time: 0, Duration: 1},
+		Note{Name: "E", Octave: 2, Fret: 0, String: 5, Time: 0, Duration: 1},
+		Note{Name: "G", Octave: 4, Fret: 3, String: 0, Time: 1, Duration: 3},
+	}, tr.Events)
+
+	tab, err := song.Tab(0)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(tab, "e|0----3-|"), tab)
+}
+
+func TestReadMusicXMLErrors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		score string
+	}{
+		{name: "not xml", score: "MThd"},
+		{name: "timewise", score: `<score-timewise version="4.0"></score-timewise>`},
+		{name: "tuning line", score: `<score-partwise><part id="P1"><measure><attributes><staff-details>
+			<staff-lines>6</staff-lines><staff-tuning line="7"><tuning-step>E</tuning-step><tuning-octave>2</tuning-octave></staff-tuning>
+			</staff-details></attributes></measure></part></score-partwise>`},
+		{name: "out of range", score: `<score-partwise><part id="P1"><measure><attributes><divisions>1</divisions></attributes>
+			<note><pitch><step>C</step><octave>1</octave></pitch><duration>1</duration></note></measure></part></score-partwise>`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ReadMusicXML(strings.NewReader(tc.score))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestKeyName(t *testing.T) {
+	assert.Equal(t, "C", keyName(0, false))
+	assert.Equal(t, "Am", keyName(0, true))
+	assert.Equal(t, "Bb", keyName(-2, false))
+	assert.Equal(t, "F#m", keyName(3, true))
+	assert.Equal(t, "", keyName(8, false))
+}
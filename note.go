@@ -15,17 +15,18 @@ const (
 var notesChromo = []string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
 
 type Note struct {
-	Name   string
-	Octave int
+	Name   string `json:"name,omitempty" yaml:"name,omitempty"`
+	Octave int    `json:"octave,omitempty" yaml:"octave,omitempty"`
 
-	Fret   int
-	String int
+	Fret   int `json:"fret" yaml:"fret"`
+	String int `json:"string" yaml:"string"`
 
-	Time float32
+	Time     float32 `json:"time,omitempty" yaml:"time,omitempty"`
+	Duration float32 `json:"duration,omitempty" yaml:"duration,omitempty"`
 }
 
 func (n Note) TabSymbol() string {
-	return fmt.Sprintf("%d", n.Fret)
+	return noteTechnique.Format(n.Fret)
 }
 
 func (n Note) StringNumber() int {
@@ -36,6 +37,15 @@ func (n Note) StartTime() float32 {
 	return n.Time
 }
 
+func (n Note) EndTime() float32 {
+	return n.Time + n.Duration
+}
+
+func (n Note) AtTime(t float32) Playable {
+	n.Time = t
+	return n
+}
+
 func (n *Note) AddFret() error {
 	found := -1
 
@@ -0,0 +1,98 @@
+package guitar
+
+import (
+	"math"
+	"strings"
+)
+
+// rhythmValues are the note values known to the rhythm line, measured
+// in quarter notes.
+var rhythmValues = []struct {
+	quarters float64
+	symbol   string
+}{
+	{4, "w"},
+	{3, "h."},
+	{2, "h"},
+	{1.5, "q."},
+	{1, "q"},
+	{0.75, "e."},
+	{0.5, "e"},
+	{0.375, "s."},
+	{0.25, "s"},
+	{0.125, "t"},
+}
+
+// RhythmSymbol returns the symbol of the note value closest to the given
+// length in quarter notes, or an empty string for non-positive lengths.
+func RhythmSymbol(quarters float32) string {
+	if quarters <= 0 {
+		return ""
+	}
+
+	symbol := ""
+	minDist := math.MaxFloat64
+	for _, v := range rhythmValues {
+		dist := math.Abs(math.Log2(float64(quarters) / v.quarters))
+		if dist < minDist {
+			minDist = dist
+			symbol = v.symbol
+		}
+	}
+
+	return symbol
+}
+
+// layoutColumns returns a copy of the written columns ready to be rendered.
+func (tb *TabWriter) layoutColumns() []tabColumn {
+	columns := tb.closedColumns()
+
+	if tb.rhythm {
+		tb.setRhythm(columns)
+	}
+
+	return columns
+}
+
+// setRhythm labels every note column with its note value. Columns whose
+// notes carry no duration last until the next note column.
+func (tb *TabWriter) setRhythm(columns []tabColumn) {
+	for i := range columns {
+		if len(columns[i].notes) == 0 {
+			continue
+		}
+
+		length := columnDuration(columns[i])
+		if length <= 0 {
+			for _, next := range columns[i+1:] {
+				if len(next.notes) > 0 {
+					length = next.time - columns[i].time
+					break
+				}
+			}
+		}
+
+		columns[i].rhythm = RhythmSymbol(tb.timing.Beats(columns[i].time, length, tb.tempo))
+	}
+}
+
+func columnDuration(col tabColumn) float32 {
+	var length float32
+	for _, n := range col.notes {
+		if s, ok := n.(Sustained); ok {
+			length = max(length, s.EndTime()-s.StartTime())
+		}
+	}
+	return length
+}
+
+func (tb *TabWriter) renderRhythm(columns []tabColumn) string {
+	line := strings.Builder{}
+	line.WriteString(strings.Repeat(" ", tb.lineStart(columns)))
+
+	for _, col := range columns {
+		line.WriteString(col.rhythm + strings.Repeat(" ", col.width()-len(col.rhythm)))
+	}
+
+	return strings.TrimRight(line.String(), " ")
+}
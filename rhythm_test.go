@@ -0,0 +1,58 @@
+package guitar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRhythmSymbol(t *testing.T) {
+	testCases := []struct {
+		name     string
+		quarters float32
+		expected string
+	}{
+		{name: "whole", quarters: 4, expected: "w"},
+		{name: "quarter", quarters: 1, expected: "q"},
+		{name: "dotted quarter", quarters: 1.5, expected: "q."},
+		{name: "sixteenth", quarters: 0.25, expected: "s"},
+		{name: "close to eighth", quarters: 0.48, expected: "e"},
+		{name: "no length", quarters: 0, expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, RhythmSymbol(tc.quarters))
+		})
+	}
+}
+
+func TestTabRhythm(t *testing.T) {
+	t.Run("from durations", func(t *testing.T) {
+		tb, _ := NewTabWriter([]string{"e", "B", "G"}, WithRhythm(), WithTimeStep(0.25), WithTimeSignature(4, 4))
+
+		err := tb.WriteNotes(
+			Note{Fret: 3, String: 0, Time: 0, Duration: 0.5},
+			Note{Fret: 5, String: 1, Time: 0.5, Duration: 0.25},
+			Note{Fret: 7, String: 2, Time: 0.75, Duration: 0.25},
+			Note{Fret: 10, String: 2, Time: 1, Duration: 0.125},
+			Note{Fret: 12, String: 0, Time: 1.125, Duration: 0.75},
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "  q ees q.\ne|3-----12-|\nB|--5------|\nG|---710---|\n", tb.Tab())
+	})
+
+	t.Run("from note spacing", func(t *testing.T) {
+		tb, _ := NewTabWriter([]string{"e", "B"}, WithRhythm())
+
+		err := tb.WriteNotes(
+			Note{Fret: 3, String: 0, Time: 0},
+			Note{Fret: 5, String: 1, Time: 0.5},
+			Note{Fret: 7, String: 0, Time: 0.75},
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "  q e\ne|3--7-\nB|--5--\n", tb.Tab())
+	})
+}
@@ -0,0 +1,324 @@
+package guitar
+
+import (
+	"fmt"
+	"math"
+)
+
+// harmonicPartials maps the frets with a harmonic node to the partial
+// ringing there: 2 is the octave, 3 the octave and a fifth.
+var harmonicPartials = map[int]int{
+	12: 2,
+	7:  3, 19: 3,
+	5: 4, 24: 4,
+	4: 5, 9: 5, 16: 5,
+	3: 6,
+}
+
+// defaultPinchPartial is the partial a pinch harmonic sounds when none
+// is given.
+const defaultPinchPartial = 3
+
+// defaultStrokeDivision is the speed of tremolos and trills without a
+// division, in notes per whole note.
+const defaultStrokeDivision = 32
+
+// harmonicSemitones returns how many semitones the partial sounds above
+// the fundamental.
+func harmonicSemitones(partial int) int {
+	return int(math.Round(12 * math.Log2(float64(partial))))
+}
+
+func harmonicPartial(fret int) (int, error) {
+	partial, ok := harmonicPartials[fret]
+	if !ok {
+		return 0, fmt.Errorf("no harmonic at fret %d", fret)
+	}
+	return partial, nil
+}
+
+// Tap is a note fretted by the picking hand, e.g. "t12".
+type Tap struct {
+	Fret   int `json:"fret" yaml:"fret"`
+	String int `json:"string" yaml:"string"`
+
+	Time     float32 `json:"time,omitempty" yaml:"time,omitempty"`
+	Duration float32 `json:"duration,omitempty" yaml:"duration,omitempty"`
+}
+
+func (t Tap) TabSymbol() string {
+	return tapTechnique.Format(t.Fret)
+}
+
+func (t Tap) StringNumber() int {
+	return t.String
+}
+
+func (t Tap) StartTime() float32 {
+	return t.Time
+}
+
+func (t Tap) EndTime() float32 {
+	return t.Time + t.Duration
+}
+
+func (t Tap) AtTime(time float32) Playable {
+	t.Time = time
+	return t
+}
+
+// PinchHarmonic is a fretted note picked with the thumb brushing the
+// string, e.g. "5ph". Partial is the harmonic it rings at, 3 when zero.
+type PinchHarmonic struct {
+	Fret    int `json:"fret" yaml:"fret"`
+	Partial int `json:"partial,omitempty" yaml:"partial,omitempty"`
+
+	String int `json:"string" yaml:"string"`
+
+	Time     float32 `json:"time,omitempty" yaml:"time,omitempty"`
+	Duration float32 `json:"duration,omitempty" yaml:"duration,omitempty"`
+}
+
+func (h PinchHarmonic) TabSymbol() string {
+	return pinchHarmonicTechnique.Format(h.Fret)
+}
+
+func (h PinchHarmonic) StringNumber() int {
+	return h.String
+}
+
+func (h PinchHarmonic) StartTime() float32 {
+	return h.Time
+}
+
+func (h PinchHarmonic) EndTime() float32 {
+	return h.Time + h.Duration
+}
+
+func (h PinchHarmonic) AtTime(t float32) Playable {
+	h.Time = t
+	return h
+}
+
+func (h PinchHarmonic) partial() int {
+	if h.Partial == 0 {
+		return defaultPinchPartial
+	}
+	return h.Partial
+}
+
+// ArtificialHarmonic is a fretted note whose string is touched Offset
+// frets higher, e.g. "5<17>" for an octave harmonic of fret 5.
+type ArtificialHarmonic struct {
+	Fret   int `json:"fret" yaml:"fret"`
+	Offset int `json:"offset" yaml:"offset"`
+
+	String int `json:"string" yaml:"string"`
+
+	Time     float32 `json:"time,omitempty" yaml:"time,omitempty"`
+	Duration float32 `json:"duration,omitempty" yaml:"duration,omitempty"`
+}
+
+func (h ArtificialHarmonic) TabSymbol() string {
+	return artificialHarmonicTechnique.Format(h.Fret, h.Fret+h.Offset)
+}
+
+func (h ArtificialHarmonic) StringNumber() int {
+	return h.String
+}
+
+func (h ArtificialHarmonic) StartTime() float32 {
+	return h.Time
+}
+
+func (h ArtificialHarmonic) EndTime() float32 {
+	return h.Time + h.Duration
+}
+
+func (h ArtificialHarmonic) AtTime(t float32) Playable {
+	h.Time = t
+	return h
+}
+
+// Tremolo is a note picked repeatedly as fast as Division notes per
+// whole note, 32 when zero, e.g. "5///".
+type Tremolo struct {
+	Fret     int `json:"fret" yaml:"fret"`
+	Division int `json:"division,omitempty" yaml:"division,omitempty"`
+
+	String int `json:"string" yaml:"string"`
+
+	Time     float32 `json:"time,omitempty" yaml:"time,omitempty"`
+	Duration float32 `json:"duration,omitempty" yaml:"duration,omitempty"`
+}
+
+func (t Tremolo) TabSymbol() string {
+	return tremoloTechnique.Format(t.Fret)
+}
+
+func (t Tremolo) StringNumber() int {
+	return t.String
+}
+
+func (t Tremolo) StartTime() float32 {
+	return t.Time
+}
+
+func (t Tremolo) EndTime() float32 {
+	return t.Time + t.Duration
+}
+
+func (t Tremolo) AtTime(time float32) Playable {
+	t.Time = time
+	return t
+}
+
+// Notes returns the picked notes of the tremolo at tempo.
+func (t Tremolo) Notes(tempo float32) []Playable {
+	step := strokeLength(t.Division, tempo)
+
+	notes := []Playable{}
+	for _, time := range strokes(t.Time, t.Duration, t.Division, tempo) {
+		notes = append(notes, Note{Fret: t.Fret, String: t.String, Time: time, Duration: min(step, t.EndTime()-time)})
+	}
+	return notes
+}
+
+// Trill alternates quickly between Fret and TrillFret with hammer-ons
+// and pull-offs, Division notes per whole note, 32 when zero, e.g. "5tr7".
+type Trill struct {
+	Fret      int `json:"fret" yaml:"fret"`
+	TrillFret int `json:"trill_fret" yaml:"trill_fret"`
+	Division  int `json:"division,omitempty" yaml:"division,omitempty"`
+
+	String int `json:"string" yaml:"string"`
+
+	Time     float32 `json:"time,omitempty" yaml:"time,omitempty"`
+	Duration float32 `json:"duration,omitempty" yaml:"duration,omitempty"`
+}
+
+func (t Trill) TabSymbol() string {
+	return trillTechnique.Format(t.Fret, t.TrillFret)
+}
+
+func (t Trill) StringNumber() int {
+	return t.String
+}
+
+func (t Trill) StartTime() float32 {
+	return t.Time
+}
+
+func (t Trill) EndTime() float32 {
+	return t.Time + t.Duration
+}
+
+func (t Trill) AtTime(time float32) Playable {
+	t.Time = time
+	return t
+}
+
+// Notes returns the trill at tempo as a picked note followed by
+// alternating hammer-ons and pull-offs.
+func (t Trill) Notes(tempo float32) []Playable {
+	step := strokeLength(t.Division, tempo)
+
+	notes := []Playable{}
+	for i, time := range strokes(t.Time, t.Duration, t.Division, tempo) {
+		length := min(step, t.EndTime()-time)
+		switch {
+		case i == 0:
+			notes = append(notes, Note{Fret: t.Fret, String: t.String, Time: time, Duration: length})
+		case i%2 == 1:
+			notes = append(notes, HammerOn{FretFrom: t.Fret, FretTo: t.TrillFret, String: t.String, Time: time, Duration: length})
+		default:
+			notes = append(notes, PullOff{FretFrom: t.TrillFret, FretTo: t.Fret, String: t.String, Time: time, Duration: length})
+		}
+	}
+	return notes
+}
+
+// strokeLength returns the time of one 1/division note at tempo.
+func strokeLength(division int, tempo float32) float32 {
+	if division <= 0 {
+		division = defaultStrokeDivision
+	}
+	if tempo <= 0 {
+		tempo = DefaultTempo
+	}
+	return 60 / tempo * 4 / float32(division)
+}
+
+// strokes returns the start times of the repeated notes filling length.
+func strokes(start, length float32, division int, tempo float32) []float32 {
+	step := strokeLength(division, tempo)
+
+	times := []float32{start}
+	for t := start + step; t < start+length-timeEpsilon; t += step {
+		times = append(times, t)
+	}
+	return times
+}
+
+// SoundingNote returns the note p sounds when it is played: the harmonic
+// for harmonics, the target fret for legato techniques and the start of
+// a bend or a legato run.
+func (fb *FingerBoard) SoundingNote(p Playable) (Note, error) {
+	fret, semitones := 0, 0
+
+	switch n := Unwrap(p).(type) {
+	case Note:
+		fret = n.Fret
+	case Tap:
+		fret = n.Fret
+	case Tremolo:
+		fret = n.Fret
+	case Trill:
+		fret = n.Fret
+	case Legato:
+		fret = n.Fret
+	case HammerOn:
+		fret = n.FretTo
+	case PullOff:
+		fret = n.FretTo
+	case Slide:
+		fret = n.FretEnd
+	case Harmonic:
+		partial, err := harmonicPartial(n.Fret)
+		if err != nil {
+			return Note{}, err
+		}
+		semitones = harmonicSemitones(partial)
+	case ArtificialHarmonic:
+		partial, err := harmonicPartial(n.Offset)
+		if err != nil {
+			return Note{}, err
+		}
+		fret, semitones = n.Fret, harmonicSemitones(partial)
+	case PinchHarmonic:
+		fret, semitones = n.Fret, harmonicSemitones(n.partial())
+	case Bender:
+		note, _, err := fb.SoundingPitch(n, 0)
+		return note, err
+	default:
+		return Note{}, fmt.Errorf("playable %T has no pitch", p)
+	}
+
+	if fret > fb.frets {
+		return Note{}, fmt.Errorf("fret %d is out of the %d frets", fret, fb.frets)
+	}
+
+	note, err := fb.tuning.NoteAt(p.StringNumber(), fret)
+	if err != nil {
+		return Note{}, err
+	}
+	for range semitones {
+		if err := note.AddFret(); err != nil {
+			return Note{}, err
+		}
+	}
+
+	note.Fret = fret
+	note.Time = p.StartTime()
+	return note, nil
+}
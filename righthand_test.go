@@ -0,0 +1,99 @@
+package guitar
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRightHandTabSymbol(t *testing.T) {
+	testCases := []struct {
+		p        Playable
+		expected string
+	}{
+		{p: Tap{Fret: 12}, expected: "t12"},
+		{p: PinchHarmonic{Fret: 5}, expected: "5ph"},
+		{p: ArtificialHarmonic{Fret: 5, Offset: 12}, expected: "5<17>"},
+		{p: Tremolo{Fret: 3}, expected: "3///"},
+		{p: Trill{Fret: 5, TrillFret: 7}, expected: "5tr7"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expected, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.p.TabSymbol())
+		})
+	}
+}
+
+func TestSoundingNote(t *testing.T) {
+	tun, _ := ParseTuning(StandardTuning)
+	fb, _ := NewFingerBoard(tun, 24)
+
+	testCases := []struct {
+		name   string
+		p      Playable
+		note   string
+		octave int
+	}{
+		{name: "note", p: Note{Fret: 3, String: 5}, note: "G", octave: 2},
+		{name: "tap", p: Tap{Fret: 12, String: 0}, note: "E", octave: 5},
+		{name: "natural octave", p: Harmonic{Fret: 12, String: 5}, note: "E", octave: 3},
+		{name: "natural fifth", p: Harmonic{Fret: 7, String: 5}, note: "B", octave: 3},
+		{name: "natural two octaves", p: Harmonic{Fret: 5, String: 5}, note: "E", octave: 4},
+		{name: "natural third", p: Harmonic{Fret: 4, String: 5}, note: "G#", octave: 4},
+		{name: "artificial", p: ArtificialHarmonic{Fret: 5, Offset: 12, String: 5}, note: "A", octave: 3},
+		{name: "pinch", p: PinchHarmonic{Fret: 5, String: 5}, note: "E", octave: 4},
+		{name: "pinch partial", p: PinchHarmonic{Fret: 5, Partial: 4, String: 5}, note: "A", octave: 4},
+		{name: "hammer-on", p: HammerOn{FretFrom: 5, FretTo: 7, String: 0}, note: "B", octave: 4},
+		{name: "articulated", p: Articulate(Tremolo{Fret: 2, String: 3}, PalmMute), note: "E", octave: 3},
+		{name: "bend", p: Bend{Fret: 7, Cents: FullStep, String: 2}, note: "D", octave: 4},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			note, err := fb.SoundingNote(tc.p)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.note, note.Name)
+			assert.Equal(t, tc.octave, note.Octave)
+		})
+	}
+
+	_, err := fb.SoundingNote(Harmonic{Fret: 6, String: 0})
+	assert.Error(t, err)
+	_, err = fb.SoundingNote(Tap{Fret: 25, String: 0})
+	assert.Error(t, err)
+	_, err = fb.SoundingNote(DeadNote{String: 0})
+	assert.Error(t, err)
+}
+
+func TestTremoloNotes(t *testing.T) {
+	notes := Tremolo{Fret: 5, Division: 16, String: 1, Time: 1, Duration: 0.5}.Notes(120)
+
+	assert.Len(t, notes, 4)
+	assert.Equal(t, Note{Fret: 5, String: 1, Time: 1.375, Duration: 0.125}, notes[3])
+}
+
+func TestTrillNotes(t *testing.T) {
+	notes := Trill{Fret: 5, TrillFret: 7, Division: 16, Duration: 0.375}.Notes(120)
+
+	assert.Equal(t, []Playable{
+		Note{Fret: 5, Duration: 0.125},
+		HammerOn{FretFrom: 5, FretTo: 7, Time: 0.125, Duration: 0.125},
+		PullOff{FretFrom: 7, FretTo: 5, Time: 0.25, Duration: 0.125},
+	}, notes)
+}
+
+func TestParseTabRightHand(t *testing.T) {
+	tun, _ := ParseTuning("E4 B3")
+
+	ps, err := ParseTab(strings.NewReader("e|-t12--5<17>-|\nB|-5ph--3///--|\n"), tun)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Playable{
+		Tap{Fret: 12, String: 0, Time: 0.2},
+		PinchHarmonic{Fret: 5, String: 1, Time: 0.2},
+		ArtificialHarmonic{Fret: 5, Offset: 12, String: 0, Time: 1.2},
+		Tremolo{Fret: 3, String: 1, Time: 1.2},
+	}, ps)
+}
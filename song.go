@@ -0,0 +1,295 @@
+package guitar
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Song is the container shared by the tab writer, importers and exporters.
+type Song struct {
+	Title  string `json:"title,omitempty" yaml:"title,omitempty"`
+	Artist string `json:"artist,omitempty" yaml:"artist,omitempty"`
+	Key    string `json:"key,omitempty" yaml:"key,omitempty"`
+
+	Timing `yaml:",inline"`
+	Tracks []*Track `json:"tracks,omitempty" yaml:"tracks,omitempty"`
+}
+
+// Track is a single instrument part of a song. Its events are kept in
+// time order.
+type Track struct {
+	Name   string `json:"name,omitempty" yaml:"name,omitempty"`
+	Tuning Tuning `json:"tuning,omitempty" yaml:"tuning,omitempty"`
+	Frets  int    `json:"frets,omitempty" yaml:"frets,omitempty"`
+	Capo   int    `json:"capo,omitempty" yaml:"capo,omitempty"`
+
+	Events Playables `json:"events,omitempty" yaml:"events,omitempty"`
+}
+
+func NewSong(title, artist string) *Song {
+	return &Song{Title: title, Artist: artist}
+}
+
+func NewTrack(name string, tuning Tuning, frets int) (*Track, error) {
+	if len(tuning) == 0 {
+		return nil, errors.New("empty tuning")
+	}
+	if frets < 0 {
+		return nil, errors.New("frets value can not be negative")
+	}
+
+	return &Track{Name: name, Tuning: tuning, Frets: frets}, nil
+}
+
+func (s *Song) AddTrack(t *Track) {
+	s.Tracks = append(s.Tracks, t)
+}
+
+func (s *Song) Track(name string) (*Track, bool) {
+	for _, t := range s.Tracks {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// SetTempo adds a tempo change at time, replacing one already there.
+func (s *Song) SetTempo(time, bpm float32) {
+	for i := range s.Tempos {
+		if abs32(s.Tempos[i].Time-time) < timeEpsilon {
+			s.Tempos[i].BPM = bpm
+			return
+		}
+	}
+	s.Tempos = append(s.Tempos, TempoChange{Time: time, BPM: bpm})
+	s.Sort()
+}
+
+// SetTimeSignature adds a time signature change at time, replacing one
+// already there.
+func (s *Song) SetTimeSignature(time float32, ts TimeSignature) {
+	for i := range s.TimeSignatures {
+		if abs32(s.TimeSignatures[i].Time-time) < timeEpsilon {
+			s.TimeSignatures[i].Signature = ts
+			return
+		}
+	}
+	s.TimeSignatures = append(s.TimeSignatures, TimeSignatureChange{Time: time, Signature: ts})
+	s.Sort()
+}
+
+// Tempo returns the tempo at time.
+func (s *Song) Tempo(time float32) float32 {
+	return s.TempoAt(time, DefaultTempo)
+}
+
+// TimeSignature returns the time signature at time.
+func (s *Song) TimeSignature(time float32) TimeSignature {
+	return s.TimeSignatureAt(time, DefaultTimeSignature)
+}
+
+// keyFifths is the number of sharps of a major key, flats counted negative.
+var keyFifths = map[string]int{
+	"Fb": -8, "Cb": -7, "Gb": -6, "Db": -5, "Ab": -4, "Eb": -3, "Bb": -2, "F": -1,
+	"C": 0, "G": 1, "D": 2, "A": 3, "E": 4, "B": 5, "F#": 6, "C#": 7, "G#": 8, "D#": 9, "A#": 10,
+}
+
+// KeySignature reads the song key, such as "G", "Bbm" or "F# minor", into
+// its sharps, flats counted negative, and whether it is minor.
+func (s *Song) KeySignature() (int, bool, error) {
+	return parseKey(s.Key)
+}
+
+func parseKey(key string) (int, bool, error) {
+	text := strings.TrimSpace(key)
+	text = strings.NewReplacer("♯", "#", "♭", "b").Replace(text)
+
+	minor := false
+	for _, suffix := range []string{" minor", "minor", " min", "min", "m"} {
+		if strings.HasSuffix(text, suffix) {
+			text, minor = strings.TrimSuffix(text, suffix), true
+			break
+		}
+	}
+	for _, suffix := range []string{" major", "major", " maj", "maj"} {
+		if !minor && strings.HasSuffix(text, suffix) {
+			text = strings.TrimSuffix(text, suffix)
+			break
+		}
+	}
+
+	if text != "" {
+		text = strings.ToUpper(text[:1]) + text[1:]
+	}
+	fifths, ok := keyFifths[text]
+	if minor {
+		fifths -= 3
+	}
+	if !ok || fifths < -7 || fifths > 7 {
+		return 0, false, fmt.Errorf("invalid key %q", key)
+	}
+	return fifths, minor, nil
+}
+
+func (s *Song) Validate() error {
+	if err := s.Timing.Validate(); err != nil {
+		return err
+	}
+	for i, t := range s.Tracks {
+		if err := t.Validate(); err != nil {
+			return fmt.Errorf("track %d %q: %w", i, t.Name, err)
+		}
+	}
+	return nil
+}
+
+// Length returns the time the last event of the song stops sounding.
+func (s *Song) Length() float32 {
+	var length float32
+	for _, t := range s.Tracks {
+		length = max(length, t.Length())
+	}
+	return length
+}
+
+// TabWriter returns a TabWriter with the events of the track written in
+// it, following the song tempo map and time signatures.
+func (s *Song) TabWriter(track int, opts ...TabOption) (*TabWriter, error) {
+	if track < 0 || track >= len(s.Tracks) {
+		return nil, fmt.Errorf("invalid track index %d, song has %d tracks", track, len(s.Tracks))
+	}
+	t := s.Tracks[track]
+
+	opts = append([]TabOption{WithTiming(s.Timing)}, opts...)
+	tb, err := NewTabWriter(t.Tuning.NoteNames(), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Capo > 0 {
+		if err := tb.Annotate(TextAt(fmt.Sprintf("Capo %d", t.Capo), 0)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tb.WriteNotes(append([]Playable(nil), t.Events...)...); err != nil {
+		return nil, err
+	}
+
+	return tb, nil
+}
+
+// Tab renders the track as a text tab headed by the song title, artist
+// and tempo.
+func (s *Song) Tab(track int, opts ...TabOption) (string, error) {
+	tb, err := s.TabWriter(track, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	header := strings.Builder{}
+	if s.Title != "" {
+		header.WriteString(s.Title)
+		if s.Artist != "" {
+			header.WriteString(" - " + s.Artist)
+		}
+		header.WriteString("\n")
+	}
+	header.WriteString(fmt.Sprintf("Tempo: %g", s.Tempo(0)))
+	if len(s.TimeSignatures) > 0 {
+		header.WriteString(", " + s.TimeSignature(0).String())
+	}
+	if s.Key != "" {
+		header.WriteString(", Key: " + s.Key)
+	}
+	header.WriteString("\n\n")
+
+	return header.String() + tb.Tab(), nil
+}
+
+// Add inserts events keeping the track in time order.
+func (t *Track) Add(events ...Playable) {
+	t.Events = append(t.Events, events...)
+	t.Sort()
+}
+
+func (t *Track) Sort() {
+	sort.SliceStable(t.Events, func(i, j int) bool { return t.Events[i].StartTime() < t.Events[j].StartTime() })
+}
+
+func (t *Track) Validate() error {
+	if len(t.Tuning) == 0 {
+		return errors.New("empty tuning")
+	}
+	if t.Frets < 0 {
+		return errors.New("frets value can not be negative")
+	}
+	if t.Capo < 0 || t.Capo >= t.neckFrets() {
+		return fmt.Errorf("invalid capo %d", t.Capo)
+	}
+
+	for i, e := range t.Events {
+		if e.StringNumber() < 0 || e.StringNumber() >= len(t.Tuning) {
+			return fmt.Errorf("event %d: invalid string index %d", i, e.StringNumber())
+		}
+		if i > 0 && e.StartTime() < t.Events[i-1].StartTime() {
+			return fmt.Errorf("event %d: events are not in time order", i)
+		}
+		if err := validateBender(e); err != nil {
+			return fmt.Errorf("event %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (t *Track) Length() float32 {
+	var length float32
+	for _, e := range t.Events {
+		length = max(length, e.StartTime())
+		if s, ok := e.(Sustained); ok {
+			length = max(length, s.EndTime())
+		}
+	}
+	return length
+}
+
+// SoundingTuning returns the open string notes with the capo applied.
+func (t *Track) SoundingTuning() (Tuning, error) {
+	tuning := make(Tuning, len(t.Tuning))
+	copy(tuning, t.Tuning)
+
+	for i := range tuning {
+		for range t.Capo {
+			if err := tuning[i].AddFret(); err != nil {
+				return nil, err
+			}
+		}
+		tuning[i].Fret = 0
+	}
+	return tuning, nil
+}
+
+// FingerBoard returns the board above the capo, where fret 0 is the capo.
+// A track without frets gets a long neck.
+func (t *Track) FingerBoard() (*FingerBoard, error) {
+	if t.Capo < 0 || t.Capo >= t.neckFrets() {
+		return nil, fmt.Errorf("invalid capo %d", t.Capo)
+	}
+	tuning, err := t.SoundingTuning()
+	if err != nil {
+		return nil, err
+	}
+	return NewFingerBoard(tuning, t.neckFrets()-t.Capo)
+}
+
+// neckFrets is the number of frets of the track, or a long neck when it
+// does not tell.
+func (t *Track) neckFrets() int {
+	if t.Frets > 0 {
+		return t.Frets
+	}
+	return longNeckFrets
+}
@@ -0,0 +1,125 @@
+package guitar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testSong(t *testing.T) *Song {
+	tun, _ := ParseTuning(StandardTuning)
+
+	s := NewSong("Etude", "Anon")
+	s.Key = "Am"
+	s.SetTimeSignature(0, TimeSignature{2, 4})
+	s.SetTempo(0, 120)
+	s.SetTempo(2, 60)
+
+	tr, err := NewTrack("Guitar", tun, 22)
+	assert.NoError(t, err)
+	tr.Capo = 2
+	tr.Add(Note{Fret: 7, String: 2, Time: 2.5}, Note{Fret: 3, String: 0, Time: 0}, Note{Fret: 5, String: 1, Time: 1.25, Duration: 0.5})
+	s.AddTrack(tr)
+
+	return s
+}
+
+func TestSongTab(t *testing.T) {
+	s := testSong(t)
+
+	tab, err := s.Tab(0, WithMeasureNumbers())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Etude - Anon\nTempo: 120, 2/4, Key: Am\n\n"+
+		"  1     2     3\n  Capo 2\n"+
+		"e|3----|-----|----|\nB|-----|-5---|----|\nG|-----|-----|--7-|\n"+
+		"D|-----|-----|----|\nA|-----|-----|----|\nE|-----|-----|----|\n", tab)
+
+	_, err = s.Tab(1)
+	assert.Error(t, err)
+}
+
+func TestSongTiming(t *testing.T) {
+	s := testSong(t)
+
+	assert.Equal(t, float32(120), s.Tempo(1.9))
+	assert.Equal(t, float32(60), s.Tempo(2))
+	assert.Equal(t, TimeSignature{2, 4}, s.TimeSignature(10))
+	assert.InDelta(t, 2.5, s.Length(), 1e-6)
+
+	s.SetTempo(2, 90)
+	assert.Len(t, s.Tempos, 2)
+	assert.Equal(t, float32(90), s.Tempo(3))
+}
+
+func TestTrack(t *testing.T) {
+	s := testSong(t)
+	tr, ok := s.Track("Guitar")
+	assert.True(t, ok)
+
+	assert.Equal(t, []float32{0, 1.25, 2.5}, []float32{
+		tr.Events[0].StartTime(), tr.Events[1].StartTime(), tr.Events[2].StartTime(),
+	})
+	assert.NoError(t, s.Validate())
+
+	tuning, err := tr.SoundingTuning()
+	assert.NoError(t, err)
+	assert.Equal(t, Note{Name: "F#", Octave: 4, String: 0}, tuning[0])
+	assert.Equal(t, Note{Name: "F#", Octave: 2, String: 5}, tuning[5])
+
+	fb, err := tr.FingerBoard()
+	assert.NoError(t, err)
+	assert.Equal(t, 20, fb.frets)
+
+	tr.Capo = 30
+	assert.Error(t, s.Validate())
+	_, err = tr.FingerBoard()
+	assert.Error(t, err)
+
+	// a track without frets has a long neck
+	tr.Frets, tr.Capo = 0, 2
+	assert.NoError(t, s.Validate())
+	fb, err = tr.FingerBoard()
+	assert.NoError(t, err)
+	assert.Equal(t, longNeckFrets-2, fb.frets)
+	tr.Capo = longNeckFrets
+	assert.Error(t, s.Validate())
+
+	_, err = NewTrack("Bass", Tuning{}, 20)
+	assert.Error(t, err)
+}
+
+func TestSongKeySignature(t *testing.T) {
+	testCases := []struct {
+		key    string
+		fifths int
+		minor  bool
+		err    bool
+	}{
+		{key: "C", fifths: 0},
+		{key: "G", fifths: 1},
+		{key: "Bb", fifths: -2},
+		{key: "F# major", fifths: 6},
+		{key: "Am", fifths: 0, minor: true},
+		{key: "e minor", fifths: 1, minor: true},
+		{key: "C#m", fifths: 4, minor: true},
+		{key: "E♭m", fifths: -6, minor: true},
+		{key: "Fbm", err: true},
+		{key: "H", err: true},
+		{key: "", err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.key, func(t *testing.T) {
+			s := Song{Key: tc.key}
+			fifths, minor, err := s.KeySignature()
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.fifths, fifths)
+			assert.Equal(t, tc.minor, minor)
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package guitar
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// fretPlaceholder stands for a fret number in a technique pattern.
+const fretPlaceholder = "{}"
+
+// Technique declares how a Playable is written in a tab. Pattern is the
+// symbol grammar used both to render and to parse it: "{}" stands for a
+// fret number and every other rune is written as is, e.g. "{}h{}" for a
+// hammer-on or "<{}>" for a harmonic.
+type Technique struct {
+	Name    string
+	Pattern string
+	// Aliases are other patterns recognized when parsing, e.g. "{}\\{}"
+	// for a slide down.
+	Aliases []string
+
+	// New builds the Playable from the frets read in the pattern. A Note
+	// without a name gets its pitch from the tuning of the parsed tab;
+	// returning nil reads the symbol without producing a Playable.
+	New func(frets []int, stringNumber int, time float32) Playable
+}
+
+// Format writes the pattern with the given frets in place of "{}".
+// Placeholders left without a fret are written as is and extra frets
+// are ignored.
+func (t Technique) Format(frets ...int) string {
+	sb := strings.Builder{}
+	rest := t.Pattern
+	for rest != "" {
+		if strings.HasPrefix(rest, fretPlaceholder) && len(frets) > 0 {
+			sb.WriteString(strconv.Itoa(frets[0]))
+			frets = frets[1:]
+			rest = rest[len(fretPlaceholder):]
+			continue
+		}
+		sb.WriteByte(rest[0])
+		rest = rest[1:]
+	}
+	return sb.String()
+}
+
+// patternToken is either a fret placeholder or a literal rune.
+type patternToken struct {
+	fret bool
+	r    rune
+}
+
+type registeredTechnique struct {
+	Technique
+	patterns [][]patternToken
+}
+
+var techniqueRegistry = struct {
+	sync.RWMutex
+	techniques []registeredTechnique
+}{}
+
+var (
+	noteTechnique = Technique{Name: "note", Pattern: "{}",
+		New: func(frets []int, stringNumber int, time float32) Playable {
+			return Note{Fret: frets[0], String: stringNumber, Time: time}
+		}}
+	slideTechnique = Technique{Name: "slide", Pattern: "{}/{}", Aliases: []string{`{}\{}`},
+		New: func(frets []int, stringNumber int, time float32) Playable {
+			return Slide{FretStart: frets[0], FretEnd: frets[1], String: stringNumber, Time: time}
+		}}
+	slideInTechnique = Technique{Name: "slide_in", Pattern: "/{}", Aliases: []string{`\{}`},
+		New: func(frets []int, stringNumber int, time float32) Playable {
+			return Slide{FretStart: -1, FretEnd: frets[0], String: stringNumber, Time: time}
+		}}
+	hammerOnTechnique = Technique{Name: "hammer_on", Pattern: "{}h{}",
+		New: func(frets []int, stringNumber int, time float32) Playable {
+			return HammerOn{FretFrom: frets[0], FretTo: frets[1], String: stringNumber, Time: time}
+		}}
+	pullOffTechnique = Technique{Name: "pull_off", Pattern: "{}p{}",
+		New: func(frets []int, stringNumber int, time float32) Playable {
+			return PullOff{FretFrom: frets[0], FretTo: frets[1], String: stringNumber, Time: time}
+		}}
+	harmonicTechnique = Technique{Name: "harmonic", Pattern: "<{}>",
+		New: func(frets []int, stringNumber int, time float32) Playable {
+			return Harmonic{Fret: frets[0], String: stringNumber, Time: time}
+		}}
+	deadNoteTechnique = Technique{Name: "dead_note", Pattern: "x", Aliases: []string{"X"},
+		New: func(frets []int, stringNumber int, time float32) Playable {
+			return DeadNote{String: stringNumber, Time: time}
+		}}
+	bendTechnique = Technique{Name: "bend", Pattern: "{}b{}",
+		New: func(frets []int, stringNumber int, time float32) Playable {
+			return Bend{Fret: frets[0], Cents: (frets[1] - frets[0]) * 100, String: stringNumber, Time: time}
+		}}
+	quarterBendTechnique = Technique{Name: "quarter_bend", Pattern: "{}b¼", Aliases: []string{"{}bq"},
+		New: func(frets []int, stringNumber int, time float32) Playable {
+			return Bend{Fret: frets[0], Cents: QuarterStep, String: stringNumber, Time: time}
+		}}
+	preBendTechnique = Technique{Name: "pre_bend", Pattern: "({})b{}",
+		New: func(frets []int, stringNumber int, time float32) Playable {
+			return PreBend{Fret: frets[0], Cents: (frets[1] - frets[0]) * 100, String: stringNumber, Time: time}
+		}}
+	bendReleaseTechnique = Technique{Name: "bend_release", Pattern: "{}b{}r{}",
+		New: func(frets []int, stringNumber int, time float32) Playable {
+			return BendRelease{Fret: frets[0], Cents: (frets[1] - frets[0]) * 100, Release: (frets[2] - frets[0]) * 100,
+				String: stringNumber, Time: time}
+		}}
+	vibratoTechnique = Technique{Name: "vibrato", Pattern: "{}~",
+		New: func(frets []int, stringNumber int, time float32) Playable {
+			return Articulate(Note{Fret: frets[0], String: stringNumber, Time: time}, Vibrato)
+		}}
+	wideVibratoTechnique = Technique{Name: "wide_vibrato", Pattern: "{}~~",
+		New: func(frets []int, stringNumber int, time float32) Playable {
+			return Articulate(Note{Fret: frets[0], String: stringNumber, Time: time}, WideVibrato)
+		}}
+	ghostNoteTechnique = Technique{Name: "ghost_note", Pattern: "({})",
+		New: func(frets []int, stringNumber int, time float32) Playable {
+			return Articulate(Note{Fret: frets[0], String: stringNumber, Time: time}, Ghost)
+		}}
+	tapTechnique = Technique{Name: "tap", Pattern: "t{}", Aliases: []string{"T{}"},
+		New: func(frets []int, stringNumber int, time float32) Playable {
+			return Tap{Fret: frets[0], String: stringNumber, Time: time}
+		}}
+	pinchHarmonicTechnique = Technique{Name: "pinch_harmonic", Pattern: "{}ph",
+		New: func(frets []int, stringNumber int, time float32) Playable {
+			return PinchHarmonic{Fret: frets[0], String: stringNumber, Time: time}
+		}}
+	artificialHarmonicTechnique = Technique{Name: "artificial_harmonic", Pattern: "{}<{}>",
+		New: func(frets []int, stringNumber int, time float32) Playable {
+			return ArtificialHarmonic{Fret: frets[0], Offset: frets[1] - frets[0], String: stringNumber, Time: time}
+		}}
+	tremoloTechnique = Technique{Name: "tremolo", Pattern: "{}///",
+		New: func(frets []int, stringNumber int, time float32) Playable {
+			return Tremolo{Fret: frets[0], String: stringNumber, Time: time}
+		}}
+	trillTechnique = Technique{Name: "trill", Pattern: "{}tr{}",
+		New: func(frets []int, stringNumber int, time float32) Playable {
+			return Trill{Fret: frets[0], TrillFret: frets[1], String: stringNumber, Time: time}
+		}}
+)
+
+func init() {
+	for _, t := range []Technique{
+		noteTechnique, slideTechnique, slideInTechnique, hammerOnTechnique,
+		pullOffTechnique, harmonicTechnique, deadNoteTechnique,
+		bendTechnique, quarterBendTechnique, preBendTechnique, bendReleaseTechnique,
+		vibratoTechnique, wideVibratoTechnique, ghostNoteTechnique,
+		tapTechnique, pinchHarmonicTechnique, artificialHarmonicTechnique, tremoloTechnique, trillTechnique,
+	} {
+		if err := RegisterTechnique(t); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterTechnique makes the tab parser recognize a technique. When
+// several patterns match the same symbol the longest match wins, then
+// the technique registered first.
+func RegisterTechnique(t Technique) error {
+	if t.Name == "" {
+		return errors.New("technique name can not be empty")
+	}
+	if t.New == nil {
+		return fmt.Errorf("technique %q without constructor", t.Name)
+	}
+
+	entry := registeredTechnique{Technique: t}
+	frets := strings.Count(t.Pattern, fretPlaceholder)
+	for _, pattern := range append([]string{t.Pattern}, t.Aliases...) {
+		tokens, err := compilePattern(pattern)
+		if err != nil {
+			return fmt.Errorf("technique %q: %w", t.Name, err)
+		}
+		if n := strings.Count(pattern, fretPlaceholder); n != frets {
+			return fmt.Errorf("technique %q: pattern %q has %d frets, %q has %d", t.Name, pattern, n, t.Pattern, frets)
+		}
+		entry.patterns = append(entry.patterns, tokens)
+	}
+
+	techniqueRegistry.Lock()
+	defer techniqueRegistry.Unlock()
+
+	for _, registered := range techniqueRegistry.techniques {
+		if registered.Name == t.Name {
+			return fmt.Errorf("technique %q is already registered", t.Name)
+		}
+	}
+	techniqueRegistry.techniques = append(techniqueRegistry.techniques, entry)
+	return nil
+}
+
+// LookupTechnique returns the registered technique called name.
+func LookupTechnique(name string) (Technique, bool) {
+	techniqueRegistry.RLock()
+	defer techniqueRegistry.RUnlock()
+
+	for _, t := range techniqueRegistry.techniques {
+		if t.Name == name {
+			return t.Technique, true
+		}
+	}
+	return Technique{}, false
+}
+
+// Techniques returns the registered techniques in registration order.
+func Techniques() []Technique {
+	techniqueRegistry.RLock()
+	defer techniqueRegistry.RUnlock()
+
+	result := make([]Technique, len(techniqueRegistry.techniques))
+	for i, t := range techniqueRegistry.techniques {
+		result[i] = t.Technique
+	}
+	return result
+}
+
+// compilePattern splits a pattern into tokens. Dashes, bar lines and
+// spaces belong to the staff and can not be part of a symbol.
+func compilePattern(pattern string) ([]patternToken, error) {
+	if pattern == "" {
+		return nil, errors.New("empty pattern")
+	}
+
+	tokens := []patternToken{}
+	rest := pattern
+	for rest != "" {
+		if strings.HasPrefix(rest, fretPlaceholder) {
+			if len(tokens) > 0 && tokens[len(tokens)-1].fret {
+				return nil, fmt.Errorf("pattern %q has adjacent frets", pattern)
+			}
+			tokens = append(tokens, patternToken{fret: true})
+			rest = rest[len(fretPlaceholder):]
+			continue
+		}
+
+		r := []rune(rest)[0]
+		if r == '-' || r == '|' || unicode.IsSpace(r) {
+			return nil, fmt.Errorf("pattern %q contains staff rune %q", pattern, r)
+		}
+		tokens = append(tokens, patternToken{r: r})
+		rest = rest[len(string(r)):]
+	}
+	return tokens, nil
+}
+
+// chains reports whether a pattern ends in a fret another technique can
+// continue from, as in "5h7p5".
+func chains(pattern []patternToken) bool {
+	return len(pattern) > 1 && pattern[len(pattern)-1].fret
+}
+
+// matchPattern reads pattern at the start of s. It returns the frets
+// read and the runes taken; when the pattern does not match, n is how
+// far it got and literal the position of the last literal read, or -1.
+func matchPattern(pattern []patternToken, s []rune) (frets []int, n int, literal int, ok bool) {
+	literal = -1
+	for _, token := range pattern {
+		if token.fret {
+			fret, l := readFret(s[n:])
+			if l == 0 {
+				return nil, n, literal, false
+			}
+			frets = append(frets, fret)
+			n += l
+			continue
+		}
+
+		if n >= len(s) || s[n] != token.r {
+			return nil, n, literal, false
+		}
+		literal = n
+		n++
+	}
+	return frets, n, literal, true
+}
+
+// symbolMatch is a technique pattern read from a tab.
+type symbolMatch struct {
+	technique Technique
+	pattern   []patternToken
+	frets     []int
+	n         int
+}
+
+// matchSymbol finds the longest registered pattern at the start of s.
+// With chained set only patterns starting with a fret and going on are
+// tried. A pattern that reads a literal and then fails further than any
+// full match is reported as an error at that literal.
+func matchSymbol(s []rune, chained bool) (symbolMatch, bool, *tokenError) {
+	techniqueRegistry.RLock()
+	defer techniqueRegistry.RUnlock()
+
+	best, found := symbolMatch{}, false
+	partial, partialErr := 0, (*tokenError)(nil)
+
+	for _, t := range techniqueRegistry.techniques {
+		for _, pattern := range t.patterns {
+			if chained && (!pattern[0].fret || !chains(pattern)) {
+				continue
+			}
+
+			frets, n, literal, ok := matchPattern(pattern, s)
+			if ok {
+				if n > best.n {
+					best, found = symbolMatch{technique: t.Technique, pattern: pattern, frets: frets, n: n}, true
+				}
+				continue
+			}
+			if literal >= 0 && n > 1 && n > partial {
+				partial = n
+				partialErr = &tokenError{literal, fmt.Sprintf("incomplete %s %q", t.Name, string(s[:n]))}
+			}
+		}
+	}
+
+	if partialErr != nil && partial > best.n {
+		return symbolMatch{}, false, partialErr
+	}
+	return best, found, nil
+}
@@ -0,0 +1,126 @@
+package guitar
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testWhammy struct {
+	From, To int
+	String   int
+	Time     float32
+}
+
+var testWhammyTechnique = Technique{Name: "test_whammy", Pattern: "{}w{}",
+	New: func(frets []int, stringNumber int, time float32) Playable {
+		return testWhammy{From: frets[0], To: frets[1], String: stringNumber, Time: time}
+	}}
+
+func (w testWhammy) TabSymbol() string  { return testWhammyTechnique.Format(w.From, w.To) }
+func (w testWhammy) StringNumber() int  { return w.String }
+func (w testWhammy) StartTime() float32 { return w.Time }
+
+// registerTestWhammy registers the whammy technique for the test, and
+// removes it when the test ends.
+func registerTestWhammy(t *testing.T) {
+	techniqueRegistry.RLock()
+	registered := slices.Clone(techniqueRegistry.techniques)
+	techniqueRegistry.RUnlock()
+
+	assert.NoError(t, RegisterTechnique(testWhammyTechnique))
+	t.Cleanup(func() {
+		techniqueRegistry.Lock()
+		defer techniqueRegistry.Unlock()
+		techniqueRegistry.techniques = registered
+	})
+}
+
+func TestTechniqueFormat(t *testing.T) {
+	testCases := []struct {
+		technique Technique
+		frets     []int
+		expected  string
+	}{
+		{technique: noteTechnique, frets: []int{12}, expected: "12"},
+		{technique: slideTechnique, frets: []int{5, 7}, expected: "5/7"},
+		{technique: harmonicTechnique, frets: []int{12}, expected: "<12>"},
+		{technique: deadNoteTechnique, expected: "x"},
+		{technique: hammerOnTechnique, frets: []int{2, 4}, expected: "2h4"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expected, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.technique.Format(tc.frets...))
+		})
+	}
+
+	assert.Equal(t, "2h{}", hammerOnTechnique.Format(2))
+	assert.Equal(t, "2", noteTechnique.Format(2, 4))
+}
+
+func TestRegisterTechniqueErrors(t *testing.T) {
+	newNote := noteTechnique.New
+
+	testCases := []struct {
+		name      string
+		technique Technique
+	}{
+		{name: "empty name", technique: Technique{Pattern: "{}", New: newNote}},
+		{name: "no constructor", technique: Technique{Name: "a", Pattern: "{}"}},
+		{name: "empty pattern", technique: Technique{Name: "a", New: newNote}},
+		{name: "adjacent frets", technique: Technique{Name: "a", Pattern: "{}{}", New: newNote}},
+		{name: "staff rune", technique: Technique{Name: "a", Pattern: "{}-{}", New: newNote}},
+		{name: "bad alias", technique: Technique{Name: "a", Pattern: "{}a", Aliases: []string{"a |"}, New: newNote}},
+		{name: "alias fret count", technique: Technique{Name: "a", Pattern: "{}a", Aliases: []string{"{}a{}"}, New: newNote}},
+		{name: "duplicate", technique: hammerOnTechnique},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Error(t, RegisterTechnique(tc.technique))
+		})
+	}
+}
+
+func TestLookupTechnique(t *testing.T) {
+	tech, ok := LookupTechnique("pull_off")
+	assert.True(t, ok)
+	assert.Equal(t, "{}p{}", tech.Pattern)
+
+	_, ok = LookupTechnique("wobble")
+	assert.False(t, ok)
+
+	assert.Equal(t, "note", Techniques()[0].Name)
+
+	registerTestWhammy(t)
+	_, ok = LookupTechnique("test_whammy")
+	assert.True(t, ok)
+}
+
+func TestUserTechniqueRoundTrip(t *testing.T) {
+	registerTestWhammy(t)
+
+	tun, _ := ParseTuning("E4 B3")
+	tb, _ := NewTabWriter(tun.NoteNames())
+	_ = tb.WriteNotes(
+		testWhammy{From: 5, To: 7, String: 1, Time: 0},
+		HammerOn{FretFrom: 7, FretTo: 9, String: 1, Time: 0.4},
+	)
+	assert.Contains(t, tb.Tab(), "5w7")
+
+	ps, err := ParseTab(strings.NewReader(tb.Tab()), tun)
+	assert.NoError(t, err)
+	assert.Len(t, ps, 2)
+	assert.Equal(t, testWhammy{From: 5, To: 7, String: 1, Time: 0}, ps[0])
+	assert.Equal(t, "7h9", ps[1].TabSymbol())
+
+	ps, err = ParseTab(strings.NewReader("e|--------|\nB|--3w5h7-|\n"), tun)
+	assert.NoError(t, err)
+	assert.Equal(t, []Playable{
+		testWhammy{From: 3, To: 5, String: 1, Time: 0.4},
+		HammerOn{FretFrom: 5, FretTo: 7, String: 1, Time: 1},
+	}, ps)
+}
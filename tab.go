@@ -3,14 +3,36 @@ package guitar
 import (
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
+// timeEpsilon absorbs float32 rounding when comparing note times.
+const timeEpsilon = 1e-4
+
 type TabWriter struct {
 	time     float32
 	timeStep float32
 
-	tabStrings []strings.Builder
+	stringNames []string
+	columns     []tabColumn
+
+	timeSignature  TimeSignature
+	tempo          float32
+	timing         Timing
+	measureNumbers bool
+	barSplit       BarSplitPolicy
+	lineWidth      int
+	rhythm         bool
+	strumArrows    bool
+	chordNames     bool
+
+	nextBar float32
+	measure int
+	ties    []Playable
+
+	annotations []Annotation
 }
 
 type Playable interface {
@@ -19,11 +41,58 @@ type Playable interface {
 	StartTime() float32
 }
 
+// Sustained is implemented by Playables that keep sounding after they start.
+type Sustained interface {
+	Playable
+	EndTime() float32
+}
+
+// Movable is implemented by Playables that can be copied to another time.
+type Movable interface {
+	Playable
+	AtTime(t float32) Playable
+}
+
+// tabColumn is a single vertical slice of the tab: either a bar line or
+// a set of symbols (possibly none, for silence) sharing the same time.
+type tabColumn struct {
+	time   float32
+	cells  []string
+	notes  []Playable
+	rhythm string
+
+	bar     bool
+	barLine BarLine
+	measure int
+}
+
+func (c tabColumn) width() int {
+	if c.bar {
+		return len(c.barLine.Symbol())
+	}
+	width := max(1, len(c.rhythm))
+	for _, cell := range c.cells {
+		width = max(width, utf8.RuneCountInString(cell))
+	}
+	return width
+}
+
+func (c tabColumn) cell(i int) string {
+	if c.bar {
+		return c.barLine.Symbol()
+	}
+	if i >= len(c.cells) {
+		return strings.Repeat("-", c.width())
+	}
+	return c.cells[i] + strings.Repeat("-", c.width()-utf8.RuneCountInString(c.cells[i]))
+}
+
 func NewTabWriter(tuningNotes []string, opts ...TabOption) (*TabWriter, error) {
 	tb := &TabWriter{
-		time:       0,
-		timeStep:   0.2,
-		tabStrings: make([]strings.Builder, len(tuningNotes)),
+		time:     0,
+		timeStep: 0.2,
+		tempo:    DefaultTempo,
+		measure:  1,
 	}
 
 	for _, opt := range opts {
@@ -34,89 +103,321 @@ func NewTabWriter(tuningNotes []string, opts ...TabOption) (*TabWriter, error) {
 		return nil, err
 	}
 
+	if tb.tempo <= 0 {
+		return nil, fmt.Errorf("invalid tempo %v", tb.tempo)
+	}
+	if err := tb.timing.Validate(); err != nil {
+		return nil, err
+	}
+	tb.timing.Sort()
+
+	if tb.hasBars() {
+		if tb.timeSignature == (TimeSignature{}) {
+			tb.timeSignature = DefaultTimeSignature
+		}
+		if err := tb.timeSignature.Validate(); err != nil {
+			return nil, err
+		}
+		tb.nextBar = tb.measureDuration(0)
+	}
+
 	return tb, nil
 }
 
 func (tb *TabWriter) Tab() string {
 	tab := strings.Builder{}
 
-	for i := range len(tb.tabStrings) {
-		tab.WriteString(tb.tabStrings[i].String() + "\n")
+	for i, system := range tb.systems(tb.layoutColumns()) {
+		if i > 0 {
+			tab.WriteString("\n")
+		}
+		for _, line := range tb.render(system) {
+			tab.WriteString(line + "\n")
+		}
 	}
 
 	return tab.String()
 }
 
 func (tb *TabWriter) WriteNotes(notes ...Playable) error {
+	if len(notes) == 0 {
+		return nil
+	}
+
+	notes, chords, err := expandChords(notes)
+	if err != nil {
+		return err
+	}
 
-	sort.Slice(notes, func(i, j int) bool { return notes[i].StartTime() < notes[j].StartTime() })
+	sort.SliceStable(notes, func(i, j int) bool { return notes[i].StartTime() < notes[j].StartTime() })
 
 	time := notes[0].StartTime()
-	if time < tb.time {
+	if time < tb.time-timeEpsilon {
 		return fmt.Errorf("note time %v precedes current time %v",
 			time, tb.time)
 	}
 
 	for _, n := range notes {
-		if n.StringNumber() >= len(tb.tabStrings) {
+		if n.StringNumber() < 0 || n.StringNumber() >= len(tb.stringNames) {
 			return fmt.Errorf("invalid string index %d, in tab builder only %d strings",
-				n.StringNumber(), len(tb.tabStrings))
+				n.StringNumber(), len(tb.stringNames))
+		}
+		if err := validateBender(n); err != nil {
+			return err
 		}
 	}
 
-	for i := 0; i < len(notes); i++ {
+	for i := 0; i < len(notes); {
 		time = notes[i].StartTime()
-		maxLen := -1
-		minLen := tb.tabStrings[0].Len()
 
-		silence := int((time - tb.time) / tb.timeStep)
-		tb.addSilence(silence)
+		silence := int((time-tb.time)/tb.timeStep + timeEpsilon)
+		for j := range silence {
+			tb.addColumn(tb.newColumn(tb.time + float32(j)*tb.timeStep))
+		}
 		tb.time += tb.timeStep * (float32(silence + 1))
 
-		for i < len(notes) && time == notes[i].StartTime() {
+		col := tb.newColumn(time)
+		for ; i < len(notes) && time == notes[i].StartTime(); i++ {
 			stringPos := notes[i].StringNumber()
 
-			if tb.tabStrings[stringPos].Len() != minLen {
+			if col.cells[stringPos] != "" {
 				return fmt.Errorf("can not write more 2 or more notes with equal time: %f to 1 string", time)
 			}
 
-			tb.tabStrings[stringPos].WriteString(notes[i].TabSymbol())
-			if tb.tabStrings[stringPos].Len() > maxLen {
-				maxLen = tb.tabStrings[stringPos].Len()
-			}
-			i++
-		}
-
-		for i := range tb.tabStrings {
-			if tb.tabStrings[i].Len() < maxLen {
-				diffLen := maxLen - tb.tabStrings[i].Len()
-				tb.tabStrings[i].WriteString(strings.Repeat("-", diffLen))
-			}
+			col.cells[stringPos] = notes[i].TabSymbol()
+			col.notes = append(col.notes, notes[i])
 		}
+		tb.addColumn(col)
 	}
 
 	// to escape situations like:
 	// E|-3--123-----
-	tb.addSilence(1)
+	// the gap belongs to the last note, so it never starts a new measure
+	tb.columns = append(tb.columns, tb.newColumn(time))
 
+	tb.annotateArticulations(notes)
+	tb.annotateChords(chords)
 	return nil
 }
 
 func (tb *TabWriter) addNotes(notes []string) error {
-	if len(notes) != len(tb.tabStrings) {
+	if len(notes) == 0 {
 		return fmt.Errorf("invalid tuning notes count")
 	}
 
-	for i := range notes {
-		tb.tabStrings[i].WriteString(notes[i] + "|")
+	tb.stringNames = append([]string(nil), notes...)
+	return nil
+}
+
+func (tb *TabWriter) newColumn(time float32) tabColumn {
+	return tabColumn{
+		time:  time,
+		cells: make([]string, len(tb.stringNames)),
+	}
+}
+
+// addColumn appends col to the tab, closing every measure that ends
+// before col starts and splitting notes of col that cross the next bar.
+func (tb *TabWriter) addColumn(col tabColumn) {
+	if !tb.hasBars() {
+		tb.columns = append(tb.columns, col)
+		return
+	}
+
+	tb.closeMeasures(col.time)
+
+	if len(tb.ties) > 0 && col.time >= tb.ties[0].StartTime()-timeEpsilon {
+		tb.placeTies(&col)
+	}
+
+	for i, n := range col.notes {
+		if tb.barSplit == BarSplitNone || !crossesTime(n, tb.nextBar) {
+			continue
+		}
+		head, tail, ok := splitPlayable(n, tb.nextBar)
+		if !ok {
+			continue
+		}
+		col.notes[i] = head
+		if tb.barSplit == BarSplitTie {
+			tb.ties = append(tb.ties, tail)
+		}
+	}
+
+	tb.columns = append(tb.columns, col)
+}
+
+// closeMeasures writes the bar lines of every measure ending at or before
+// time. Ties of a measure that has no column of its own get one.
+func (tb *TabWriter) closeMeasures(time float32) {
+	for time >= tb.nextBar-timeEpsilon {
+		barTime := tb.nextBar
+		tb.measure++
+		tb.nextBar += tb.measureDuration(barTime)
+		tb.addBar(tabColumn{time: barTime, bar: true, measure: tb.measure})
+
+		if len(tb.ties) > 0 && time >= tb.nextBar-timeEpsilon {
+			tie := tb.newColumn(barTime)
+			tb.placeTies(&tie)
+			tb.columns = append(tb.columns, tie)
+		}
+	}
+}
+
+// addBar appends a bar line column, merging it with a bar line already
+// written at the same time.
+func (tb *TabWriter) addBar(bar tabColumn) {
+	if n := len(tb.columns); n > 0 && tb.columns[n-1].bar && abs32(tb.columns[n-1].time-bar.time) < timeEpsilon {
+		last := &tb.columns[n-1]
+		last.barLine = last.barLine.merge(bar.barLine)
+		if bar.measure > 0 {
+			last.measure = bar.measure
+		}
+		return
+	}
+
+	tb.columns = append(tb.columns, bar)
+}
+
+// WriteBarLine writes a bar line of the given kind at time. A bar line
+// falling on a measure boundary replaces the plain one written there.
+func (tb *TabWriter) WriteBarLine(kind BarLine, time float32) error {
+	if time < tb.time-timeEpsilon {
+		return fmt.Errorf("bar line time %v precedes current time %v", time, tb.time)
+	}
+
+	silence := int((time-tb.time)/tb.timeStep + timeEpsilon)
+	for j := range silence {
+		tb.addColumn(tb.newColumn(tb.time + float32(j)*tb.timeStep))
+	}
+	tb.time += tb.timeStep * float32(silence)
+
+	if tb.hasBars() {
+		tb.closeMeasures(time)
 	}
+	tb.addBar(tabColumn{time: time, bar: true, barLine: kind})
+
 	return nil
 }
 
-func (tb *TabWriter) addSilence(n int) {
-	for i := range len(tb.tabStrings) {
-		tb.tabStrings[i].WriteString(strings.Repeat("-", n))
+// placeTies writes the pending tied remainders into col. A remainder that
+// still crosses the following bar line is split again and kept pending.
+func (tb *TabWriter) placeTies(col *tabColumn) {
+	pending := tb.ties
+	tb.ties = nil
+
+	for _, tie := range pending {
+		stringPos := tie.StringNumber()
+		if col.cells[stringPos] != "" {
+			continue
+		}
+		if crossesTime(tie, tb.nextBar) {
+			head, tail, _ := splitPlayable(tie, tb.nextBar)
+			tie = head
+			tb.ties = append(tb.ties, tail)
+		}
+		col.cells[stringPos] = "(" + tie.TabSymbol() + ")"
+		col.notes = append(col.notes, tie)
+	}
+}
+
+// closedColumns returns a copy of the columns with the ties still pending
+// after the last written note placed in measures of their own.
+func (tb *TabWriter) closedColumns() []tabColumn {
+	end := *tb
+	end.columns = append([]tabColumn(nil), tb.columns...)
+	end.ties = append([]Playable(nil), tb.ties...)
+
+	for len(end.ties) > 0 {
+		time := end.ties[0].StartTime()
+		end.addColumn(end.newColumn(time))
+		end.columns = append(end.columns, end.newColumn(time))
+	}
+	return end.columns
+}
+
+func (tb *TabWriter) hasBars() bool {
+	return tb.timeSignature != TimeSignature{} || len(tb.timing.TimeSignatures) > 0
+}
+
+// measureDuration returns the length of the measure starting at start.
+func (tb *TabWriter) measureDuration(start float32) float32 {
+	ts := tb.timing.TimeSignatureAt(start, tb.timeSignature)
+	return ts.MeasureDuration(tb.timing.TempoAt(start, tb.tempo))
+}
+
+// lineStart returns the offset of the first column in a rendered line.
+// A system starting with a bar line uses it in place of the "|" after
+// the string name.
+func (tb *TabWriter) lineStart(columns []tabColumn) int {
+	if len(columns) > 0 && columns[0].bar {
+		return len(tb.stringNames[0])
 	}
+	return len(tb.stringNames[0]) + 1
+}
+
+// render lays out a system as the measure number line (when enabled)
+// followed by one line per string.
+func (tb *TabWriter) render(system tabSystem) []string {
+	lines := []string{}
+	columns := system.columns
+
+	closeBar := tb.hasBars() && system.last && len(columns) > 0 && !columns[len(columns)-1].bar
+
+	if tb.measureNumbers && tb.hasBars() {
+		lines = append(lines, tb.renderMeasureNumbers(columns, system.firstMeasure))
+	}
+
+	lines = append(lines, tb.renderAnnotations(system, false)...)
+
+	if tb.rhythm {
+		lines = append(lines, tb.renderRhythm(columns))
+	}
+
+	for i, name := range tb.stringNames {
+		line := strings.Builder{}
+		line.WriteString(name)
+		if len(columns) == 0 || !columns[0].bar {
+			line.WriteString("|")
+		}
+		for _, col := range columns {
+			line.WriteString(col.cell(i))
+		}
+		if closeBar {
+			line.WriteString("|")
+		}
+		lines = append(lines, line.String())
+	}
+
+	lines = append(lines, tb.renderAnnotations(system, true)...)
+
+	return lines
+}
+
+func (tb *TabWriter) renderMeasureNumbers(columns []tabColumn, firstMeasure int) string {
+	line := []byte{}
+	pos := tb.lineStart(columns)
+
+	put := func(measure int) {
+		label := strconv.Itoa(measure)
+		if pos < len(line) {
+			return
+		}
+		line = append(line, strings.Repeat(" ", pos-len(line))...)
+		line = append(line, label...)
+	}
+
+	if len(columns) > 0 && !columns[0].bar {
+		put(firstMeasure)
+	}
+	for i, col := range columns {
+		pos += col.width()
+		if col.bar && col.measure > 0 && i+1 < len(columns) {
+			put(col.measure)
+		}
+	}
+
+	return strings.TrimRight(string(line), " ")
 }
 
 type TabOption func(*TabWriter)
@@ -132,3 +433,60 @@ func WithDefaultTimeStep() TabOption {
 		tb.timeStep = 0.2
 	}
 }
+
+// WithTimeSignature enables bar lines: a "|" is inserted every time a
+// measure of the given signature ends.
+func WithTimeSignature(beats, beatUnit int) TabOption {
+	return func(tb *TabWriter) {
+		tb.timeSignature = TimeSignature{Beats: beats, BeatUnit: beatUnit}
+	}
+}
+
+// WithTempo sets the tempo in quarter notes per minute used to place
+// bar lines. The default is 120.
+func WithTempo(bpm float32) TabOption {
+	return func(tb *TabWriter) {
+		tb.tempo = bpm
+	}
+}
+
+// WithTiming follows the tempo and time signature changes of timing.
+// WithTempo and WithTimeSignature give the values before the first change.
+func WithTiming(timing Timing) TabOption {
+	return func(tb *TabWriter) {
+		tb.timing = Timing{
+			Tempos:         append([]TempoChange(nil), timing.Tempos...),
+			TimeSignatures: append([]TimeSignatureChange(nil), timing.TimeSignatures...),
+		}
+	}
+}
+
+// WithMeasureNumbers adds a line of measure numbers above the tab.
+func WithMeasureNumbers() TabOption {
+	return func(tb *TabWriter) {
+		tb.measureNumbers = true
+	}
+}
+
+// WithBarSplit sets what happens to notes that ring across a bar line.
+func WithBarSplit(policy BarSplitPolicy) TabOption {
+	return func(tb *TabWriter) {
+		tb.barSplit = policy
+	}
+}
+
+// WithLineWidth breaks the tab into stacked systems no wider than width
+// characters. Systems are broken at bar lines when the tab has them.
+func WithLineWidth(width int) TabOption {
+	return func(tb *TabWriter) {
+		tb.lineWidth = width
+	}
+}
+
+// WithRhythm adds a rhythm line above the tab with a duration symbol
+// ("w", "h", "q", "e", "s", "t", dotted "q.") over every note column.
+func WithRhythm() TabOption {
+	return func(tb *TabWriter) {
+		tb.rhythm = true
+	}
+}
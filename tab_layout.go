@@ -0,0 +1,131 @@
+package guitar
+
+import "math"
+
+// tabSystem is one stacked block of the tab: every string line is
+// rendered with its own header and the blocks are printed one under another.
+type tabSystem struct {
+	columns      []tabColumn
+	firstMeasure int
+	last         bool
+
+	// start and end bound the times written in the system.
+	start, end float32
+}
+
+// systems splits columns into systems that fit the line width.
+// Whole measures are kept together whenever they fit; a measure wider than
+// a line, or a tab without bar lines, is broken between columns.
+func (tb *TabWriter) systems(columns []tabColumn) []tabSystem {
+	if tb.lineWidth <= 0 {
+		return boundSystems([]tabSystem{{columns: columns, firstMeasure: 1}})
+	}
+
+	available := tb.lineWidth - len(tb.stringNames[0]) - 1
+
+	systems := []tabSystem{}
+	current := tabSystem{firstMeasure: 1}
+	currentWidth := 0
+	measure := 1
+
+	flush := func() {
+		if len(current.columns) == 0 {
+			return
+		}
+		systems = append(systems, current)
+		current = tabSystem{firstMeasure: measure}
+		currentWidth = 0
+	}
+
+	for _, segment := range tb.segments(columns) {
+		width := segmentWidth(segment)
+		if !segment[len(segment)-1].bar && tb.hasBars() {
+			// the closing bar line is added after the last measure
+			width++
+		}
+
+		if currentWidth+width > available {
+			flush()
+		}
+
+		if width > available {
+			for _, col := range segment {
+				if currentWidth+col.width() > available {
+					flush()
+				}
+				current.columns = append(current.columns, col)
+				currentWidth += col.width()
+			}
+		} else {
+			current.columns = append(current.columns, segment...)
+			currentWidth += width
+		}
+
+		if last := segment[len(segment)-1]; last.bar && last.measure > 0 {
+			measure = last.measure
+		}
+	}
+
+	flush()
+	if len(systems) == 0 {
+		systems = append(systems, current)
+	}
+
+	return boundSystems(systems)
+}
+
+// boundSystems marks the last system and sets the time bounds of each
+// system so that they cover the whole time line.
+func boundSystems(systems []tabSystem) []tabSystem {
+	inf := float32(math.Inf(1))
+
+	for i := range systems {
+		systems[i].start, systems[i].end = -inf, inf
+		if i > 0 {
+			for _, col := range systems[i].columns {
+				if !col.bar {
+					systems[i].start = col.time
+					systems[i-1].end = col.time
+					break
+				}
+			}
+		}
+	}
+	systems[len(systems)-1].last = true
+
+	return systems
+}
+
+// segments groups columns into the units a line may be broken between:
+// whole measures (including their closing bar line) or single columns.
+func (tb *TabWriter) segments(columns []tabColumn) [][]tabColumn {
+	segments := [][]tabColumn{}
+
+	if !tb.hasBars() {
+		for i := range columns {
+			segments = append(segments, columns[i:i+1])
+		}
+		return segments
+	}
+
+	start := 0
+	for i, col := range columns {
+		if col.bar {
+			segments = append(segments, columns[start:i+1])
+			start = i + 1
+		}
+	}
+	if start < len(columns) {
+		segments = append(segments, columns[start:])
+	}
+
+	return segments
+}
+
+func segmentWidth(columns []tabColumn) int {
+	width := 0
+	for _, col := range columns {
+		width += col.width()
+	}
+	return width
+}
@@ -0,0 +1,57 @@
+package guitar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTabLineWidth(t *testing.T) {
+	testCases := []struct {
+		name        string
+		opts        []TabOption
+		expectedTab string
+	}{
+		{
+			name:        "fits in one line",
+			opts:        []TabOption{WithTimeSignature(2, 4), WithLineWidth(80)},
+			expectedTab: "e|3-----|-----|-12-|\nB|--5---|-----|----|\nG|---7--|-----|----|\n",
+		},
+		{
+			name: "break at bar lines",
+			opts: []TabOption{WithTimeSignature(2, 4), WithMeasureNumbers(), WithLineWidth(16)},
+			expectedTab: "  1      2\ne|3-----|-----|\nB|--5---|-----|\nG|---7--|-----|\n\n" +
+				"  3\ne|-12-|\nB|----|\nG|----|\n",
+		},
+		{
+			name: "break between columns without bars",
+			opts: []TabOption{WithLineWidth(6)},
+			expectedTab: "e|3---\nB|--5-\nG|---7\n\ne|----\nB|----\nG|----\n\n" +
+				"e|----\nB|----\nG|----\n\ne|12-\nB|---\nG|---\n",
+		},
+		{
+			name: "measure wider than a line",
+			opts: []TabOption{WithTimeSignature(4, 4), WithLineWidth(8)},
+			expectedTab: "e|3-----\nB|--5---\nG|---7--\n\ne|-----|\nB|-----|\nG|-----|\n\n" +
+				"e|-12-|\nB|----|\nG|----|\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tb, err := NewTabWriter([]string{"e", "B", "G"}, tc.opts...)
+			assert.NoError(t, err)
+
+			err = tb.WriteNotes(
+				Note{Fret: 3, String: 0, Time: 0},
+				Note{Fret: 5, String: 1, Time: 0.4},
+				Note{Fret: 7, String: 2, Time: 0.6, Duration: 1.6},
+			)
+			assert.NoError(t, err)
+			err = tb.WriteNotes(Note{Fret: 12, String: 0, Time: 2.2})
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.expectedTab, tb.Tab())
+		})
+	}
+}
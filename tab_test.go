@@ -86,7 +86,7 @@ func TestWriteNotes(t *testing.T) {
 		},
 		{
 			name:        "chord",
-			notes:       [][]Playable{ParseChord("1 0 0 0 2 3", 0)},
+			notes:       [][]Playable{mustParseChord("1 0 0 0 2 3", 0)},
 			expectedTab: "e|1-\nB|0-\nG|0-\nD|0-\nA|2-\nE|3-\n",
 		},
 	}
@@ -113,3 +113,115 @@ func TestWriteNotes(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteNotesSeveralTimesInOneCall(t *testing.T) {
+	tb, _ := NewTabWriter([]string{"e", "B", "G"})
+
+	err := tb.WriteNotes(
+		Note{Fret: 3, String: 0, Time: 0},
+		Note{Fret: 5, String: 1, Time: 0.2},
+		Note{Fret: 7, String: 2, Time: 0.4},
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "e|3---\nB|-5--\nG|--7-\n", tb.Tab())
+}
+
+func TestWriteNotesWithBars(t *testing.T) {
+	testCases := []struct {
+		name        string
+		opts        []TabOption
+		expectedTab string
+	}{
+		{
+			name:        "bar lines",
+			opts:        []TabOption{WithTimeSignature(4, 4)},
+			expectedTab: "e|3----------|-12-|\nB|--5--------|----|\nG|---7-------|----|\n",
+		},
+		{
+			name:        "measure numbers",
+			opts:        []TabOption{WithTimeSignature(2, 4), WithMeasureNumbers()},
+			expectedTab: "  1      2     3\ne|3-----|-----|-12-|\nB|--5---|-----|----|\nG|---7--|-----|----|\n",
+		},
+		{
+			name:        "tie across bars",
+			opts:        []TabOption{WithTimeSignature(2, 4), WithBarSplit(BarSplitTie)},
+			expectedTab: "e|3-----|-------|---12-|\nB|--5---|-------|------|\nG|---7--|(7)----|(7)---|\n",
+		},
+		{
+			name:        "slower tempo",
+			opts:        []TabOption{WithTimeSignature(2, 4), WithTempo(60)},
+			expectedTab: "e|3----------|-12-|\nB|--5--------|----|\nG|---7-------|----|\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tb, err := NewTabWriter([]string{"e", "B", "G"}, tc.opts...)
+			assert.NoError(t, err)
+
+			err = tb.WriteNotes(
+				Note{Fret: 3, String: 0, Time: 0},
+				Note{Fret: 5, String: 1, Time: 0.4},
+				Note{Fret: 7, String: 2, Time: 0.6, Duration: 1.6},
+			)
+			assert.NoError(t, err)
+			err = tb.WriteNotes(Note{Fret: 12, String: 0, Time: 2.2})
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.expectedTab, tb.Tab())
+		})
+	}
+}
+
+func TestWriteNotesTruncateAtBar(t *testing.T) {
+	tb, _ := NewTabWriter([]string{"e"}, WithTimeSignature(2, 4), WithBarSplit(BarSplitTruncate))
+
+	err := tb.WriteNotes(Note{Fret: 7, String: 0, Time: 0.6, Duration: 1.6})
+	assert.NoError(t, err)
+
+	written := tb.columns[len(tb.columns)-2].notes[0].(Note)
+	assert.InDelta(t, 0.4, written.Duration, 1e-6)
+}
+
+func TestWriteNotesTieAtEnd(t *testing.T) {
+	tb, _ := NewTabWriter([]string{"e", "B"}, WithTimeSignature(2, 4), WithBarSplit(BarSplitTie))
+
+	err := tb.WriteNotes(Note{Fret: 3, String: 0, Time: 0}, Note{Fret: 7, String: 1, Time: 0.6, Duration: 1.6})
+	assert.NoError(t, err)
+	assert.Equal(t, "e|3----|----|----|\nB|---7-|(7)-|(7)-|\n", tb.Tab())
+
+	err = tb.WriteNotes(Note{Fret: 12, String: 0, Time: 2.2})
+	assert.NoError(t, err)
+	assert.Equal(t, "e|3-----|-------|---12-|\nB|---7--|(7)----|(7)---|\n", tb.Tab())
+}
+
+func TestWriteNotesHarmonicTie(t *testing.T) {
+	tb, _ := NewTabWriter([]string{"e"}, WithTimeSignature(2, 4), WithBarSplit(BarSplitTie))
+
+	err := tb.WriteNotes(Harmonic{Fret: 12, String: 0, Time: 0.6, Duration: 0.8}, Note{Fret: 3, String: 0, Time: 1.4})
+	assert.NoError(t, err)
+	assert.Equal(t, "e|---<12>-|(<12>)-3-|\n", tb.Tab())
+}
+
+func TestNewTabWriterInvalidTimeSignature(t *testing.T) {
+	_, err := NewTabWriter([]string{"e"}, WithTimeSignature(4, 3))
+	assert.Error(t, err)
+
+	_, err = NewTabWriter([]string{"e"}, WithTimeSignature(4, 4), WithTempo(0))
+	assert.Error(t, err)
+}
+
+func TestWriteBarLine(t *testing.T) {
+	tb, _ := NewTabWriter([]string{"e", "B"}, WithTimeSignature(2, 4))
+
+	assert.NoError(t, tb.WriteBarLine(RepeatStart, 0))
+	assert.NoError(t, tb.WriteNotes(Note{Fret: 1, String: 0, Time: 0}))
+	assert.NoError(t, tb.WriteBarLine(RepeatEnd, 1))
+	assert.NoError(t, tb.WriteBarLine(RepeatStart, 1))
+	assert.NoError(t, tb.WriteNotes(Note{Fret: 2, String: 1, Time: 1.2}))
+	assert.NoError(t, tb.WriteBarLine(DoubleBar, 1.6))
+	assert.Error(t, tb.WriteBarLine(SingleBar, 0.5))
+
+	assert.Equal(t, "e|:1-----:|:----||\nB|:------:|:-2--||\n", tb.Tab())
+}
@@ -0,0 +1,373 @@
+package guitar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// TabParseError reports where a tab could not be read. Line and Column
+// are 1-based and point into the original text.
+type TabParseError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *TabParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+type ParseOption func(*tabParser)
+
+// WithColumnTime sets the time one tab column lasts. The default matches
+// the default TabWriter time step.
+func WithColumnTime(step float32) ParseOption {
+	return func(p *tabParser) {
+		p.step = step
+	}
+}
+
+// staffHeader matches the string name and the opening bar line of a
+// staff line, e.g. "e|", "D#||" or " A :".
+var staffHeader = regexp.MustCompile(`^\s*([A-Ga-g][#b♯♭]?[0-9]?)?\s*(\|\|?:?|:)?`)
+
+type tabParser struct {
+	tuning Tuning
+	step   float32
+
+	columns int
+	notes   []Playable
+	// frets is the fret each string was left at, for telling ties from
+	// ghost notes
+	frets map[int]int
+}
+
+// staffLine is the body of a staff line after its header.
+type staffLine struct {
+	body   []rune
+	line   int
+	offset int
+}
+
+// ParseTab reads an ASCII tab into time ordered Playables. Staff lines
+// are grouped into systems of len(tuning) lines, the top line being
+// string 0; systems follow each other in time. Text between systems,
+// bar lines and unknown symbols are skipped. Every column that is not a
+// bar line lasts the column time. A chain such as "5h7p5" is read as
+// one HammerOn, PullOff or Slide per step, never as a Legato.
+func ParseTab(r io.Reader, tuning Tuning, opts ...ParseOption) ([]Playable, error) {
+	if len(tuning) == 0 {
+		return nil, fmt.Errorf("empty tuning")
+	}
+
+	p := &tabParser{tuning: tuning, step: 0.2, frets: map[int]int{}}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	system := []staffLine{}
+	flush := func() error {
+		if len(system) == 0 {
+			return nil
+		}
+		if len(system) != len(tuning) {
+			return &TabParseError{Line: system[0].line, Column: 1,
+				Msg: fmt.Sprintf("found %d staff lines, tuning has %d strings", len(system), len(tuning))}
+		}
+		err := p.parseSystem(system)
+		system = system[:0]
+		return err
+	}
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line, ok := parseStaffLine(scanner.Text(), lineNumber)
+		if !ok {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		system = append(system, line)
+		if len(system) == len(tuning) {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(p.notes, func(i, j int) bool { return p.notes[i].StartTime() < p.notes[j].StartTime() })
+	return p.notes, nil
+}
+
+// parseStaffLine splits a staff line into header and body. Lines with
+// more letters than dashes are text, not a staff.
+func parseStaffLine(text string, lineNumber int) (staffLine, bool) {
+	text = strings.TrimRightFunc(text, unicode.IsSpace)
+	header := staffHeader.FindString(text)
+
+	body := []rune(text[len(header):])
+	if len(body) < 2 {
+		return staffLine{}, false
+	}
+	if strings.TrimSpace(header) == "" && body[0] != '-' {
+		// without a header only a line starting with a dash is a staff,
+		// which keeps annotations such as "P.M.----|" out
+		return staffLine{}, false
+	}
+
+	dashes, letters := 0, 0
+	for _, r := range body {
+		switch {
+		case r == '-':
+			dashes++
+		case unicode.IsLetter(r):
+			letters++
+		}
+	}
+	if dashes == 0 || dashes < letters {
+		return staffLine{}, false
+	}
+
+	return staffLine{body: body, line: lineNumber, offset: len([]rune(header))}, true
+}
+
+func (p *tabParser) parseSystem(system []staffLine) error {
+	width := 0
+	for _, l := range system {
+		width = max(width, len(l.body))
+	}
+
+	// times[c] is the time of column c; bar line columns take no time
+	times := make([]float32, width+1)
+	for c := 0; c < width; c++ {
+		times[c] = float32(p.columns) * p.step
+		if !isBarColumn(system, c) {
+			p.columns++
+		}
+	}
+	times[width] = float32(p.columns) * p.step
+
+	for stringNumber, l := range system {
+		if c, n := longFret(l.body); n > 0 {
+			return &TabParseError{Line: l.line, Column: l.offset + c + 1,
+				Msg: fmt.Sprintf("fret %q has more than two digits", string(l.body[c:c+n]))}
+		}
+		for c := 0; c < len(l.body); {
+			afterBar := c == 0 || l.body[c-1] == '|' || l.body[c-1] == ':'
+			n, notes, err := p.parseToken(l.body[c:], stringNumber, afterBar, func(i int) float32 { return times[c+i] })
+			if err != nil {
+				return &TabParseError{Line: l.line, Column: l.offset + c + err.column + 1, Msg: err.msg}
+			}
+			p.notes = append(p.notes, notes...)
+			c += max(n, 1)
+		}
+	}
+
+	return nil
+}
+
+func isBarColumn(system []staffLine, c int) bool {
+	bar := false
+	for _, l := range system {
+		if c >= len(l.body) {
+			continue
+		}
+		switch l.body[c] {
+		case '|':
+			bar = true
+		case ':', '*', ' ':
+		default:
+			return false
+		}
+	}
+	return bar
+}
+
+// tokenError is a parse error at a column relative to the token start.
+type tokenError struct {
+	column int
+	msg    string
+}
+
+// parseToken reads the symbol at the start of s and returns the number of
+// runes it takes. Symbols are read with the registered techniques; those
+// ending in a fret may be followed by others starting from it, e.g.
+// "5h7p5". Runes that do not start a known symbol are skipped.
+func (p *tabParser) parseToken(s []rune, stringNumber int, afterBar bool, timeAt func(int) float32) (int, []Playable, *tokenError) {
+	m, ok, err := matchSymbol(s, false)
+
+	// a tied note "(5)" right after a bar line keeps the note left on
+	// that fret ringing; otherwise it is a ghost note
+	fret, tie := tieLength(s)
+	if last, rings := p.frets[stringNumber]; tie > 0 && tie >= m.n && afterBar && rings && last == fret {
+		return tie, nil, nil
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	if !ok {
+		return 1, nil, nil
+	}
+
+	notes := []Playable{}
+	pos, time := 0, timeAt(0)
+	for {
+		note, err := p.newPlayable(m, stringNumber, time)
+		if err != nil {
+			return 0, nil, &tokenError{pos + err.column, err.msg}
+		}
+		if note != nil {
+			notes = append(notes, note)
+		}
+		if len(m.frets) > 0 {
+			p.frets[stringNumber] = m.frets[len(m.frets)-1]
+		} else {
+			delete(p.frets, stringNumber)
+		}
+
+		end := pos + m.n
+		if !chains(m.pattern) {
+			return end, notes, nil
+		}
+
+		// the next technique starts from the last fret and plays at the
+		// column following it
+		_, last := readFretBack(s[:end])
+		next, ok, err := matchSymbol(s[end-last:], true)
+		if err != nil {
+			return 0, nil, &tokenError{end - last + err.column, err.msg}
+		}
+		if !ok {
+			n, err := p.endChain(s[end-last:], last, notes, stringNumber, time)
+			if err != nil {
+				return 0, nil, &tokenError{end - last + err.column, err.msg}
+			}
+			return end - last + n, notes, nil
+		}
+		m, pos, time = next, end-last, timeAt(end)
+	}
+}
+
+// endChain reads a technique written on the last fret of a chain, such as
+// the vibrato of "5h7~", adding its articulations to the last note. s
+// starts at the fret, fretLength runes long. It returns the runes read,
+// or an error for a technique that can not end a chain.
+func (p *tabParser) endChain(s []rune, fretLength int, notes []Playable, stringNumber int, time float32) (int, *tokenError) {
+	m, ok, err := matchSymbol(s, false)
+	if err != nil {
+		return 0, err
+	}
+	if !ok || !m.pattern[0].fret || m.n <= fretLength {
+		return fretLength, nil
+	}
+
+	suffix, isArticulated := m.technique.New(m.frets, stringNumber, time).(Articulated)
+	if _, isNote := Unwrap(suffix).(Note); !isArticulated || !isNote || len(notes) == 0 {
+		return 0, &tokenError{0, fmt.Sprintf("%s %q can not end a chain", m.technique.Name, string(s[:m.n]))}
+	}
+	notes[len(notes)-1] = Articulate(notes[len(notes)-1], suffix.Articulations)
+	return m.n, nil
+}
+
+func (p *tabParser) newPlayable(m symbolMatch, stringNumber int, time float32) (Playable, *tokenError) {
+	pl, err := p.pitch(m.technique.New(m.frets, stringNumber, time))
+	if err == nil {
+		err = validateBender(pl)
+	}
+	if err != nil {
+		return nil, &tokenError{0, err.Error()}
+	}
+	return pl, nil
+}
+
+// pitch names a Note built without a name, also inside articulations,
+// from the tuning. Without a tuning notes are left unnamed.
+func (p *tabParser) pitch(pl Playable) (Playable, error) {
+	switch n := pl.(type) {
+	case Note:
+		if n.Name != "" || len(p.tuning) == 0 {
+			return n, nil
+		}
+		note, err := p.tuning.NoteAt(n.String, n.Fret)
+		if err != nil {
+			return nil, err
+		}
+		note.Time, note.Duration = n.Time, n.Duration
+		return note, nil
+	case Articulated:
+		inner, err := p.pitch(n.Playable)
+		if err != nil {
+			return nil, err
+		}
+		n.Playable = inner
+		return n, nil
+	}
+	return pl, nil
+}
+
+// tieLength returns the fret and length of a tied note "(5)" at the
+// start of s, or a length of 0.
+func tieLength(s []rune) (int, int) {
+	if len(s) == 0 || s[0] != '(' {
+		return 0, 0
+	}
+	fret, n := readFret(s[1:])
+	if n > 0 && 1+n < len(s) && s[1+n] == ')' {
+		return fret, n + 2
+	}
+	return 0, 0
+}
+
+// longFret finds the first run of more than two digits in s, which no
+// fret is written with, returning its start and length.
+func longFret(s []rune) (int, int) {
+	for i := 0; i < len(s); {
+		n := 0
+		for i+n < len(s) && isDigit(s[i+n]) {
+			n++
+		}
+		if n > 2 {
+			return i, n
+		}
+		i += max(n, 1)
+	}
+	return 0, 0
+}
+
+// readFretBack reads the fret at the end of s.
+func readFretBack(s []rune) (int, int) {
+	n := 0
+	for n < len(s) && n < 2 && isDigit(s[len(s)-1-n]) {
+		n++
+	}
+	fret, _ := readFret(s[len(s)-n:])
+	return fret, n
+}
+
+func readFret(s []rune) (int, int) {
+	fret, n := 0, 0
+	for n < len(s) && n < 2 && isDigit(s[n]) {
+		fret = fret*10 + int(s[n]-'0')
+		n++
+	}
+	return fret, n
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
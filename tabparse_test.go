@@ -0,0 +1,135 @@
+package guitar
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTab(t *testing.T) {
+	tun, _ := ParseTuning(StandardTuning)
+
+	tab := `Song title
+  Am
+    P.M.---|
+e|0------|--------|
+B|1------|--3h5p3-|
+G|2------|--------|
+D|2--x---|--5/7---|
+A|0------|--<12>--|
+E|-------|-12-----|
+  la la
+
+e ||--10--||
+B ||------||
+G ||------||
+D ||------||
+A ||------||
+E ||------||
+`
+
+	ps, err := ParseTab(strings.NewReader(tab), tun)
+	assert.NoError(t, err)
+
+	expected := []Playable{
+		Note{Name: "E", Octave: 4, Fret: 0, String: 0, Time: 0},
+		Note{Name: "C", Octave: 4, Fret: 1, String: 1, Time: 0},
+		Note{Name: "A", Octave: 3, Fret: 2, String: 2, Time: 0},
+		Note{Name: "E", Octave: 3, Fret: 2, String: 3, Time: 0},
+		Note{Name: "A", Octave: 2, Fret: 0, String: 4, Time: 0},
+		DeadNote{String: 3, Time: 0.6},
+		Note{Name: "E", Octave: 3, Fret: 12, String: 5, Time: 1.6},
+		HammerOn{FretFrom: 3, FretTo: 5, String: 1, Time: 1.8},
+		Slide{FretStart: 5, FretEnd: 7, String: 3, Time: 1.8},
+		Harmonic{Fret: 12, String: 4, Time: 1.8},
+		PullOff{FretFrom: 5, FretTo: 3, String: 1, Time: 2.4},
+		Note{Name: "D", Octave: 5, Fret: 10, String: 0, Time: 3.4},
+	}
+
+	assert.Equal(t, len(expected), len(ps))
+	for i := range expected {
+		assert.IsType(t, expected[i], ps[i])
+		assert.Equal(t, expected[i].TabSymbol(), ps[i].TabSymbol())
+		assert.Equal(t, expected[i].StringNumber(), ps[i].StringNumber())
+		assert.InDelta(t, expected[i].StartTime(), ps[i].StartTime(), 1e-4)
+	}
+	assert.Equal(t, "D", ps[11].(Note).Name)
+}
+
+func TestParseTabWriterOutput(t *testing.T) {
+	tun, _ := ParseTuning(StandardTuning)
+	tb, _ := NewTabWriter(tun.NoteNames(), WithTimeSignature(4, 4), WithMeasureNumbers())
+	_ = tb.WriteNotes(mustParseChord("0 1 2 2 0 -", 0)...)
+	_ = tb.WriteNotes(Slide{FretStart: 5, FretEnd: 7, String: 2, Time: 0.4})
+
+	ps, err := ParseTab(strings.NewReader(tb.Tab()), tun)
+
+	assert.NoError(t, err)
+	assert.Len(t, ps, 6)
+	assert.Equal(t, "5/7", ps[5].TabSymbol())
+}
+
+func TestParseTabGhostNotes(t *testing.T) {
+	tun, _ := ParseTuning(StandardTuning)
+	tb, _ := NewTabWriter(tun.NoteNames(), WithTimeSignature(4, 4), WithBarSplit(BarSplitTie))
+	err := tb.WriteNotes(
+		Articulate(Note{Fret: 3, String: 1, Time: 0.4}, Ghost),
+		Note{Fret: 5, String: 0, Time: 1.6, Duration: 0.8},
+		Articulate(Note{Fret: 7, String: 2, Time: 2}, Ghost),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"e|----------5-|(5)-|",
+		"B|--(3)-------|----|",
+		"G|------------|(7)-|",
+	}, strings.Split(tb.Tab(), "\n")[:3])
+
+	ps, err := ParseTab(strings.NewReader(tb.Tab()), tun)
+	assert.NoError(t, err)
+	// the tied "(5)" only keeps the note ringing
+	assert.Len(t, ps, 3)
+	assert.Equal(t, []string{"(3)", "5", "(7)"}, []string{ps[0].TabSymbol(), ps[1].TabSymbol(), ps[2].TabSymbol()})
+	assert.Equal(t, Ghost, ps[2].(Articulated).Articulations)
+	assert.InDelta(t, 2.4, ps[2].StartTime(), 1e-4)
+}
+
+func TestParseTabChainEnd(t *testing.T) {
+	tun, _ := ParseTuning("E4 B3")
+
+	ps, err := ParseTab(strings.NewReader("e|-3/5h7~-|\nB|---------|\n"), tun)
+	assert.NoError(t, err)
+	assert.Equal(t, []Playable{
+		Slide{FretStart: 3, FretEnd: 5, String: 0, Time: 0.2},
+		Articulate(HammerOn{FretFrom: 5, FretTo: 7, String: 0, Time: 0.8}, Vibrato),
+	}, ps)
+}
+
+func TestParseTabErrors(t *testing.T) {
+	tun, _ := ParseTuning("E4 B3")
+
+	testCases := []struct {
+		name   string
+		tab    string
+		line   int
+		column int
+	}{
+		{name: "missing hammer target", tab: "e|--5h-|\nB|-----|\n", line: 1, column: 6},
+		{name: "unclosed harmonic", tab: "\ne|-----|\nB|-<12-|\n", line: 3, column: 4},
+		{name: "too few strings", tab: "e|-----|\n\nB|-----|\n", line: 1, column: 1},
+		{name: "three digit fret", tab: "e|--1234-|\nB|--------|\n", line: 1, column: 5},
+		{name: "artificial harmonic ending a chain", tab: "e|---5h7<12>---|\nB|--------------|\n", line: 1, column: 8},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseTab(strings.NewReader(tc.tab), tun)
+
+			parseErr := &TabParseError{}
+			assert.True(t, errors.As(err, &parseErr))
+			assert.Equal(t, tc.line, parseErr.Line)
+			assert.Equal(t, tc.column, parseErr.Column)
+		})
+	}
+}
@@ -1,19 +1,23 @@
 package guitar
 
-import "fmt"
-
 type Harmonic struct {
-	Fret int
+	Fret int `json:"fret" yaml:"fret"`
 
-	String int
+	String int `json:"string" yaml:"string"`
 
-	Time float32
+	Time     float32 `json:"time,omitempty" yaml:"time,omitempty"`
+	Duration float32 `json:"duration,omitempty" yaml:"duration,omitempty"`
 }
 
 func (h Harmonic) TabSymbol() string {
-	return fmt.Sprintf("<%d>", h.Fret)
+	return harmonicTechnique.Format(h.Fret)
 }
 
+func (h Harmonic) StringNumber() int {
+	return h.String
+}
+
+// Deprecated: use StringNumber.
 func (h Harmonic) StringPosition() int {
 	return h.String
 }
@@ -22,20 +26,30 @@ func (h Harmonic) StartTime() float32 {
 	return h.Time
 }
 
+func (h Harmonic) EndTime() float32 {
+	return h.Time + h.Duration
+}
+
+func (h Harmonic) AtTime(t float32) Playable {
+	h.Time = t
+	return h
+}
+
 type Slide struct {
-	FretStart int
-	FretEnd   int
+	FretStart int `json:"fret_start" yaml:"fret_start"`
+	FretEnd   int `json:"fret_end" yaml:"fret_end"`
 
-	String int
+	String int `json:"string" yaml:"string"`
 
-	Time float32
+	Time     float32 `json:"time,omitempty" yaml:"time,omitempty"`
+	Duration float32 `json:"duration,omitempty" yaml:"duration,omitempty"`
 }
 
 func (s Slide) TabSymbol() string {
 	if s.FretStart == -1 {
-		return fmt.Sprintf("/%d", s.FretEnd)
+		return slideInTechnique.Format(s.FretEnd)
 	}
-	return fmt.Sprintf("%d/%d", s.FretStart, s.FretEnd)
+	return slideTechnique.Format(s.FretStart, s.FretEnd)
 }
 
 func (s Slide) StringNumber() int {
@@ -46,17 +60,27 @@ func (s Slide) StartTime() float32 {
 	return s.Time
 }
 
+func (s Slide) EndTime() float32 {
+	return s.Time + s.Duration
+}
+
+func (s Slide) AtTime(t float32) Playable {
+	s.Time = t
+	return s
+}
+
 type HammerOn struct {
-	FretFrom int
-	FretTo   int
+	FretFrom int `json:"fret_from" yaml:"fret_from"`
+	FretTo   int `json:"fret_to" yaml:"fret_to"`
 
-	String int
+	String int `json:"string" yaml:"string"`
 
-	Time float32
+	Time     float32 `json:"time,omitempty" yaml:"time,omitempty"`
+	Duration float32 `json:"duration,omitempty" yaml:"duration,omitempty"`
 }
 
 func (h HammerOn) TabSymbol() string {
-	return fmt.Sprintf("%dh%d", h.FretFrom, h.FretTo)
+	return hammerOnTechnique.Format(h.FretFrom, h.FretTo)
 }
 
 func (h HammerOn) StringNumber() int {
@@ -67,17 +91,27 @@ func (h HammerOn) StartTime() float32 {
 	return h.Time
 }
 
+func (h HammerOn) EndTime() float32 {
+	return h.Time + h.Duration
+}
+
+func (h HammerOn) AtTime(t float32) Playable {
+	h.Time = t
+	return h
+}
+
 type PullOff struct {
-	FretFrom int
-	FretTo   int
+	FretFrom int `json:"fret_from" yaml:"fret_from"`
+	FretTo   int `json:"fret_to" yaml:"fret_to"`
 
-	String int
+	String int `json:"string" yaml:"string"`
 
-	Time float32
+	Time     float32 `json:"time,omitempty" yaml:"time,omitempty"`
+	Duration float32 `json:"duration,omitempty" yaml:"duration,omitempty"`
 }
 
 func (p PullOff) TabSymbol() string {
-	return fmt.Sprintf("%dp%d", p.FretFrom, p.FretTo)
+	return pullOffTechnique.Format(p.FretFrom, p.FretTo)
 }
 
 func (p PullOff) StringNumber() int {
@@ -87,3 +121,41 @@ func (p PullOff) StringNumber() int {
 func (p PullOff) StartTime() float32 {
 	return p.Time
 }
+
+func (p PullOff) EndTime() float32 {
+	return p.Time + p.Duration
+}
+
+func (p PullOff) AtTime(t float32) Playable {
+	p.Time = t
+	return p
+}
+
+// DeadNote is a muted string struck without a pitch, written as "x".
+type DeadNote struct {
+	String int `json:"string" yaml:"string"`
+
+	Time     float32 `json:"time,omitempty" yaml:"time,omitempty"`
+	Duration float32 `json:"duration,omitempty" yaml:"duration,omitempty"`
+}
+
+func (d DeadNote) TabSymbol() string {
+	return deadNoteTechnique.Format()
+}
+
+func (d DeadNote) StringNumber() int {
+	return d.String
+}
+
+func (d DeadNote) StartTime() float32 {
+	return d.Time
+}
+
+func (d DeadNote) EndTime() float32 {
+	return d.Time + d.Duration
+}
+
+func (d DeadNote) AtTime(t float32) Playable {
+	d.Time = t
+	return d
+}
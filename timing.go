@@ -0,0 +1,171 @@
+package guitar
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	DefaultTempo float32 = 120
+)
+
+var DefaultTimeSignature = TimeSignature{Beats: 4, BeatUnit: 4}
+
+type TempoChange struct {
+	Time float32 `json:"time,omitempty" yaml:"time,omitempty"`
+	BPM  float32 `json:"bpm" yaml:"bpm"`
+}
+
+type TimeSignatureChange struct {
+	Time      float32       `json:"time,omitempty" yaml:"time,omitempty"`
+	Signature TimeSignature `json:"signature,omitempty" yaml:"signature,omitempty"`
+}
+
+// Timing is the tempo map and the time signature changes of a song.
+// Every change lasts until the next one; times are in seconds.
+type Timing struct {
+	Tempos         []TempoChange         `json:"tempos,omitempty" yaml:"tempos,omitempty"`
+	TimeSignatures []TimeSignatureChange `json:"time_signatures,omitempty" yaml:"time_signatures,omitempty"`
+}
+
+func (t Timing) Validate() error {
+	for _, c := range t.Tempos {
+		if c.BPM <= 0 {
+			return fmt.Errorf("invalid tempo %v at %v", c.BPM, c.Time)
+		}
+		if c.Time < 0 {
+			return fmt.Errorf("invalid tempo change time %v", c.Time)
+		}
+	}
+
+	for _, c := range t.TimeSignatures {
+		if err := c.Signature.Validate(); err != nil {
+			return err
+		}
+		if c.Time < 0 {
+			return fmt.Errorf("invalid time signature change time %v", c.Time)
+		}
+	}
+
+	return nil
+}
+
+// Sort orders the changes by time.
+func (t *Timing) Sort() {
+	sort.SliceStable(t.Tempos, func(i, j int) bool { return t.Tempos[i].Time < t.Tempos[j].Time })
+	sort.SliceStable(t.TimeSignatures, func(i, j int) bool { return t.TimeSignatures[i].Time < t.TimeSignatures[j].Time })
+}
+
+// TempoAt returns the tempo in effect at time, or def before the first change.
+func (t Timing) TempoAt(time, def float32) float32 {
+	tempo := def
+	for _, c := range t.Tempos {
+		if c.Time > time+timeEpsilon {
+			break
+		}
+		tempo = c.BPM
+	}
+	return tempo
+}
+
+// TimeSignatureAt returns the time signature in effect at time, or def
+// before the first change.
+func (t Timing) TimeSignatureAt(time float32, def TimeSignature) TimeSignature {
+	ts := def
+	for _, c := range t.TimeSignatures {
+		if c.Time > time+timeEpsilon {
+			break
+		}
+		ts = c.Signature
+	}
+	return ts
+}
+
+// Beats converts a length of time starting at start into quarter notes,
+// following the tempo changes on the way.
+func (t Timing) Beats(start, length, def float32) float32 {
+	var beats float32
+
+	end := start + length
+	for start < end-timeEpsilon {
+		next := end
+		for _, c := range t.Tempos {
+			if c.Time > start+timeEpsilon {
+				next = min(next, c.Time)
+				break
+			}
+		}
+		beats += (next - start) * t.TempoAt(start, def) / 60
+		start = next
+	}
+
+	return beats
+}
+
+// quarterTiming collects tempo and time signature changes placed in
+// quarter notes from the start, as read from scores, and converts them
+// to seconds.
+type quarterTiming struct {
+	tempos     []quarterTempo
+	signatures []quarterSignature
+}
+
+type quarterTempo struct {
+	quarter float64
+	bpm     float64
+}
+
+type quarterSignature struct {
+	quarter   float64
+	signature TimeSignature
+}
+
+func (qt *quarterTiming) addTempo(quarter, bpm float64) {
+	qt.tempos = append(qt.tempos, quarterTempo{quarter: quarter, bpm: bpm})
+}
+
+func (qt *quarterTiming) addSignature(quarter float64, ts TimeSignature) {
+	qt.signatures = append(qt.signatures, quarterSignature{quarter: quarter, signature: ts})
+}
+
+// timing sorts the changes and converts them to seconds, the first of two
+// changes at the same position winning. Call it before seconds.
+func (qt *quarterTiming) timing() Timing {
+	sort.SliceStable(qt.tempos, func(i, j int) bool { return qt.tempos[i].quarter < qt.tempos[j].quarter })
+	sort.SliceStable(qt.signatures, func(i, j int) bool { return qt.signatures[i].quarter < qt.signatures[j].quarter })
+
+	tempos := []quarterTempo{}
+	for _, t := range qt.tempos {
+		if n := len(tempos); n == 0 || t.quarter-tempos[n-1].quarter >= timeEpsilon {
+			tempos = append(tempos, t)
+		}
+	}
+	qt.tempos = tempos
+
+	timing := Timing{}
+	for _, t := range qt.tempos {
+		timing.Tempos = append(timing.Tempos, TempoChange{Time: qt.seconds(t.quarter), BPM: float32(t.bpm)})
+	}
+	for i, ts := range qt.signatures {
+		if i > 0 && ts.quarter-qt.signatures[i-1].quarter < timeEpsilon {
+			continue
+		}
+		timing.TimeSignatures = append(timing.TimeSignatures, TimeSignatureChange{Time: qt.seconds(ts.quarter), Signature: ts.signature})
+	}
+	return timing
+}
+
+// seconds converts quarter notes from the start to seconds.
+func (qt *quarterTiming) seconds(quarter float64) float32 {
+	var seconds float64
+	last, bpm := 0.0, float64(DefaultTempo)
+	for _, t := range qt.tempos {
+		if t.quarter >= quarter {
+			break
+		}
+		seconds += (t.quarter - last) * 60 / bpm
+		last, bpm = t.quarter, t.bpm
+	}
+	seconds += (quarter - last) * 60 / bpm
+	return float32(seconds)
+}
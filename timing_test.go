@@ -0,0 +1,34 @@
+package guitar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimingBeats(t *testing.T) {
+	timing := Timing{Tempos: []TempoChange{{Time: 0, BPM: 120}, {Time: 2, BPM: 60}}}
+
+	assert.InDelta(t, 2, timing.Beats(0, 1, 100), 1e-6)
+	assert.InDelta(t, 3, timing.Beats(1, 2, 100), 1e-6)
+	assert.InDelta(t, 1, timing.Beats(5, 1, 100), 1e-6)
+}
+
+func TestTimingValidate(t *testing.T) {
+	assert.NoError(t, Timing{}.Validate())
+	assert.Error(t, Timing{Tempos: []TempoChange{{BPM: 0}}}.Validate())
+	assert.Error(t, Timing{TimeSignatures: []TimeSignatureChange{{Signature: TimeSignature{3, 5}}}}.Validate())
+}
+
+func TestTabWriterTimingChanges(t *testing.T) {
+	timing := Timing{
+		TimeSignatures: []TimeSignatureChange{{Time: 0, Signature: TimeSignature{2, 4}}, {Time: 1, Signature: TimeSignature{3, 4}}},
+	}
+	tb, err := NewTabWriter([]string{"e"}, WithTiming(timing))
+	assert.NoError(t, err)
+
+	err = tb.WriteNotes(Note{Fret: 1, Time: 0}, Note{Fret: 2, Time: 1}, Note{Fret: 3, Time: 2.6})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "e|1----|2-------|3-|\n", tb.Tab())
+}
@@ -0,0 +1,230 @@
+package guitar
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var notesFlat = []string{"C", "Db", "D", "Eb", "E", "F", "Gb", "G", "Ab", "A", "Bb", "B"}
+
+// accidentalSigns spells the ♯ and ♭ signs of a note name as # and b.
+var accidentalSigns = strings.NewReplacer("♯", "#", "♭", "b")
+
+// chordNamePattern splits a chord symbol into root, suffix and an
+// optional bass note, e.g. "F#m7/C#" or "C6/9".
+var chordNamePattern = regexp.MustCompile(`^([A-G][#b♯♭]?)(.*?)(?:/([A-G][#b♯♭]?))?$`)
+
+// Transpose moves Playables by semitones along their strings, keeping
+// string and time. A natural harmonic becomes an artificial harmonic
+// touched the same number of frets above the moved note. Moving a fret
+// below the nut is an error.
+func Transpose(ps []Playable, semitones int) ([]Playable, error) {
+	result := make([]Playable, 0, len(ps))
+	for i, p := range ps {
+		moved, err := transposePlayable(p, semitones)
+		if err != nil {
+			return nil, fmt.Errorf("event %d: %w", i, err)
+		}
+		result = append(result, moved)
+	}
+	return result, nil
+}
+
+func transposePlayable(p Playable, semitones int) (Playable, error) {
+	if a, ok := p.(Articulated); ok {
+		inner, err := transposePlayable(a.Playable, semitones)
+		if err != nil {
+			return nil, err
+		}
+		a.Playable = inner
+		return a, nil
+	}
+
+	var err error
+	move := func(fret int) int {
+		moved := fret + semitones
+		if moved < 0 && err == nil {
+			err = fmt.Errorf("string %d: fret %d is moved below the nut", p.StringNumber(), fret)
+		}
+		return moved
+	}
+
+	var result Playable
+	switch n := p.(type) {
+	case Note:
+		n.Fret = move(n.Fret)
+		if n.Name != "" && err == nil {
+			n, err = shiftPitch(n, semitones)
+		}
+		result = n
+	case DeadNote:
+		result = n
+	case Tap:
+		n.Fret = move(n.Fret)
+		result = n
+	case Tremolo:
+		n.Fret = move(n.Fret)
+		result = n
+	case Trill:
+		n.Fret, n.TrillFret = move(n.Fret), move(n.TrillFret)
+		result = n
+	case Harmonic:
+		if semitones == 0 {
+			return n, nil
+		}
+		result = ArtificialHarmonic{Fret: move(0), Offset: n.Fret, String: n.String, Time: n.Time, Duration: n.Duration}
+	case PinchHarmonic:
+		n.Fret = move(n.Fret)
+		result = n
+	case ArtificialHarmonic:
+		n.Fret = move(n.Fret)
+		result = n
+	case Slide:
+		if n.FretStart >= 0 {
+			n.FretStart = move(n.FretStart)
+		}
+		n.FretEnd = move(n.FretEnd)
+		result = n
+	case HammerOn:
+		n.FretFrom, n.FretTo = move(n.FretFrom), move(n.FretTo)
+		result = n
+	case PullOff:
+		n.FretFrom, n.FretTo = move(n.FretFrom), move(n.FretTo)
+		result = n
+	case Bend:
+		n.Fret = move(n.Fret)
+		result = n
+	case PreBend:
+		n.Fret = move(n.Fret)
+		result = n
+	case BendRelease:
+		n.Fret = move(n.Fret)
+		result = n
+	case CompoundBend:
+		n.Fret = move(n.Fret)
+		result = n
+	case Legato:
+		n.Fret = move(n.Fret)
+		steps := make([]LegatoStep, len(n.Steps))
+		for i, s := range n.Steps {
+			if s.Kind != LegatoVibrato {
+				s.Fret = move(s.Fret)
+			}
+			steps[i] = s
+		}
+		n.Steps = steps
+		result = n
+	case Chord:
+		frets := make([]int, len(n.Frets))
+		for i, f := range n.Frets {
+			if f >= 0 {
+				// the chord plays on several strings, name the one moved
+				if f+semitones < 0 && err == nil {
+					err = fmt.Errorf("string %d: fret %d is moved below the nut", i, f)
+				}
+				f += semitones
+			}
+			frets[i] = f
+		}
+		n.Frets = frets
+		if n.Name != "" && err == nil {
+			n.Name, err = TransposeChordName(n.Name, semitones)
+		}
+		result = n
+	default:
+		return nil, fmt.Errorf("can not transpose %T", p)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// shiftPitch moves the named pitch of a note by semitones.
+func shiftPitch(n Note, semitones int) (Note, error) {
+	pitch, err := midiPitch(Note{Name: n.Name, Octave: n.Octave})
+	if err != nil {
+		return n, err
+	}
+	n.Name, n.Octave = pitchNote(pitch + semitones)
+	return n, nil
+}
+
+// TransposeChordName moves a chord symbol such as "F#m7/C#" by
+// semitones. Names written with flats keep flats, others use sharps.
+func TransposeChordName(name string, semitones int) (string, error) {
+	m := chordNamePattern.FindStringSubmatch(name)
+	if m == nil {
+		return "", fmt.Errorf("invalid chord name %q", name)
+	}
+	flats := strings.ContainsAny(m[1], "b♭") || strings.ContainsAny(m[3], "b♭")
+	return transposeChordName(name, semitones, flats)
+}
+
+func transposeChordName(name string, semitones int, flats bool) (string, error) {
+	m := chordNamePattern.FindStringSubmatch(name)
+	if m == nil {
+		return "", fmt.Errorf("invalid chord name %q", name)
+	}
+
+	root, err := transposeNoteName(m[1], semitones, flats)
+	if err != nil {
+		return "", fmt.Errorf("chord %q: %w", name, err)
+	}
+	result := root + m[2]
+	if m[3] != "" {
+		bass, err := transposeNoteName(m[3], semitones, flats)
+		if err != nil {
+			return "", fmt.Errorf("chord %q: %w", name, err)
+		}
+		result += "/" + bass
+	}
+	return result, nil
+}
+
+func transposeNoteName(name string, semitones int, flats bool) (string, error) {
+	n := Note{Name: accidentalSigns.Replace(name)}
+	if err := n.Validate(); err != nil {
+		return "", err
+	}
+
+	index := 0
+	for i, chromo := range notesChromo {
+		if chromo == n.Name {
+			index = i
+		}
+	}
+	index = ((index+semitones)%12 + 12) % 12
+	if flats {
+		return notesFlat[index], nil
+	}
+	return notesChromo[index], nil
+}
+
+// transposeKey moves a key such as "Am" or "F# minor", spelling it with
+// the fewest accidentals.
+func transposeKey(key string, semitones int) (string, error) {
+	if _, _, err := parseKey(key); err != nil {
+		return "", err
+	}
+
+	best, bestFifths := "", 0
+	for _, flats := range []bool{false, true} {
+		moved, err := transposeChordName(strings.TrimSpace(key), semitones, flats)
+		if err != nil {
+			return "", err
+		}
+		fifths, _, err := parseKey(moved)
+		if err != nil {
+			continue
+		}
+		if best == "" || abs(fifths) < abs(bestFifths) {
+			best, bestFifths = moved, fifths
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("can not transpose key %q", key)
+	}
+	return best, nil
+}
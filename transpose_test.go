@@ -0,0 +1,82 @@
+package guitar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranspose(t *testing.T) {
+	ps := []Playable{
+		Note{Name: "A", Octave: 4, Fret: 5, String: 0, Time: 0},
+		Articulate(Slide{FretStart: -1, FretEnd: 5, String: 1, Time: 1}, Vibrato),
+		Harmonic{Fret: 12, String: 5, Time: 2},
+		NewLegato(5, 3, 3).HammerOn(7, 0.1).Vibrato(0.2),
+		NewChord("Am", []int{0, 1, 2, 2, 0, ChordMuted}, 4),
+		DeadNote{String: 4, Time: 5},
+	}
+
+	moved, err := Transpose(ps, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []Playable{
+		Note{Name: "B", Octave: 4, Fret: 7, String: 0, Time: 0},
+		Articulate(Slide{FretStart: -1, FretEnd: 7, String: 1, Time: 1}, Vibrato),
+		ArtificialHarmonic{Fret: 2, Offset: 12, String: 5, Time: 2},
+		NewLegato(7, 3, 3).HammerOn(9, 0.1).Vibrato(0.2),
+		NewChord("Bm", []int{2, 3, 4, 4, 2, ChordMuted}, 4),
+		DeadNote{String: 4, Time: 5},
+	}, moved)
+
+	_, err = Transpose(ps, -1)
+	assert.Error(t, err)
+}
+
+func TestTransposeChordName(t *testing.T) {
+	testCases := []struct {
+		name      string
+		semitones int
+		expected  string
+	}{
+		{name: "C", semitones: 2, expected: "D"},
+		{name: "F#m7/C#", semitones: 1, expected: "Gm7/D"},
+		{name: "Bb", semitones: 2, expected: "C"},
+		{name: "Bb", semitones: 1, expected: "B"},
+		{name: "Eb", semitones: 1, expected: "E"},
+		{name: "Ab7", semitones: -1, expected: "G7"},
+		{name: "Db", semitones: 1, expected: "D"},
+		{name: "Gbmaj7", semitones: 2, expected: "Abmaj7"},
+		{name: "C6/9", semitones: -3, expected: "A6/9"},
+		{name: "A", semitones: 12, expected: "A"},
+		{name: "C♯", semitones: 2, expected: "D#"},
+		{name: "B♭m", semitones: 2, expected: "Cm"},
+		{name: "A♭7/E♭", semitones: -1, expected: "G7/D"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			name, err := TransposeChordName(tc.name, tc.semitones)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, name)
+		})
+	}
+
+	_, err := TransposeChordName("N.C.", 1)
+	assert.Error(t, err)
+}
+
+func TestTransposeKey(t *testing.T) {
+	key, err := transposeKey("C", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Db", key)
+
+	key, err = transposeKey("Am", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bm", key)
+
+	key, err = transposeKey("F# minor", -1)
+	assert.NoError(t, err)
+	assert.Equal(t, "F minor", key)
+
+	_, err = transposeKey("H", 1)
+	assert.Error(t, err)
+}
@@ -53,3 +53,23 @@ func ParseTuning(notes string) (Tuning, error) {
 
 	return tuning, nil
 }
+
+// NoteAt returns the note sounding on the string at fret.
+func (t Tuning) NoteAt(stringNumber, fret int) (Note, error) {
+	if stringNumber < 0 || stringNumber >= len(t) {
+		return Note{}, fmt.Errorf("invalid string index %d, tuning has %d strings", stringNumber, len(t))
+	}
+	if fret < 0 {
+		return Note{}, fmt.Errorf("invalid fret %d", fret)
+	}
+
+	note := t[stringNumber]
+	note.String = stringNumber
+	note.Fret = 0
+	for range fret {
+		if err := note.AddFret(); err != nil {
+			return Note{}, err
+		}
+	}
+	return note, nil
+}